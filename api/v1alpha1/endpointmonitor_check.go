@@ -0,0 +1,93 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strconv"
+	"strings"
+)
+
+// GetType returns the type of the check, falling back to Keyword if a keyword is set and to HTTP otherwise
+func (c *CheckConfig) GetType() string {
+	if c == nil {
+		return CheckTypeHTTP
+	}
+	if len(c.Type) != 0 {
+		return c.Type
+	}
+	if c.Keyword != nil && len(c.Keyword.Value) != 0 {
+		return CheckTypeKeyword
+	}
+	return CheckTypeHTTP
+}
+
+// GetIntervalSeconds returns the interval of the check in seconds, or 0 if it isn't set
+func (c *CheckConfig) GetIntervalSeconds() int {
+	if c == nil || c.Interval == nil {
+		return 0
+	}
+	return int(c.Interval.Seconds())
+}
+
+// GetTimeoutSeconds returns the timeout of the check in seconds, or 0 if it isn't set
+func (c *CheckConfig) GetTimeoutSeconds() int {
+	if c == nil || c.Timeout == nil {
+		return 0
+	}
+	return int(c.Timeout.Seconds())
+}
+
+// HasExpectedStatusCodes returns true if the status codes treated as up are set
+func (c *CheckConfig) HasExpectedStatusCodes() bool {
+	return c != nil && len(c.ExpectedStatusCodes) != 0
+}
+
+// IsExpectedStatusCode returns true if the given status code is treated as up
+func (c *CheckConfig) IsExpectedStatusCode(code int) bool {
+	if c == nil {
+		return false
+	}
+	for _, statusCodes := range c.ExpectedStatusCodes {
+		from, to := statusCodes.Bounds()
+		if code >= from && code <= to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsKeywordAbsent returns true if the check succeeds when the keyword is absent from the response body
+func (k *KeywordCheck) IsKeywordAbsent() bool {
+	return k != nil && k.Condition == KeywordAbsent
+}
+
+// Bounds returns the first and last status code of the range. Both are 0 if the range is invalid.
+func (r StatusCodeRange) Bounds() (int, int) {
+	parts := strings.SplitN(string(r), "-", 2)
+	from, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0
+	}
+	if len(parts) == 1 {
+		return from, from
+	}
+	to, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, 0
+	}
+	return from, to
+}
@@ -17,6 +17,11 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -32,14 +37,28 @@ type EndpointMonitorSpec struct {
 	// +optional
 	HealthEndpoint string `json:"healthEndpoint,omitempty"`
 
-	// Comma separated list of providers
+	// Names of the configured providers to register the monitor with. The monitor is registered
+	// with all configured providers if empty. Removing a provider removes its remote monitor.
+	// A comma separated string of names, as used by previous versions, is accepted as well.
 	// +optional
-	Providers string `json:"providers"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Providers ProviderNames `json:"providers,omitempty"`
 
 	// URL to monitor from either an ingress or route reference
 	// +optional
 	URLFrom *URLSource `json:"urlFrom,omitempty"`
 
+	// Secret in the namespace of the EndpointMonitor holding the providers to register the monitor with,
+	// instead of the providers configured for the controller
+	// +optional
+	CredentialsRef *CredentialsReference `json:"credentialsRef,omitempty"`
+
+	// Provider agnostic check configuration, mapped onto the native API of every provider.
+	// Provider specific configuration takes precedence over it.
+	// +optional
+	Check *CheckConfig `json:"check,omitempty"`
+
 	// Configuration for UptimeRobot Monitor Provider
 	// +optional
 	UptimeRobotConfig *UptimeRobotConfig `json:"uptimeRobotConfig,omitempty"`
@@ -69,6 +88,81 @@ type EndpointMonitorSpec struct {
 	GCloudConfig *GCloudConfig `json:"gcloudConfig,omitempty"`
 }
 
+const (
+	// CheckTypeHTTP checks that the URL responds with an expected status code
+	CheckTypeHTTP = "HTTP"
+	// CheckTypeKeyword checks that a keyword is present or absent in the response body
+	CheckTypeKeyword = "Keyword"
+	// CheckTypeTCP checks that a TCP connection to the host and port of the URL can be opened
+	CheckTypeTCP = "TCP"
+
+	// KeywordPresent means the check succeeds if the keyword is present in the response body
+	KeywordPresent = "Present"
+	// KeywordAbsent means the check succeeds if the keyword is absent from the response body
+	KeywordAbsent = "Absent"
+)
+
+// CheckConfig defines a provider agnostic check. Providers ignore the settings they don't support.
+type CheckConfig struct {
+	// Type of the check, defaults to Keyword if a keyword is set and to HTTP otherwise
+	// +kubebuilder:validation:Enum=HTTP;Keyword;TCP
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Interval between two checks, rounded to the closest interval supported by the provider
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Time to wait for a response before the check fails
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// HTTP method of the request
+	// +kubebuilder:validation:Enum=GET;HEAD;POST;PUT;PATCH;DELETE;OPTIONS
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// HTTP status codes treated as up, e.g. ["200-299", "401"]
+	// +optional
+	ExpectedStatusCodes []StatusCodeRange `json:"expectedStatusCodes,omitempty"`
+
+	// Keyword to look for in the response body
+	// +optional
+	Keyword *KeywordCheck `json:"keyword,omitempty"`
+
+	// Additional request headers
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Basic authentication of the request
+	// +optional
+	BasicAuth *BasicAuth `json:"basicAuth,omitempty"`
+}
+
+// StatusCodeRange is a single HTTP status code, e.g. "200", or an inclusive range of codes, e.g. "200-299"
+// +kubebuilder:validation:Pattern=`^[1-5][0-9][0-9](-[1-5][0-9][0-9])?$`
+type StatusCodeRange string
+
+// KeywordCheck defines a keyword to look for in the response body
+type KeywordCheck struct {
+	// Keyword to look for
+	Value string `json:"value"`
+
+	// The check succeeds if the keyword is Present (default) or Absent
+	// +kubebuilder:validation:Enum=Present;Absent
+	// +optional
+	Condition string `json:"condition,omitempty"`
+}
+
+// BasicAuth defines the credentials of the basic authentication
+type BasicAuth struct {
+	// Basic auth user
+	Username string `json:"username"`
+
+	// Key of the secret in the namespace of the EndpointMonitor that holds the password
+	PasswordSecretRef corev1.SecretKeySelector `json:"passwordSecretRef"`
+}
+
 // UptimeRobotConfig defines the configuration for UptimeRobot Monitor Provider
 type UptimeRobotConfig struct {
 	// The uptimerobot alertContacts to be associated with this monitor
@@ -296,17 +390,73 @@ type GCloudConfig struct {
 	ProjectId string `json:"projectId,omitempty"`
 }
 
+// ProviderNames holds the names of the providers of an EndpointMonitor. EndpointMonitors created before providers
+// became a list store them as a comma separated string, which is decoded as a list so that they can still be read.
+// They are stored as a list when they are updated.
+type ProviderNames []string
+
+// UnmarshalJSON decodes a list of names or a comma separated string of names
+func (n *ProviderNames) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err == nil {
+		*n = names
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("providers must be a list of names or a comma separated string of names, got %s", data)
+	}
+	names = nil
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); len(name) != 0 {
+			names = append(names, name)
+		}
+	}
+	*n = names
+	return nil
+}
+
+// HasProvider returns true if the monitor should be registered with the given provider
+func (s *EndpointMonitorSpec) HasProvider(provider string) bool {
+	if len(s.Providers) == 0 {
+		return true
+	}
+	for _, name := range s.Providers {
+		if name == provider {
+			return true
+		}
+	}
+	return false
+}
+
 // URLSource represents the set of resources to fetch the URL from
 type URLSource struct {
 	// +optional
 	IngressRef *IngressURLSource `json:"ingressRef,omitempty"`
 	// +optional
 	RouteRef *RouteURLSource `json:"routeRef,omitempty"`
+	// +optional
+	HTTPRouteRef *HTTPRouteURLSource `json:"httpRouteRef,omitempty"`
+	// +optional
+	ServiceRef *ServiceURLSource `json:"serviceRef,omitempty"`
 }
 
 // IngressURLSource selects an Ingress to populate the URL with
 type IngressURLSource struct {
 	Name string `json:"name"`
+
+	// Host of the rule to monitor, defaults to the host of the first rule
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// Path of the rule to monitor, defaults to the first path of the rule
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Monitor every host and path of the Ingress with a monitor of its own. Host and Path are ignored.
+	// +optional
+	AllRules bool `json:"allRules,omitempty"`
 }
 
 // RouteURLSource selects a Route to populate the URL with
@@ -314,14 +464,248 @@ type RouteURLSource struct {
 	Name string `json:"name"`
 }
 
+// HTTPRouteURLSource selects a Gateway API HTTPRoute to populate the URL with
+type HTTPRouteURLSource struct {
+	Name string `json:"name"`
+}
+
+const (
+	ServiceSchemeTCP   = "tcp"
+	ServiceSchemeHTTP  = "http"
+	ServiceSchemeHTTPS = "https"
+)
+
+// ServiceURLSource selects a Service of type LoadBalancer to populate the URL with. The URL is built from the
+// address of its load balancer.
+type ServiceURLSource struct {
+	Name string `json:"name"`
+
+	// Name or number of the port of the Service, defaults to its first port
+	// +optional
+	Port string `json:"port,omitempty"`
+
+	// Scheme of the URL. Defaults to http or https if the app protocol or the name of the port starts with it,
+	// and to tcp otherwise.
+	// +kubebuilder:validation:Enum=tcp;http;https
+	// +optional
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// DefaultCredentialsKey is the key of the credentials secret holding the providers if none is specified
+const DefaultCredentialsKey = "config.yaml"
+
+// CredentialsReference selects a Secret holding providers in the format of the controller configuration
+type CredentialsReference struct {
+	// Name of the Secret
+	Name string `json:"name"`
+
+	// Key of the Secret holding the providers, defaults to config.yaml
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// GetKey returns the key of the Secret holding the providers
+func (r *CredentialsReference) GetKey() string {
+	if len(r.Key) == 0 {
+		return DefaultCredentialsKey
+	}
+	return r.Key
+}
+
+const (
+	// ConditionTypeReady indicates that the monitor has been synced with all of its providers
+	ConditionTypeReady = "Ready"
+
+	// ConditionTypeSynced indicates that the monitor has been synced with a single provider
+	ConditionTypeSynced = "Synced"
+)
+
+const (
+	// EndpointMonitorFinalizer is added to every EndpointMonitor so that its remote monitors
+	// are removed before the object is deleted
+	EndpointMonitorFinalizer = "endpointmonitor.stakater.com/finalizer"
+
+	// OrphanAnnotation keeps the remote monitors at the providers when set to "true" on a deleted EndpointMonitor
+	OrphanAnnotation = "endpointmonitor.stakater.com/orphan"
+)
+
+// Annotations of Ingresses and Routes to generate an EndpointMonitor for them
+const (
+	// EnabledAnnotation generates an EndpointMonitor for the Ingress or Route when set to "true"
+	EnabledAnnotation = "endpointmonitor.stakater.com/enabled"
+
+	// ProvidersAnnotation holds a comma separated list of the providers of the generated EndpointMonitor
+	ProvidersAnnotation = "endpointmonitor.stakater.com/providers"
+
+	// CredentialsRefAnnotation holds the name of the credentials secret of the generated EndpointMonitor
+	CredentialsRefAnnotation = "endpointmonitor.stakater.com/credentials-ref"
+
+	// ForceHTTPSAnnotation forces the generated EndpointMonitor to use HTTPS when set to "true"
+	ForceHTTPSAnnotation = "endpointmonitor.stakater.com/force-https"
+
+	// CheckAnnotation holds the check configuration of the generated EndpointMonitor as JSON
+	CheckAnnotation = "endpointmonitor.stakater.com/check"
+
+	// Annotations holding the provider specific configuration of the generated EndpointMonitor as JSON
+	UptimeRobotConfigAnnotation = "endpointmonitor.stakater.com/uptimerobot-config"
+	UptimeConfigAnnotation      = "endpointmonitor.stakater.com/uptime-config"
+	UpdownConfigAnnotation      = "endpointmonitor.stakater.com/updown-config"
+	StatusCakeConfigAnnotation  = "endpointmonitor.stakater.com/statuscake-config"
+	PingdomConfigAnnotation     = "endpointmonitor.stakater.com/pingdom-config"
+	AppInsightsConfigAnnotation = "endpointmonitor.stakater.com/appinsights-config"
+	GCloudConfigAnnotation      = "endpointmonitor.stakater.com/gcloud-config"
+)
+
+// Annotations of Ingresses, Routes and HTTPRoutes overriding the URL discovered for them. They take precedence over
+// the health endpoint discovered from the pods, but not over the healthEndpoint of the EndpointMonitor.
+const (
+	// HealthPathAnnotation holds the path of the health endpoint, e.g. /healthz/public
+	HealthPathAnnotation = "endpointmonitor.stakater.com/health-path"
+
+	// SchemeAnnotation forces the scheme of the URL, either http or https
+	SchemeAnnotation = "endpointmonitor.stakater.com/scheme"
+
+	// QueryAnnotation holds a query string appended to the URL, e.g. probe=external
+	QueryAnnotation = "endpointmonitor.stakater.com/query"
+)
+
 // EndpointMonitorStatus defines the observed state of EndpointMonitor
 type EndpointMonitorStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// The generation observed by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// State of the monitor at each provider
+	// +optional
+	Monitors []MonitorStatus `json:"monitors,omitempty"`
+
+	// Conditions of the EndpointMonitor, aggregated over all providers
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Health endpoints of the URLs discovered from the URL source
+	// +optional
+	HealthEndpoints []HealthEndpointStatus `json:"healthEndpoints,omitempty"`
+}
+
+// HealthEndpointStatus defines how the health endpoint of a URL discovered from the URL source has been found
+type HealthEndpointStatus struct {
+	// Host and path monitored, only set if the URL source resolves to several targets
+	// +optional
+	Target string `json:"target,omitempty"`
+
+	// Path of the health endpoint, empty if none has been found
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Reason is one of Configured, Annotation, ReadinessProbe, LivenessProbe, StartupProbe or NotFound
+	Reason string `json:"reason"`
+
+	// Human readable details, e.g. the container and pod the probe was taken from
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// MonitorStatus defines the observed state of the monitor at a single provider
+type MonitorStatus struct {
+	// Name of the provider instance the monitor is registered with
+	Provider string `json:"provider"`
+
+	// Host and path monitored, only set if the URL source resolves to several targets
+	// +optional
+	Target string `json:"target,omitempty"`
+
+	// Name of the monitor at the provider
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// ID of the monitor at the provider
+	// +optional
+	ID string `json:"id,omitempty"`
+
+	// URL monitored by the provider
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Last time the monitor was successfully synced with the provider
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Conditions of the monitor at the provider
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// State of the check reported by the provider, only set if the status exporter is enabled
+	// +optional
+	CheckStatus *CheckStatus `json:"checkStatus,omitempty"`
+}
+
+// CheckStatus defines the state of the remote check as reported by the provider
+type CheckStatus struct {
+	// State of the check, one of Up, Down, Paused or Unknown
+	State string `json:"state"`
+
+	// Response time of the last check in milliseconds
+	// +optional
+	ResponseTimeMilliseconds *int64 `json:"responseTimeMilliseconds,omitempty"`
+
+	// Ratio of the time the check was up over the period reported by the provider, between 0 and 1
+	// +optional
+	UptimeRatio string `json:"uptimeRatio,omitempty"`
+
+	// Last time the state was retrieved from the provider
+	LastPollTime metav1.Time `json:"lastPollTime"`
+}
+
+// GetMonitorStatus returns the status of the monitor of the target registered with the given provider or nil if
+// there is none
+func (s *EndpointMonitorStatus) GetMonitorStatus(provider string, target string) *MonitorStatus {
+	for index := range s.Monitors {
+		if s.Monitors[index].Provider == provider && s.Monitors[index].Target == target {
+			return &s.Monitors[index]
+		}
+	}
+	return nil
+}
+
+// GetMonitorStatuses returns the status of all monitors registered with the given provider
+func (s *EndpointMonitorStatus) GetMonitorStatuses(provider string) []MonitorStatus {
+	var statuses []MonitorStatus
+	for index := range s.Monitors {
+		if s.Monitors[index].Provider == provider {
+			statuses = append(statuses, s.Monitors[index])
+		}
+	}
+	return statuses
+}
+
+// RemoveMonitorStatus removes the status of the monitor of the target registered with the given provider
+func (s *EndpointMonitorStatus) RemoveMonitorStatus(provider string, target string) {
+	for index := range s.Monitors {
+		if s.Monitors[index].Provider == provider && s.Monitors[index].Target == target {
+			s.Monitors = append(s.Monitors[:index], s.Monitors[index+1:]...)
+			return
+		}
+	}
+}
+
+// SetMonitorStatus adds or replaces the status of the monitor registered with the provider of the given status
+func (s *EndpointMonitorStatus) SetMonitorStatus(status MonitorStatus) {
+	if existing := s.GetMonitorStatus(status.Provider, status.Target); existing != nil {
+		*existing = status
+		return
+	}
+	s.Monitors = append(s.Monitors, status)
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.monitors[0].url`
+//+kubebuilder:printcolumn:name="Providers",type=string,JSONPath=`.status.monitors[*].provider`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+//+kubebuilder:printcolumn:name="Check",type=string,JSONPath=`.status.monitors[*].checkStatus.state`,priority=1
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // EndpointMonitor is the Schema for the endpointmonitors API
 type EndpointMonitor struct {
@@ -0,0 +1,42 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestProviderNamesUnmarshalJSON(t *testing.T) {
+	for data, expected := range map[string]ProviderNames{
+		`{"providers": ["UptimeRobot", "Pingdom"]}`: {"UptimeRobot", "Pingdom"},
+		`{"providers": "UptimeRobot"}`:              {"UptimeRobot"},
+		`{"providers": "UptimeRobot, Pingdom,"}`:    {"UptimeRobot", "Pingdom"},
+		`{"providers": ""}`:                         nil,
+		`{"providers": null}`:                       nil,
+		`{}`:                                        nil,
+	} {
+		var spec EndpointMonitorSpec
+		if err := json.Unmarshal([]byte(data), &spec); err != nil {
+			t.Errorf("Unexpected error for %s: %v", data, err)
+			continue
+		}
+		if !reflect.DeepEqual(spec.Providers, expected) {
+			t.Errorf("Expected providers %v for %s, got %v", expected, data, spec.Providers)
+		}
+	}
+
+	var spec EndpointMonitorSpec
+	if err := json.Unmarshal([]byte(`{"providers": 5}`), &spec); err == nil {
+		t.Error("Expected an error for providers that are neither a list nor a string")
+	}
+}
+
+func TestProviderNamesMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(EndpointMonitorSpec{Providers: ProviderNames{"UptimeRobot"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"providers":["UptimeRobot"]}` {
+		t.Errorf("Expected providers to be stored as a list, got %s", data)
+	}
+}
@@ -0,0 +1,329 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Provider types supported by ProviderConfig
+const (
+	ProviderTypeUptimeRobot = "UptimeRobot"
+	ProviderTypePingdom     = "Pingdom"
+	ProviderTypeStatusCake  = "StatusCake"
+	ProviderTypeUptime      = "Uptime"
+	ProviderTypeUpdown      = "Updown"
+	ProviderTypeAppInsights = "AppInsights"
+	ProviderTypeGCloud      = "gcloud"
+)
+
+// ProviderConfigSpec defines the desired state of ProviderConfig. Only the configuration of the given type is used.
+type ProviderConfigSpec struct {
+	// Type of the provider
+	// +kubebuilder:validation:Enum=UptimeRobot;Pingdom;StatusCake;Uptime;Updown;AppInsights;gcloud
+	Type string `json:"type"`
+
+	// Configuration for UptimeRobot
+	// +optional
+	UptimeRobot *UptimeRobotProviderConfig `json:"uptimeRobot,omitempty"`
+
+	// Configuration for Pingdom
+	// +optional
+	Pingdom *PingdomProviderConfig `json:"pingdom,omitempty"`
+
+	// Configuration for StatusCake
+	// +optional
+	StatusCake *StatusCakeProviderConfig `json:"statusCake,omitempty"`
+
+	// Configuration for Uptime
+	// +optional
+	Uptime *UptimeProviderConfig `json:"uptime,omitempty"`
+
+	// Configuration for Updown
+	// +optional
+	Updown *UpdownProviderConfig `json:"updown,omitempty"`
+
+	// Configuration for AppInsights
+	// +optional
+	AppInsights *AppInsightsProviderConfig `json:"appInsights,omitempty"`
+
+	// Configuration for Google Cloud Monitoring
+	// +optional
+	GCloud *GCloudProviderConfig `json:"gcloud,omitempty"`
+
+	// Timeouts, retries and rate limit of the requests to the API of the provider
+	// +optional
+	HTTP *HTTPClientConfig `json:"http,omitempty"`
+}
+
+// HTTPClientConfig configures the requests to the API of a provider
+type HTTPClientConfig struct {
+	// Timeout of a single attempt of a request, defaults to 30s
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Retries of a request that has been rate limited or failed with a server error, defaults to 3
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+
+	// Requests per minute sent to the provider, requests are not limited if unset
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RequestsPerMinute int32 `json:"requestsPerMinute,omitempty"`
+
+	// Requests that may be sent at once within the rate limit, defaults to 1
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Burst int32 `json:"burst,omitempty"`
+
+	// URL of the HTTP(S) proxy, defaults to the proxy of the controller configuration
+	// +optional
+	Proxy string `json:"proxy,omitempty"`
+
+	// Secret key holding the URL of the proxy, e.g. with the credentials of an authenticating proxy. It takes
+	// precedence over proxy.
+	// +optional
+	ProxySecretRef *SecretKeyReference `json:"proxySecretRef,omitempty"`
+
+	// Hosts, domains and CIDRs reached without proxy
+	// +optional
+	NoProxy []string `json:"noProxy,omitempty"`
+
+	// PEM encoded certificate authorities trusted in addition to the system ones and those of the controller
+	// configuration
+	// +optional
+	CABundles []CABundleReference `json:"caBundles,omitempty"`
+
+	// kubernetes.io/tls Secret holding the client certificate presented for mTLS
+	// +optional
+	ClientCertificateSecretRef *SecretReference `json:"clientCertificateSecretRef,omitempty"`
+}
+
+// CABundleReference selects PEM encoded certificate authorities in a Secret or a ConfigMap
+type CABundleReference struct {
+	// Secret key holding the certificates
+	// +optional
+	SecretKeyRef *SecretKeyReference `json:"secretKeyRef,omitempty"`
+
+	// ConfigMap key holding the certificates
+	// +optional
+	ConfigMapKeyRef *ConfigMapKeyReference `json:"configMapKeyRef,omitempty"`
+}
+
+// ConfigMapKeyReference selects a key of a ConfigMap
+type ConfigMapKeyReference struct {
+	// Name of the ConfigMap
+	Name string `json:"name"`
+
+	// Namespace of the ConfigMap, defaults to the namespace of the operator
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Key of the ConfigMap
+	Key string `json:"key"`
+}
+
+// SecretReference selects a Secret
+type SecretReference struct {
+	// Name of the Secret
+	Name string `json:"name"`
+
+	// Namespace of the Secret, defaults to the namespace of the operator
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// SecretKeyReference selects a key of a Secret
+type SecretKeyReference struct {
+	// Name of the Secret
+	Name string `json:"name"`
+
+	// Namespace of the Secret, defaults to the namespace of the operator
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Key of the Secret
+	Key string `json:"key"`
+}
+
+// UptimeRobotProviderConfig defines the account of an UptimeRobot provider
+type UptimeRobotProviderConfig struct {
+	// Secret key holding the API key
+	APIKeySecretRef SecretKeyReference `json:"apiKeySecretRef"`
+
+	// Base URL of the API
+	// +kubebuilder:default="https://api.uptimerobot.com/v2/"
+	// +optional
+	APIURL string `json:"apiURL,omitempty"`
+
+	// Alert contacts of new monitors, e.g. 0544483_0_0-2628365_0_0
+	// +optional
+	AlertContacts string `json:"alertContacts,omitempty"`
+}
+
+// PingdomProviderConfig defines the account of a Pingdom provider
+type PingdomProviderConfig struct {
+	// Secret key holding the API token
+	APITokenSecretRef SecretKeyReference `json:"apiTokenSecretRef"`
+
+	// Base URL of the API
+	// +kubebuilder:default="https://api.pingdom.com/api/3.1"
+	// +optional
+	APIURL string `json:"apiURL,omitempty"`
+
+	// Alert contacts of new monitors
+	// +optional
+	AlertContacts string `json:"alertContacts,omitempty"`
+
+	// Alert integrations of new monitors
+	// +optional
+	AlertIntegrations string `json:"alertIntegrations,omitempty"`
+
+	// Team alert contacts of new monitors
+	// +optional
+	TeamAlertContacts string `json:"teamAlertContacts,omitempty"`
+}
+
+// StatusCakeProviderConfig defines the account of a StatusCake provider
+type StatusCakeProviderConfig struct {
+	// Secret key holding the API key
+	APIKeySecretRef SecretKeyReference `json:"apiKeySecretRef"`
+
+	// Base URL of the API
+	// +kubebuilder:default="https://api.statuscake.com/v1/uptime"
+	// +optional
+	APIURL string `json:"apiURL,omitempty"`
+
+	// Username of the account
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// Contact groups of new monitors
+	// +optional
+	AlertContacts string `json:"alertContacts,omitempty"`
+}
+
+// UptimeProviderConfig defines the account of an Uptime provider
+type UptimeProviderConfig struct {
+	// Secret key holding the API key
+	APIKeySecretRef SecretKeyReference `json:"apiKeySecretRef"`
+
+	// Base URL of the API
+	// +kubebuilder:default="https://uptime.com/api/v1/"
+	// +optional
+	APIURL string `json:"apiURL,omitempty"`
+
+	// Alert contacts of new monitors
+	// +optional
+	AlertContacts string `json:"alertContacts,omitempty"`
+}
+
+// UpdownProviderConfig defines the account of an Updown provider
+type UpdownProviderConfig struct {
+	// Secret key holding the API key
+	APIKeySecretRef SecretKeyReference `json:"apiKeySecretRef"`
+}
+
+// AppInsightsProviderConfig defines the Application Insights component of an AppInsights provider.
+// The Azure credentials are read from the AZURE_* environment variables of the operator.
+type AppInsightsProviderConfig struct {
+	// Name of the Application Insights component
+	Name string `json:"name"`
+
+	// Location of the component
+	Location string `json:"location"`
+
+	// Resource group of the component
+	ResourceGroup string `json:"resourceGroup"`
+
+	// Frequency of the WebTests in seconds
+	// +optional
+	Frequency int32 `json:"frequency,omitempty"`
+
+	// Locations to run the WebTests from
+	// +optional
+	GeoLocation []string `json:"geoLocation,omitempty"`
+
+	// Send alert emails to the service owners
+	// +optional
+	EmailToServiceOwners bool `json:"emailToServiceOwners,omitempty"`
+
+	// Additional emails to send alerts to
+	// +optional
+	CustomEmails []string `json:"customEmails,omitempty"`
+
+	// Webhook to send alerts to
+	// +optional
+	WebhookServiceURI string `json:"webhookServiceURI,omitempty"`
+}
+
+// GCloudProviderConfig defines the project of a Google Cloud Monitoring provider
+type GCloudProviderConfig struct {
+	// Secret key holding the JSON credentials of a service account
+	CredentialsSecretRef SecretKeyReference `json:"credentialsSecretRef"`
+
+	// ID of the project
+	ProjectID string `json:"projectId"`
+}
+
+const (
+	// ConditionTypeCredentialsValid indicates that the provider accepted the credentials
+	ConditionTypeCredentialsValid = "CredentialsValid"
+)
+
+// ProviderConfigStatus defines the observed state of ProviderConfig
+type ProviderConfigStatus struct {
+	// The generation observed by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions of the ProviderConfig
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Credentials",type=string,JSONPath=`.status.conditions[?(@.type=="CredentialsValid")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ProviderConfig is the Schema for the providerconfigs API. Its name is the name of the provider referenced
+// by EndpointMonitors.
+type ProviderConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProviderConfigSpec   `json:"spec,omitempty"`
+	Status ProviderConfigStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ProviderConfigList contains a list of ProviderConfig
+type ProviderConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProviderConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProviderConfig{}, &ProviderConfigList{})
+}
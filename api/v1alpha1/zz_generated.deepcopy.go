@@ -22,6 +22,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -40,13 +41,177 @@ func (in *AppInsightsConfig) DeepCopy() *AppInsightsConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppInsightsProviderConfig) DeepCopyInto(out *AppInsightsProviderConfig) {
+	*out = *in
+	if in.GeoLocation != nil {
+		in, out := &in.GeoLocation, &out.GeoLocation
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomEmails != nil {
+		in, out := &in.CustomEmails, &out.CustomEmails
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppInsightsProviderConfig.
+func (in *AppInsightsProviderConfig) DeepCopy() *AppInsightsProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AppInsightsProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BasicAuth) DeepCopyInto(out *BasicAuth) {
+	*out = *in
+	in.PasswordSecretRef.DeepCopyInto(&out.PasswordSecretRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BasicAuth.
+func (in *BasicAuth) DeepCopy() *BasicAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(BasicAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CABundleReference) DeepCopyInto(out *CABundleReference) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(ConfigMapKeyReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CABundleReference.
+func (in *CABundleReference) DeepCopy() *CABundleReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CABundleReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheckConfig) DeepCopyInto(out *CheckConfig) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ExpectedStatusCodes != nil {
+		in, out := &in.ExpectedStatusCodes, &out.ExpectedStatusCodes
+		*out = make([]StatusCodeRange, len(*in))
+		copy(*out, *in)
+	}
+	if in.Keyword != nil {
+		in, out := &in.Keyword, &out.Keyword
+		*out = new(KeywordCheck)
+		**out = **in
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.BasicAuth != nil {
+		in, out := &in.BasicAuth, &out.BasicAuth
+		*out = new(BasicAuth)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheckConfig.
+func (in *CheckConfig) DeepCopy() *CheckConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CheckConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheckStatus) DeepCopyInto(out *CheckStatus) {
+	*out = *in
+	if in.ResponseTimeMilliseconds != nil {
+		in, out := &in.ResponseTimeMilliseconds, &out.ResponseTimeMilliseconds
+		*out = new(int64)
+		**out = **in
+	}
+	in.LastPollTime.DeepCopyInto(&out.LastPollTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheckStatus.
+func (in *CheckStatus) DeepCopy() *CheckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CheckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapKeyReference) DeepCopyInto(out *ConfigMapKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapKeyReference.
+func (in *ConfigMapKeyReference) DeepCopy() *ConfigMapKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsReference) DeepCopyInto(out *CredentialsReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsReference.
+func (in *CredentialsReference) DeepCopy() *CredentialsReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EndpointMonitor) DeepCopyInto(out *EndpointMonitor) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointMonitor.
@@ -102,11 +267,26 @@ func (in *EndpointMonitorList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EndpointMonitorSpec) DeepCopyInto(out *EndpointMonitorSpec) {
 	*out = *in
+	if in.Providers != nil {
+		in, out := &in.Providers, &out.Providers
+		*out = make(ProviderNames, len(*in))
+		copy(*out, *in)
+	}
 	if in.URLFrom != nil {
 		in, out := &in.URLFrom, &out.URLFrom
 		*out = new(URLSource)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(CredentialsReference)
+		**out = **in
+	}
+	if in.Check != nil {
+		in, out := &in.Check, &out.Check
+		*out = new(CheckConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.UptimeRobotConfig != nil {
 		in, out := &in.UptimeRobotConfig, &out.UptimeRobotConfig
 		*out = new(UptimeRobotConfig)
@@ -157,6 +337,25 @@ func (in *EndpointMonitorSpec) DeepCopy() *EndpointMonitorSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EndpointMonitorStatus) DeepCopyInto(out *EndpointMonitorStatus) {
 	*out = *in
+	if in.Monitors != nil {
+		in, out := &in.Monitors, &out.Monitors
+		*out = make([]MonitorStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HealthEndpoints != nil {
+		in, out := &in.HealthEndpoints, &out.HealthEndpoints
+		*out = make([]HealthEndpointStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointMonitorStatus.
@@ -184,6 +383,99 @@ func (in *GCloudConfig) DeepCopy() *GCloudConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCloudProviderConfig) DeepCopyInto(out *GCloudProviderConfig) {
+	*out = *in
+	out.CredentialsSecretRef = in.CredentialsSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCloudProviderConfig.
+func (in *GCloudProviderConfig) DeepCopy() *GCloudProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GCloudProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPClientConfig) DeepCopyInto(out *HTTPClientConfig) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ProxySecretRef != nil {
+		in, out := &in.ProxySecretRef, &out.ProxySecretRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CABundles != nil {
+		in, out := &in.CABundles, &out.CABundles
+		*out = make([]CABundleReference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClientCertificateSecretRef != nil {
+		in, out := &in.ClientCertificateSecretRef, &out.ClientCertificateSecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPClientConfig.
+func (in *HTTPClientConfig) DeepCopy() *HTTPClientConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPClientConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPRouteURLSource) DeepCopyInto(out *HTTPRouteURLSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPRouteURLSource.
+func (in *HTTPRouteURLSource) DeepCopy() *HTTPRouteURLSource {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPRouteURLSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthEndpointStatus) DeepCopyInto(out *HealthEndpointStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthEndpointStatus.
+func (in *HealthEndpointStatus) DeepCopy() *HealthEndpointStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthEndpointStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressURLSource) DeepCopyInto(out *IngressURLSource) {
 	*out = *in
@@ -199,6 +491,52 @@ func (in *IngressURLSource) DeepCopy() *IngressURLSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeywordCheck) DeepCopyInto(out *KeywordCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeywordCheck.
+func (in *KeywordCheck) DeepCopy() *KeywordCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(KeywordCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitorStatus) DeepCopyInto(out *MonitorStatus) {
+	*out = *in
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CheckStatus != nil {
+		in, out := &in.CheckStatus, &out.CheckStatus
+		*out = new(CheckStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitorStatus.
+func (in *MonitorStatus) DeepCopy() *MonitorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PingdomConfig) DeepCopyInto(out *PingdomConfig) {
 	*out = *in
@@ -214,6 +552,177 @@ func (in *PingdomConfig) DeepCopy() *PingdomConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PingdomProviderConfig) DeepCopyInto(out *PingdomProviderConfig) {
+	*out = *in
+	out.APITokenSecretRef = in.APITokenSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PingdomProviderConfig.
+func (in *PingdomProviderConfig) DeepCopy() *PingdomProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PingdomProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderConfig) DeepCopyInto(out *ProviderConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderConfig.
+func (in *ProviderConfig) DeepCopy() *ProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProviderConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderConfigList) DeepCopyInto(out *ProviderConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProviderConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderConfigList.
+func (in *ProviderConfigList) DeepCopy() *ProviderConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProviderConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderConfigSpec) DeepCopyInto(out *ProviderConfigSpec) {
+	*out = *in
+	if in.UptimeRobot != nil {
+		in, out := &in.UptimeRobot, &out.UptimeRobot
+		*out = new(UptimeRobotProviderConfig)
+		**out = **in
+	}
+	if in.Pingdom != nil {
+		in, out := &in.Pingdom, &out.Pingdom
+		*out = new(PingdomProviderConfig)
+		**out = **in
+	}
+	if in.StatusCake != nil {
+		in, out := &in.StatusCake, &out.StatusCake
+		*out = new(StatusCakeProviderConfig)
+		**out = **in
+	}
+	if in.Uptime != nil {
+		in, out := &in.Uptime, &out.Uptime
+		*out = new(UptimeProviderConfig)
+		**out = **in
+	}
+	if in.Updown != nil {
+		in, out := &in.Updown, &out.Updown
+		*out = new(UpdownProviderConfig)
+		**out = **in
+	}
+	if in.AppInsights != nil {
+		in, out := &in.AppInsights, &out.AppInsights
+		*out = new(AppInsightsProviderConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GCloud != nil {
+		in, out := &in.GCloud, &out.GCloud
+		*out = new(GCloudProviderConfig)
+		**out = **in
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPClientConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderConfigSpec.
+func (in *ProviderConfigSpec) DeepCopy() *ProviderConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderConfigStatus) DeepCopyInto(out *ProviderConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderConfigStatus.
+func (in *ProviderConfigStatus) DeepCopy() *ProviderConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in ProviderNames) DeepCopyInto(out *ProviderNames) {
+	{
+		in := &in
+		*out = make(ProviderNames, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderNames.
+func (in ProviderNames) DeepCopy() ProviderNames {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderNames)
+	in.DeepCopyInto(out)
+	return *out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RouteURLSource) DeepCopyInto(out *RouteURLSource) {
 	*out = *in
@@ -229,6 +738,51 @@ func (in *RouteURLSource) DeepCopy() *RouteURLSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
+func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReference) DeepCopyInto(out *SecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReference.
+func (in *SecretReference) DeepCopy() *SecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceURLSource) DeepCopyInto(out *ServiceURLSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceURLSource.
+func (in *ServiceURLSource) DeepCopy() *ServiceURLSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceURLSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatusCakeConfig) DeepCopyInto(out *StatusCakeConfig) {
 	*out = *in
@@ -244,6 +798,22 @@ func (in *StatusCakeConfig) DeepCopy() *StatusCakeConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatusCakeProviderConfig) DeepCopyInto(out *StatusCakeProviderConfig) {
+	*out = *in
+	out.APIKeySecretRef = in.APIKeySecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusCakeProviderConfig.
+func (in *StatusCakeProviderConfig) DeepCopy() *StatusCakeProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(StatusCakeProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *URLSource) DeepCopyInto(out *URLSource) {
 	*out = *in
@@ -257,6 +827,16 @@ func (in *URLSource) DeepCopyInto(out *URLSource) {
 		*out = new(RouteURLSource)
 		**out = **in
 	}
+	if in.HTTPRouteRef != nil {
+		in, out := &in.HTTPRouteRef, &out.HTTPRouteRef
+		*out = new(HTTPRouteURLSource)
+		**out = **in
+	}
+	if in.ServiceRef != nil {
+		in, out := &in.ServiceRef, &out.ServiceRef
+		*out = new(ServiceURLSource)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new URLSource.
@@ -284,6 +864,22 @@ func (in *UpdownConfig) DeepCopy() *UpdownConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdownProviderConfig) DeepCopyInto(out *UpdownProviderConfig) {
+	*out = *in
+	out.APIKeySecretRef = in.APIKeySecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdownProviderConfig.
+func (in *UpdownProviderConfig) DeepCopy() *UpdownProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdownProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UptimeConfig) DeepCopyInto(out *UptimeConfig) {
 	*out = *in
@@ -299,6 +895,22 @@ func (in *UptimeConfig) DeepCopy() *UptimeConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UptimeProviderConfig) DeepCopyInto(out *UptimeProviderConfig) {
+	*out = *in
+	out.APIKeySecretRef = in.APIKeySecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UptimeProviderConfig.
+func (in *UptimeProviderConfig) DeepCopy() *UptimeProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(UptimeProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UptimeRobotConfig) DeepCopyInto(out *UptimeRobotConfig) {
 	*out = *in
@@ -313,3 +925,19 @@ func (in *UptimeRobotConfig) DeepCopy() *UptimeRobotConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UptimeRobotProviderConfig) DeepCopyInto(out *UptimeRobotProviderConfig) {
+	*out = *in
+	out.APIKeySecretRef = in.APIKeySecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UptimeRobotProviderConfig.
+func (in *UptimeRobotProviderConfig) DeepCopy() *UptimeRobotProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(UptimeRobotProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -17,16 +17,22 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
@@ -36,6 +42,7 @@ import (
 	"github.com/stakater/IngressMonitorController/v2/pkg/controllers"
 	"github.com/stakater/IngressMonitorController/v2/pkg/kube"
 	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+	"github.com/stakater/IngressMonitorController/v2/pkg/webhooks"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -102,19 +109,154 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The controller starts in degraded mode if the configuration is invalid, the problems are
+	// reported by the config_degraded metric and as events on the config secret
+	configHealth := &config.Health{}
+	recorder := mgr.GetEventRecorderFor("ingressmonitorcontroller")
+
 	// Load Controller Config
-	config.LoadControllerConfig(mgr.GetAPIReader())
-	config := config.GetControllerConfig()
+	monitorServices := &monitors.MonitorServiceRegistry{}
+	if err = config.LoadControllerConfig(mgr.GetAPIReader()); err != nil {
+		setupLog.Error(err, "invalid controller configuration, starting in degraded mode")
+		configHealth.SetError(err)
+		reportConfigError(recorder, err)
+	} else if providers := config.GetControllerConfig().Providers; len(providers) != 0 {
+		// Valid providers keep working if others are invalid, providers can also be configured by ProviderConfigs only
+		services, err := monitors.SetupMonitorServicesForProviders(providers)
+		monitorServices.Set(services)
+		if err != nil {
+			setupLog.Error(err, "invalid providers in controller configuration, starting in degraded mode")
+			configHealth.SetProviderErrors(err, monitors.GetInvalidProviders(providers, services))
+			reportConfigError(recorder, err)
+		}
+	}
 
-	if err = (&controllers.EndpointMonitorReconciler{
+	configEvents := make(chan event.GenericEvent)
+
+	// ProviderConfigs are loaded before the manager starts so that no monitor is synced without its provider
+	providerConfigReconciler := &controllers.ProviderConfigReconciler{
 		Client:          mgr.GetClient(),
-		Log:             ctrl.Log.WithName("controllers").WithName("EndpointMonitor"),
-		Scheme:          mgr.GetScheme(),
-		MonitorServices: monitors.SetupMonitorServicesForProviders(config.Providers),
+		Log:             ctrl.Log.WithName("controllers").WithName("ProviderConfig"),
+		APIReader:       mgr.GetAPIReader(),
+		MonitorServices: monitorServices,
+		ConfigEvents:    configEvents,
+	}
+	if err = providerConfigReconciler.LoadProviderConfigs(context.Background()); err != nil {
+		// Loading the ProviderConfigs is retried once the manager is started
+		setupLog.Error(err, "unable to load ProviderConfigs, all providers are unavailable until they are loaded")
+	}
+	if err = providerConfigReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ProviderConfig")
+		os.Exit(1)
+	}
+	if err = mgr.Add(providerConfigReconciler); err != nil {
+		setupLog.Error(err, "unable to add ProviderConfig loader")
+		os.Exit(1)
+	}
+
+	// Monitor services built from credentials secrets are shared by the reconciler and the status exporter
+	credentialsCache := &monitors.MonitorServiceCache{}
+	if err = (&controllers.EndpointMonitorReconciler{
+		Client:           mgr.GetClient(),
+		Log:              ctrl.Log.WithName("controllers").WithName("EndpointMonitor"),
+		Scheme:           mgr.GetScheme(),
+		MonitorServices:  monitorServices,
+		ConfigHealth:     configHealth,
+		ConfigEvents:     configEvents,
+		APIReader:        mgr.GetAPIReader(),
+		CredentialsCache: credentialsCache,
+		Recorder:         recorder,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "EndpointMonitor")
 		os.Exit(1)
 	}
+
+	// EndpointMonitors are generated for annotated Ingresses and Routes
+	generator := controllers.EndpointMonitorGenerator{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("EndpointMonitorGenerator"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: recorder,
+	}
+	if err = (&controllers.IngressReconciler{EndpointMonitorGenerator: generator}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Ingress")
+		os.Exit(1)
+	}
+	if kube.IsOpenshift {
+		if err = (&controllers.RouteReconciler{EndpointMonitorGenerator: generator}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Route")
+			os.Exit(1)
+		}
+	}
+
+	if err = mgr.Add(&controllers.MonitorGarbageCollector{
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("MonitorGarbageCollector"),
+		MonitorServices: monitorServices,
+		ConfigHealth:    configHealth,
+	}); err != nil {
+		setupLog.Error(err, "unable to add monitor garbage collector")
+		os.Exit(1)
+	}
+
+	if err = mgr.Add(&controllers.MonitorMetricsRecorder{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("MonitorMetricsRecorder"),
+	}); err != nil {
+		setupLog.Error(err, "unable to add monitor metrics recorder")
+		os.Exit(1)
+	}
+
+	if err = mgr.Add(&controllers.MonitorStatusExporter{
+		Client:           mgr.GetClient(),
+		Log:              ctrl.Log.WithName("controllers").WithName("MonitorStatusExporter"),
+		MonitorServices:  monitorServices,
+		APIReader:        mgr.GetAPIReader(),
+		CredentialsCache: credentialsCache,
+	}); err != nil {
+		setupLog.Error(err, "unable to add monitor status exporter")
+		os.Exit(1)
+	}
+
+	// Reload the configuration when the config secret changes
+	if secretKey, err := config.GetConfigSecretKey(); err != nil {
+		setupLog.Error(err, "unable to watch config secret, configuration changes require a restart")
+	} else {
+		configCache, err := cache.New(mgr.GetConfig(), cache.Options{
+			Scheme:    mgr.GetScheme(),
+			Mapper:    mgr.GetRESTMapper(),
+			Namespace: secretKey.Namespace,
+			SelectorsByObject: cache.SelectorsByObject{
+				&corev1.Secret{}: {Field: fields.OneTermEqualSelector("metadata.name", secretKey.Name)},
+			},
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to create cache for config secret")
+			os.Exit(1)
+		}
+		if err = mgr.Add(&controllers.ConfigWatcher{
+			Client:          mgr.GetClient(),
+			Log:             ctrl.Log.WithName("controllers").WithName("ConfigWatcher"),
+			Recorder:        recorder,
+			MonitorServices: monitorServices,
+			ConfigHealth:    configHealth,
+			APIReader:       mgr.GetAPIReader(),
+			Cache:           configCache,
+			Elected:         mgr.Elected(),
+			ConfigEvents:    configEvents,
+		}); err != nil {
+			setupLog.Error(err, "unable to add config watcher")
+			os.Exit(1)
+		}
+	}
+	// The webhooks require a serving certificate, they are only enabled if it is provisioned. ENABLE_WEBHOOKS is set by
+	// webhook.enabled of the Helm chart and by manager_webhook_patch.yaml of the kustomization, both disabled by default.
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err = (&webhooks.EndpointMonitorWebhook{MonitorServices: monitorServices}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "EndpointMonitor")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -145,3 +287,16 @@ func getWatchNamespace() (string, error) {
 	}
 	return ns, nil
 }
+
+// reportConfigError records a warning event on the config secret
+func reportConfigError(recorder record.EventRecorder, err error) {
+	secretKey, keyErr := config.GetConfigSecretKey()
+	if keyErr != nil {
+		return
+	}
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: secretKey.Name, Namespace: secretKey.Namespace},
+	}
+	recorder.Event(secret, corev1.EventTypeWarning, controllers.ReasonInvalidConfig, err.Error())
+}
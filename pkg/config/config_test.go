@@ -3,6 +3,7 @@ package config
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 const (
@@ -29,6 +30,14 @@ const (
 
 	configFilePathAppInsights        = "../../examples/configs/test-config-appinsights.yaml"
 	correctTestAppInsightsConfigName = "AppInsights"
+
+	configFilePathGarbageCollection = "../../examples/configs/test-config-garbage-collection.yaml"
+
+	configFilePathStatusExporter = "../../examples/configs/test-config-status-exporter.yaml"
+
+	configFilePathHTTP = "../../examples/configs/test-config-http.yaml"
+
+	configFilePathMultipleProviders = "../../examples/configs/test-config-multiple-providers.yaml"
 )
 
 func TestConfigWithCorrectValues(t *testing.T) {
@@ -127,3 +136,119 @@ func TestConfigWithEmptyConfig(t *testing.T) {
 		t.Error("Marshalled config and incorrect config match, should not match")
 	}
 }
+
+func TestConfigWithGarbageCollection(t *testing.T) {
+	correctConfig := Config{Providers: []Provider{{Name: correctTestConfigName, ApiKey: correctTestAPIKey, ApiURL: correctTestAPIURL, AlertContacts: correctTestAlertContacts}},
+		EnableMonitorDeletion: correctTestEnableMonitorDeletion, MonitorNameTemplate: "imc-{{.Namespace}}-{{.Name}}",
+		GarbageCollection: GarbageCollection{Enabled: true, Interval: 30 * time.Minute, DryRun: true, MonitorNamePrefix: "imc-"}}
+	config := ReadConfig(configFilePathGarbageCollection)
+	if !reflect.DeepEqual(config, correctConfig) {
+		t.Error("Marshalled config and correct config do not match")
+	}
+}
+
+func TestGarbageCollectionDefaultInterval(t *testing.T) {
+	config := ReadConfig(configFilePath)
+	if config.GarbageCollection.GetInterval() != DefaultGarbageCollectionInterval {
+		t.Errorf("Expected default interval %v, got %v", DefaultGarbageCollectionInterval, config.GarbageCollection.GetInterval())
+	}
+}
+
+func TestConfigWithStatusExporter(t *testing.T) {
+	correctConfig := Config{Providers: []Provider{{Name: correctTestConfigName, ApiKey: correctTestAPIKey, ApiURL: correctTestAPIURL, AlertContacts: correctTestAlertContacts}},
+		EnableMonitorDeletion: correctTestEnableMonitorDeletion,
+		StatusExporter:        StatusExporter{Enabled: true, Interval: 2 * time.Minute}}
+	config := ReadConfig(configFilePathStatusExporter)
+	if !reflect.DeepEqual(config, correctConfig) {
+		t.Error("Marshalled config and correct config do not match")
+	}
+}
+
+func TestStatusExporterDefaultInterval(t *testing.T) {
+	config := ReadConfig(configFilePath)
+	if config.StatusExporter.GetInterval() != DefaultStatusExporterInterval {
+		t.Errorf("Expected default interval %v, got %v", DefaultStatusExporterInterval, config.StatusExporter.GetInterval())
+	}
+}
+
+func TestConfigWithHTTP(t *testing.T) {
+	maxRetries := 0
+	correctConfig := Config{Providers: []Provider{{Name: correctTestConfigName, ApiKey: correctTestAPIKey, ApiURL: correctTestAPIURL, AlertContacts: correctTestAlertContacts,
+		HTTP: HTTP{Timeout: 10 * time.Second, MaxRetries: &maxRetries, RequestsPerMinute: 10, Burst: 2}}},
+		EnableMonitorDeletion: correctTestEnableMonitorDeletion}
+	config := ReadConfig(configFilePathHTTP)
+	if !reflect.DeepEqual(config, correctConfig) {
+		t.Error("Marshalled config and correct config do not match")
+	}
+	if config.Providers[0].HTTP.GetMaxRetries() != 0 {
+		t.Error("Retries should be disabled")
+	}
+}
+
+func TestHTTPDefaults(t *testing.T) {
+	config := ReadConfig(configFilePath)
+	httpConfig := config.Providers[0].HTTP
+	if httpConfig.GetTimeout() != DefaultHTTPTimeout || httpConfig.GetMaxRetries() != DefaultHTTPMaxRetries || httpConfig.GetBurst() != 1 {
+		t.Errorf("Expected default HTTP configuration, got %v, %v and %v", httpConfig.GetTimeout(), httpConfig.GetMaxRetries(), httpConfig.GetBurst())
+	}
+}
+
+func TestConfigWithMultipleProvidersOfSameType(t *testing.T) {
+	correctConfig := Config{Providers: []Provider{
+		{Name: "uptimerobot-prod", Type: "UptimeRobot", ApiKey: correctTestAPIKey, ApiURL: correctTestAPIURL, AlertContacts: correctTestAlertContacts},
+		{Name: "uptimerobot-staging", Type: "UptimeRobot", ApiKey: "8a7d9ashdyasjdklkskuasd657", ApiURL: correctTestAPIURL, AlertContacts: "2628365_0_0"},
+		{Name: correctTestPingdomConfigMulti, ApiToken: correctTestPingdomAPIToken, ApiURL: correctTestPingdomAPIURL}},
+		EnableMonitorDeletion: correctTestEnableMonitorDeletion}
+	config := ReadConfig(configFilePathMultipleProviders)
+	if !reflect.DeepEqual(config, correctConfig) {
+		t.Error("Marshalled config and correct config do not match")
+	}
+
+	expectedTypes := []string{"UptimeRobot", "UptimeRobot", "Pingdom"}
+	for index, provider := range config.Providers {
+		if provider.GetType() != expectedTypes[index] {
+			t.Errorf("Expected type %s for provider %s, got %s", expectedTypes[index], provider.Name, provider.GetType())
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig([]byte("providers:\n  - name: UptimeRobot\n    apiKey: abc\nenableMonitorDeletion: true\n"))
+	if err != nil {
+		t.Error("Unexpected error: " + err.Error())
+	}
+	correctConfig := Config{Providers: []Provider{{Name: "UptimeRobot", ApiKey: "abc"}}, EnableMonitorDeletion: true}
+	if !reflect.DeepEqual(config, correctConfig) {
+		t.Error("Parsed config and correct config do not match")
+	}
+
+	if _, err := ParseConfig([]byte("providers: [")); err == nil {
+		t.Error("Expected an error for invalid YAML")
+	}
+}
+
+func TestRestrictCredentialsProvider(t *testing.T) {
+	config := Config{Providers: []Provider{
+		{Name: "uptimerobot-prod", Type: "UptimeRobot", ApiURL: correctTestAPIURL},
+		{Name: correctTestPingdomConfigMulti, ApiURL: correctTestPingdomAPIURL},
+	}}
+
+	provider := Provider{Name: "team", Type: "UptimeRobot", ApiKey: "abc"}
+	if err := config.RestrictCredentialsProvider(&provider); err != nil || provider.ApiURL != correctTestAPIURL {
+		t.Errorf("Expected the apiURL to default to the one of the controller, got %q and %v", provider.ApiURL, err)
+	}
+	provider = Provider{Name: "team", Type: "UptimeRobot", ApiURL: correctTestPingdomAPIURL}
+	if err := config.RestrictCredentialsProvider(&provider); err == nil {
+		t.Error("Expected the apiURL of a provider of another type to be rejected")
+	}
+	provider = Provider{Name: "team", Type: "UptimeRobot", HTTP: HTTP{Proxy: "http://proxy:3128"}}
+	if err := config.RestrictCredentialsProvider(&provider); err == nil {
+		t.Error("Expected the proxy to be rejected")
+	}
+
+	config.CredentialsSecrets.AllowEndpointOverrides = true
+	provider = Provider{Name: "team", Type: "UptimeRobot", ApiURL: "https://collector.team", HTTP: HTTP{Proxy: "http://proxy:3128"}}
+	if err := config.RestrictCredentialsProvider(&provider); err != nil {
+		t.Errorf("Expected overrides to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"sync"
+
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
+)
+
+// Health records the problems of the configuration of the controller. The controller keeps running with the
+// valid parts of its configuration while the configuration is degraded, which is reported by the config_degraded
+// metric. It doesn't fail the readiness check, the webhooks served by the pod would reject all EndpointMonitors else.
+type Health struct {
+	mutex sync.RWMutex
+	err   error
+	// failedProviders holds the names of the invalid providers, all providers are affected if it is nil
+	failedProviders map[string]bool
+}
+
+// SetError records problems that affect the whole configuration, e.g. because it couldn't be loaded. nil marks the
+// configuration as healthy.
+func (h *Health) SetError(err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.err = err
+	h.failedProviders = nil
+	setConfigDegraded(err)
+}
+
+// SetProviderErrors records problems that only affect the given providers, the other providers keep working
+func (h *Health) SetProviderErrors(err error, providers []string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.err = err
+	h.failedProviders = make(map[string]bool)
+	for _, provider := range providers {
+		h.failedProviders[provider] = true
+	}
+	setConfigDegraded(err)
+}
+
+func setConfigDegraded(err error) {
+	if err != nil {
+		metrics.ConfigDegraded.Set(1)
+	} else {
+		metrics.ConfigDegraded.Set(0)
+	}
+}
+
+// Error returns the problems of the configuration or nil if it is healthy
+func (h *Health) Error() error {
+	if h == nil {
+		return nil
+	}
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.err
+}
+
+// ProviderError returns the problems of the configuration if they affect one of the given providers, or any
+// provider if none is given
+func (h *Health) ProviderError(providers []string) error {
+	if h == nil {
+		return nil
+	}
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	if h.err == nil || h.failedProviders == nil || (len(providers) == 0 && len(h.failedProviders) != 0) {
+		return h.err
+	}
+	for _, provider := range providers {
+		if h.failedProviders[provider] {
+			return h.err
+		}
+	}
+	return nil
+}
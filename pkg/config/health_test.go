@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
+)
+
+func TestHealthProviderError(t *testing.T) {
+	health := &Health{}
+	if health.ProviderError(nil) != nil || health.ProviderError([]string{"UptimeRobot"}) != nil {
+		t.Error("Expected a healthy configuration")
+	}
+
+	// Problems of the whole configuration affect all providers
+	health.SetError(errors.New("invalid configuration"))
+	if health.ProviderError([]string{"UptimeRobot"}) == nil || health.ProviderError(nil) == nil {
+		t.Error("Expected all providers to be affected")
+	}
+
+	health.SetProviderErrors(errors.New("invalid provider typo"), []string{"typo"})
+	if health.Error() == nil {
+		t.Error("Expected the configuration to be degraded")
+	}
+	if degraded := testutil.ToFloat64(metrics.ConfigDegraded); degraded != 1 {
+		t.Errorf("Expected the configuration to be reported as degraded, got %v", degraded)
+	}
+	if err := health.ProviderError([]string{"UptimeRobot", "Pingdom"}); err != nil {
+		t.Errorf("Expected the valid providers not to be affected, got %v", err)
+	}
+	if health.ProviderError([]string{"UptimeRobot", "typo"}) == nil {
+		t.Error("Expected the invalid provider to be affected")
+	}
+	if health.ProviderError(nil) == nil {
+		t.Error("Expected the monitors of all providers to be affected")
+	}
+
+	health.SetError(nil)
+	if health.Error() != nil || health.ProviderError([]string{"typo"}) != nil {
+		t.Error("Expected a healthy configuration")
+	}
+	if degraded := testutil.ToFloat64(metrics.ConfigDegraded); degraded != 0 {
+		t.Errorf("Expected the configuration to be reported as healthy, got %v", degraded)
+	}
+}
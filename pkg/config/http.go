@@ -0,0 +1,216 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stakater/IngressMonitorController/v2/pkg/secret"
+)
+
+// Keys of the certificate and its key in a kubernetes.io/tls secret
+const (
+	defaultClientCertificateKey = corev1.TLSCertKey
+	defaultClientKeyKey         = corev1.TLSPrivateKeyKey
+)
+
+// CABundle references PEM encoded certificate authorities in a secret or a config map
+type CABundle struct {
+	SecretRef    *KeyReference `yaml:"secretRef,omitempty"`
+	ConfigMapRef *KeyReference `yaml:"configMapRef,omitempty"`
+}
+
+// KeyReference selects a key of a secret or config map
+type KeyReference struct {
+	Name string `yaml:"name"`
+	// Namespace defaults to the namespace of the operator
+	Namespace string `yaml:"namespace,omitempty"`
+	Key       string `yaml:"key"`
+}
+
+// ClientCertificate references a PEM encoded client certificate and its key in a secret, e.g. of type
+// kubernetes.io/tls
+type ClientCertificate struct {
+	SecretName string `yaml:"secretName"`
+	// Namespace defaults to the namespace of the operator
+	Namespace string `yaml:"namespace,omitempty"`
+	// CertificateKey defaults to tls.crt
+	CertificateKey string `yaml:"certificateKey,omitempty"`
+	// KeyKey defaults to tls.key
+	KeyKey string `yaml:"keyKey,omitempty"`
+}
+
+// Certificates holds the PEM encoded certificates loaded for an HTTP configuration
+type Certificates struct {
+	CAs               []byte
+	ClientCertificate []byte
+	ClientKey         []byte
+}
+
+// LoadHTTP loads the certificates of the HTTP configurations and applies the HTTP configuration of the controller
+// to the providers
+func (c *Config) LoadHTTP(apiReader client.Reader) error {
+	if err := c.HTTP.Load(apiReader); err != nil {
+		return fmt.Errorf("invalid http configuration: %w", err)
+	}
+	for index := range c.Providers {
+		provider := &c.Providers[index]
+		if err := provider.HTTP.Load(apiReader); err != nil {
+			return fmt.Errorf("invalid http configuration of provider %s: %w", provider.Name, err)
+		}
+		provider.HTTP = provider.HTTP.WithDefaults(c.HTTP)
+	}
+	return nil
+}
+
+// Load validates the configuration and loads its CA bundles and client certificate from their secrets and config
+// maps
+func (h *HTTP) Load(apiReader client.Reader) error {
+	if len(h.Proxy) != 0 {
+		proxyURL, err := url.Parse(h.Proxy)
+		if err != nil || len(proxyURL.Host) == 0 {
+			// The URL may contain credentials, it is not part of the error
+			return fmt.Errorf("proxy must be a URL like http://proxy:3128")
+		}
+	}
+
+	var certificates Certificates
+	for _, bundle := range h.CABundles {
+		data, err := loadCABundle(apiReader, bundle)
+		if err != nil {
+			return err
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(data) {
+			return fmt.Errorf("CA bundle %s contains no PEM encoded certificate", getCABundleName(bundle))
+		}
+		certificates.CAs = appendPEM(certificates.CAs, data)
+	}
+
+	if clientCertificate := h.ClientCertificate; clientCertificate != nil {
+		namespace, err := getNamespace(clientCertificate.Namespace)
+		if err != nil {
+			return err
+		}
+		certificateKey, keyKey := clientCertificate.CertificateKey, clientCertificate.KeyKey
+		if len(certificateKey) == 0 {
+			certificateKey = defaultClientCertificateKey
+		}
+		if len(keyKey) == 0 {
+			keyKey = defaultClientKeyKey
+		}
+
+		certificate, err := secret.LoadSecretData(apiReader, clientCertificate.SecretName, namespace, certificateKey)
+		if err != nil {
+			return fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		key, err := secret.LoadSecretData(apiReader, clientCertificate.SecretName, namespace, keyKey)
+		if err != nil {
+			return fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		if _, err := tls.X509KeyPair([]byte(certificate), []byte(key)); err != nil {
+			return fmt.Errorf("invalid client certificate in secret %s: %w", clientCertificate.SecretName, err)
+		}
+		certificates.ClientCertificate, certificates.ClientKey = []byte(certificate), []byte(key)
+	}
+
+	h.Certificates = certificates
+	return nil
+}
+
+// WithDefaults returns the configuration with the unset values taken from the given defaults, e.g. the HTTP
+// configuration of the controller. The CA bundles of both configurations are trusted.
+func (h HTTP) WithDefaults(defaults HTTP) HTTP {
+	if h.Timeout <= 0 {
+		h.Timeout = defaults.Timeout
+	}
+	if h.MaxRetries == nil {
+		h.MaxRetries = defaults.MaxRetries
+	}
+	if h.RequestsPerMinute <= 0 {
+		h.RequestsPerMinute = defaults.RequestsPerMinute
+	}
+	if h.Burst <= 0 {
+		h.Burst = defaults.Burst
+	}
+	if len(h.Proxy) == 0 {
+		h.Proxy = defaults.Proxy
+	}
+	if len(h.NoProxy) == 0 {
+		h.NoProxy = defaults.NoProxy
+	}
+	if len(defaults.CABundles) != 0 {
+		h.CABundles = append(append([]CABundle{}, defaults.CABundles...), h.CABundles...)
+		h.Certificates.CAs = appendPEM(append([]byte{}, defaults.Certificates.CAs...), h.Certificates.CAs)
+	}
+	if h.ClientCertificate == nil {
+		h.ClientCertificate = defaults.ClientCertificate
+		h.Certificates.ClientCertificate = defaults.Certificates.ClientCertificate
+		h.Certificates.ClientKey = defaults.Certificates.ClientKey
+	}
+	return h
+}
+
+// HasCertificateReferences returns true if the configuration references certificates in secrets or config maps
+func (h HTTP) HasCertificateReferences() bool {
+	return len(h.CABundles) != 0 || h.ClientCertificate != nil
+}
+
+func loadCABundle(apiReader client.Reader, bundle CABundle) ([]byte, error) {
+	switch {
+	case bundle.SecretRef != nil:
+		namespace, err := getNamespace(bundle.SecretRef.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		data, err := secret.LoadSecretData(apiReader, bundle.SecretRef.Name, namespace, bundle.SecretRef.Key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load CA bundle: %w", err)
+		}
+		return []byte(data), nil
+	case bundle.ConfigMapRef != nil:
+		namespace, err := getNamespace(bundle.ConfigMapRef.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		configMap := &corev1.ConfigMap{}
+		if err := apiReader.Get(context.TODO(), types.NamespacedName{Name: bundle.ConfigMapRef.Name, Namespace: namespace}, configMap); err != nil {
+			return nil, fmt.Errorf("unable to load CA bundle: %w", err)
+		}
+		data, ok := configMap.Data[bundle.ConfigMapRef.Key]
+		if !ok {
+			return nil, fmt.Errorf("unable to load CA bundle: config map %s did not contain key %s", bundle.ConfigMapRef.Name, bundle.ConfigMapRef.Key)
+		}
+		return []byte(data), nil
+	}
+	return nil, fmt.Errorf("CA bundle must reference a secret or a config map")
+}
+
+func getCABundleName(bundle CABundle) string {
+	if bundle.SecretRef != nil {
+		return "secret " + bundle.SecretRef.Name
+	}
+	return "config map " + bundle.ConfigMapRef.Name
+}
+
+// getNamespace returns the given namespace, or the namespace of the operator if it is empty
+func getNamespace(namespace string) (string, error) {
+	if len(namespace) != 0 {
+		return namespace, nil
+	}
+	return GetOperatorNamespace()
+}
+
+// appendPEM appends PEM encoded data, making sure that the blocks are separated by a newline
+func appendPEM(data []byte, pem []byte) []byte {
+	if len(data) != 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	return append(data, pem...)
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+)
+
+const (
+	ReasonConfigReloaded = "ConfigReloaded"
+	ReasonInvalidConfig  = "InvalidConfig"
+)
+
+// ConfigWatcher reloads the configuration of the controller when the config secret changes. The monitor services
+// are rebuilt and all EndpointMonitors are re-enqueued. An invalid configuration is rejected and the previous one
+// stays in effect. It runs on every replica so that a new leader starts with the latest configuration.
+type ConfigWatcher struct {
+	client.Client
+	Log             logr.Logger
+	Recorder        record.EventRecorder
+	MonitorServices *monitors.MonitorServiceRegistry
+	ConfigHealth    *config.Health
+	// APIReader reads the certificates of the HTTP configuration without caching all secrets of the cluster
+	APIReader client.Reader
+	// Cache only holds the config secret
+	Cache cache.Cache
+	// Elected is closed once the replica is the leader and the EndpointMonitor controller is running
+	Elected <-chan struct{}
+	// ConfigEvents is watched by the EndpointMonitor controller
+	ConfigEvents chan<- event.GenericEvent
+}
+
+// Start watches the config secret until the context is cancelled
+func (w *ConfigWatcher) Start(ctx context.Context) error {
+	informer, err := w.Cache.GetInformer(ctx, &corev1.Secret{})
+	if err != nil {
+		return err
+	}
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.reload(ctx, obj)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			w.reload(ctx, obj)
+		},
+	})
+
+	// The cache is owned by the watcher, the manager would only start it on the leader
+	return w.Cache.Start(ctx)
+}
+
+// NeedLeaderElection makes sure that the configuration is reloaded on all replicas
+func (w *ConfigWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+func (w *ConfigWatcher) reload(ctx context.Context, obj interface{}) {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+
+	data, ok := secret.Data[config.IngressMonitorControllerSecretConfigKey]
+	if !ok {
+		w.reject(secret, fmt.Errorf("secret %s did not contain key %s", secret.Name, config.IngressMonitorControllerSecretConfigKey))
+		return
+	}
+	newConfig, err := config.ParseConfig(data)
+	if err != nil {
+		w.reject(secret, fmt.Errorf("unable to parse %s: %w", config.IngressMonitorControllerSecretConfigKey, err))
+		return
+	}
+	if err := newConfig.LoadHTTP(w.APIReader); err != nil {
+		w.reject(secret, err)
+		return
+	}
+
+	if w.ConfigHealth.Error() == nil && reflect.DeepEqual(newConfig, config.GetControllerConfig()) {
+		// Nothing has changed, e.g. on the initial sync of the cache
+		return
+	}
+
+	// Providers can be configured by ProviderConfigs only
+	var monitorServices []monitors.MonitorServiceProxy
+	if len(newConfig.Providers) != 0 {
+		monitorServices, err = monitors.SetupMonitorServicesForProviders(newConfig.Providers)
+		if err != nil {
+			monitors.CloseMonitorServices(monitorServices)
+			w.reject(secret, err)
+			return
+		}
+	}
+
+	w.MonitorServices.Set(monitorServices)
+	config.SetControllerConfig(newConfig)
+	w.ConfigHealth.SetError(nil)
+
+	w.Log.Info("Configuration has been reloaded")
+	w.Recorder.Event(secret, corev1.EventTypeNormal, ReasonConfigReloaded, "Configuration has been reloaded")
+
+	go func() {
+		// Only the leader runs the EndpointMonitor controller
+		select {
+		case <-w.Elected:
+			enqueueEndpointMonitors(ctx, w.Client, w.ConfigEvents, w.Log)
+		case <-ctx.Done():
+		}
+	}()
+}
+
+// reject keeps the previous configuration in effect and reports the problems of the new one
+func (w *ConfigWatcher) reject(secret *corev1.Secret, err error) {
+	w.Log.Error(err, "Invalid configuration, keeping the previous configuration")
+	w.Recorder.Event(secret, corev1.EventTypeWarning, ReasonInvalidConfig, "Keeping the previous configuration: "+err.Error())
+}
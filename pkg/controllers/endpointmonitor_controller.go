@@ -22,14 +22,30 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"github.com/stakater/IngressMonitorController/v2/pkg/kube"
+	kubeutil "github.com/stakater/IngressMonitorController/v2/pkg/kube/util"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
+	"github.com/stakater/IngressMonitorController/v2/pkg/models"
 	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
-	"github.com/stakater/IngressMonitorController/v2/pkg/util"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 )
@@ -39,73 +55,243 @@ type EndpointMonitorReconciler struct {
 	client.Client
 	Log             logr.Logger
 	Scheme          *runtime.Scheme
-	MonitorServices []monitors.MonitorServiceProxy
+	MonitorServices *monitors.MonitorServiceRegistry
+	ConfigHealth    *config.Health
+	// APIReader reads the credentials secrets without caching all secrets of the cluster
+	APIReader client.Reader
+	// CredentialsCache holds the monitor services built from the credentials secrets of the instances
+	CredentialsCache *monitors.MonitorServiceCache
+	// ConfigEvents re-enqueues all instances when the configuration is reloaded
+	ConfigEvents <-chan event.GenericEvent
+	// Recorder records the changes of the remote monitors on the instances and the Ingresses or Routes of their URL
+	Recorder record.EventRecorder
 }
 
-//+kubebuilder:rbac:groups=endpointmonitor.stakater.com,resources=endpointmonitors,verbs=get;list;watch
+//+kubebuilder:rbac:groups=endpointmonitor.stakater.com,resources=endpointmonitors,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=endpointmonitor.stakater.com,resources=endpointmonitors/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=endpointmonitor.stakater.com,resources=endpointmonitors/finalizers,verbs=update
 //+kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;watch
 //+kubebuilder:rbac:groups=extensions,resources=ingresses,verbs=get;list;watch
 //+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
-//+kubebuilder:rbac:groups="",resources=services,verbs=get;list
-//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-func (r *EndpointMonitorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *EndpointMonitorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	log := r.Log.WithValues("endpointmonitor", req.NamespacedName)
 
 	// Fetch the EndpointMonitor instance
 	instance := &endpointmonitorv1alpha1.EndpointMonitor{}
+	defer func() {
+		metrics.Reconciles.WithLabelValues(getReconcileOutcome(instance, err)).Inc()
+	}()
 
-	var monitorName string
-	format, err := util.GetNameTemplateFormat(config.GetControllerConfig().MonitorNameTemplate)
+	monitorName, err := getMonitorName(req.Name, req.Namespace)
 	if err != nil {
 		log.Error(err, "Failed to parse MonitorNameTemplate, using default template `{{.Name}}-{{.Namespace}}`")
-		monitorName = req.Name + "-" + req.Namespace
-	} else {
-		monitorName = fmt.Sprintf(format, req.Name, req.Namespace)
 	}
 
-	err = r.Get(context.TODO(), req.NamespacedName, instance)
+	err = r.Get(ctx, req.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
-			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Remote monitors are removed before the finalizer is released.
 			// Return and don't requeue
-			return r.handleDelete(req, instance, monitorName)
+			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
 
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.handleDelete(ctx, instance, monitorName)
+	}
+
+	// Add finalizer to remove the remote monitors before the instance is deleted
+	if !controllerutil.ContainsFinalizer(instance, endpointmonitorv1alpha1.EndpointMonitorFinalizer) {
+		controllerutil.AddFinalizer(instance, endpointmonitorv1alpha1.EndpointMonitorFinalizer)
+		if err := r.Update(ctx, instance); err != nil {
+			log.Error(err, "Failed to add finalizer")
+			return reconcile.Result{}, err
+		}
+	}
+
+	// The monitor services are replaced when the configuration is reloaded, the whole request works on a snapshot
+	monitorServices, err := r.getMonitorServices(instance)
+	if err != nil {
+		log.Error(err, "Failed to load credentials for monitor "+monitorName)
+		setCredentialsUnavailable(instance, err)
+		if statusErr := r.updateStatus(ctx, instance); statusErr != nil {
+			log.Error(statusErr, "Failed to update status")
+		}
+		return reconcile.Result{}, err
+	}
+
+	// Providers are validated before any monitor is touched, the spec has to be fixed by the user
+	if err := validateProviders(instance, monitorServices); err != nil {
+		log.Error(err, "Invalid providers for monitor "+monitorName)
+		setInvalidProviders(instance, err)
+		return reconcile.Result{}, r.updateStatus(ctx, instance)
+	}
+
 	// Handle CreationDelay
 	createTime := instance.CreationTimestamp
 	delay := time.Until(createTime.Add(config.GetControllerConfig().CreationDelay))
 
-	for index := 0; index < len(r.MonitorServices); index++ {
-		monitor := findMonitorByName(r.MonitorServices[index], monitorName)
-		if monitor != nil {
-			// Monitor already exists, update if required
-			err = r.handleUpdate(req, instance, *monitor, r.MonitorServices[index])
-		} else {
-			// Monitor doesn't exist, create monitor
-			if delay.Nanoseconds() > 0 {
-				// Requeue request to add creation delay
-				log.Info("Requeuing request to add monitor " + monitorName + " for " + fmt.Sprintf("%+v", config.GetControllerConfig().CreationDelay) + " seconds")
-				return reconcile.Result{RequeueAfter: delay}, nil
+	// The URLs are shared by the monitors of all providers
+	targets, err := kubeutil.GetMonitorTargets(r.Client, instance)
+	if err != nil {
+		log.Error(err, "Failed to resolve URL for monitor "+monitorName)
+		setURLResolutionFailed(instance, err)
+		r.recordEvent(ctx, instance, corev1.EventTypeWarning, ReasonURLResolutionFailed, err.Error())
+		if statusErr := r.updateStatus(ctx, instance); statusErr != nil {
+			log.Error(statusErr, "Failed to update status")
+		}
+		return reconcile.Result{}, err
+	}
+	setHealthEndpointStatuses(instance, targets)
+
+	basicAuthPassword, err := r.getBasicAuthPassword(instance)
+	if err != nil {
+		log.Error(err, "Failed to load basic auth password for monitor "+monitorName)
+		setCredentialsUnavailable(instance, err)
+		if statusErr := r.updateStatus(ctx, instance); statusErr != nil {
+			log.Error(statusErr, "Failed to update status")
+		}
+		return reconcile.Result{}, err
+	}
+
+	var retryableErrors []error
+	creationDelayed := false
+	for index := 0; index < len(monitorServices); index++ {
+		monitorService := monitorServices[index]
+		if !instance.Spec.HasProvider(monitorService.GetName()) {
+			// The provider has been removed from the instance or was never part of it
+			err = r.handleProviderRemoved(ctx, instance, monitorService)
+			if err != nil {
+				log.Error(err, "Failed to remove monitor "+monitorName+" from provider "+monitorService.GetName())
+				retryableErrors = append(retryableErrors, err)
 			}
-			err = r.handleCreate(req, instance, monitorName, r.MonitorServices[index])
+			continue
 		}
+
+		for _, target := range targets {
+			targetMonitorName := getTargetMonitorName(monitorName, target.Name)
+			created, updated := false, false
+			var monitor *models.Monitor
+			monitor, err = findMonitor(monitorService, instance.Status.GetMonitorStatus(monitorService.GetName(), target.Name), targetMonitorName)
+			switch {
+			case err != nil:
+				// Whether the monitor exists is unknown, creating it could duplicate it
+			case monitor != nil:
+				// Monitor already exists, update if required
+				updated, err = r.handleUpdate(req, instance, *monitor, target.URL, basicAuthPassword, monitorService)
+			default:
+				// Monitor doesn't exist, create monitor
+				if delay.Nanoseconds() > 0 {
+					// Requeue request to add creation delay, the other monitors are synced in the meantime
+					log.Info("Requeuing request to add monitor " + targetMonitorName + " for " + fmt.Sprintf("%+v", config.GetControllerConfig().CreationDelay) + " seconds")
+					creationDelayed = true
+					continue
+				}
+				err = r.handleCreate(req, instance, targetMonitorName, target.URL, basicAuthPassword, monitorService)
+				created = err == nil
+				if created {
+					// Retrieve the created monitor to record its ID, it is looked up by name until then
+					var lookupErr error
+					if monitor, lookupErr = findMonitorByName(monitorService, targetMonitorName); lookupErr != nil {
+						log.Error(lookupErr, "Failed to retrieve created monitor "+targetMonitorName)
+					}
+				}
+			}
+			if monitor == nil {
+				monitor = &models.Monitor{Name: targetMonitorName}
+			}
+			monitor.URL = target.URL
+			setMonitorStatus(instance, monitorService.GetName(), target.Name, *monitor, err)
+
+			switch {
+			case err != nil:
+				log.Error(err, "Failed to sync monitor "+targetMonitorName+" with provider "+monitorService.GetName())
+				r.recordEvent(ctx, instance, corev1.EventTypeWarning, ReasonProviderError,
+					fmt.Sprintf("Failed to sync monitor %s with provider %s: %v", targetMonitorName, monitorService.GetName(), err))
+				// Auth and validation failures won't go away by retrying, they are only reported in the status
+				if monitorerrors.IsRetryable(err) || monitorerrors.IsNotFound(err) {
+					retryableErrors = append(retryableErrors, err)
+				}
+			case created:
+				r.recordEvent(ctx, instance, corev1.EventTypeNormal, ReasonMonitorCreated,
+					fmt.Sprintf("Monitor %s has been created at provider %s", targetMonitorName, monitorService.GetName()))
+			case updated:
+				r.recordEvent(ctx, instance, corev1.EventTypeNormal, ReasonMonitorUpdated,
+					fmt.Sprintf("Monitor %s has been updated at provider %s", targetMonitorName, monitorService.GetName()))
+			}
+		}
+
+		// Hosts or paths may have been removed from the URL source
+		if err = r.handleTargetsRemoved(ctx, instance, monitorService, targets); err != nil {
+			log.Error(err, "Failed to remove monitors of removed targets from provider "+monitorService.GetName())
+			retryableErrors = append(retryableErrors, err)
+		}
+	}
+
+	setReadyCondition(instance)
+	if statusErr := r.updateStatus(ctx, instance); statusErr != nil {
+		log.Error(statusErr, "Failed to update status")
+		return reconcile.Result{}, statusErr
 	}
 
-	return reconcile.Result{RequeueAfter: config.ReconciliationRequeueTime}, err
+	if len(retryableErrors) != 0 {
+		// Returning an error requeues the request with exponential backoff
+		return reconcile.Result{}, utilerrors.NewAggregate(retryableErrors)
+	}
+	if creationDelayed && delay < config.ReconciliationRequeueTime {
+		return reconcile.Result{RequeueAfter: delay}, nil
+	}
+	return reconcile.Result{RequeueAfter: config.ReconciliationRequeueTime}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *EndpointMonitorReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&endpointmonitorv1alpha1.EndpointMonitor{}).
-		Complete(r)
+	if err := setupIndexes(context.Background(), mgr); err != nil {
+		return err
+	}
+
+	// Changes of the URL sources are propagated right away instead of on the next resync
+	urlSourceChanged := builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))
+	podCreated := builder.WithPredicates(predicate.Funcs{
+		// Probes of a pod are immutable, they only change with new pods
+		UpdateFunc:  func(event.UpdateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	})
+
+	controller := ctrl.NewControllerManagedBy(mgr).
+		// Status updates don't change the generation and must not trigger another reconcile
+		For(&endpointmonitorv1alpha1.EndpointMonitor{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Watches(&source.Channel{Source: r.ConfigEvents}, &handler.EnqueueRequestForObject{}).
+		Watches(&source.Kind{Type: &networkingv1.Ingress{}}, handler.EnqueueRequestsFromMapFunc(r.mapIngress), urlSourceChanged).
+		Watches(&source.Kind{Type: &corev1.Service{}}, handler.EnqueueRequestsFromMapFunc(r.mapService), builder.WithPredicates(serviceChanged)).
+		Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(r.mapPod), podCreated)
+	if kube.IsOpenshift {
+		controller = controller.Watches(&source.Kind{Type: &routev1.Route{}}, handler.EnqueueRequestsFromMapFunc(r.mapRoute), urlSourceChanged)
+	}
+
+	// HTTPRoutes are only watched if the Gateway API is installed
+	httpRouteKind, ok, err := getHTTPRouteKind(mgr.GetRESTMapper())
+	if err != nil {
+		return err
+	}
+	if ok {
+		httpRoute := &unstructured.Unstructured{}
+		httpRoute.SetGroupVersionKind(httpRouteKind)
+		controller = controller.Watches(&source.Kind{Type: httpRoute}, handler.EnqueueRequestsFromMapFunc(r.mapHTTPRoute), urlSourceChanged)
+	}
+	return controller.Complete(r)
 }
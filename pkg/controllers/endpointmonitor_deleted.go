@@ -1,45 +1,254 @@
 package controllers
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	kubeutil "github.com/stakater/IngressMonitorController/v2/pkg/kube/util"
+	"github.com/stakater/IngressMonitorController/v2/pkg/models"
 	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-func (r *EndpointMonitorReconciler) handleDelete(request reconcile.Request, instance *endpointmonitorv1alpha1.EndpointMonitor, monitorName string) (reconcile.Result, error) {
-	log := r.Log.WithValues("endpointMonitor", request.Namespace)
+// handleDelete removes the remote monitors of a deleted instance and releases its finalizer afterwards.
+// The finalizer is kept as long as a monitor could not be removed so that it doesn't leak at the provider.
+func (r *EndpointMonitorReconciler) handleDelete(ctx context.Context, instance *endpointmonitorv1alpha1.EndpointMonitor, monitorName string) (reconcile.Result, error) {
+	log := r.Log.WithValues("endpointMonitor", instance.Namespace+"/"+instance.Name)
 
-	if instance == nil {
-		// Instance not found, nothing to do
+	if !controllerutil.ContainsFinalizer(instance, endpointmonitorv1alpha1.EndpointMonitorFinalizer) {
+		// Monitors have already been removed, nothing to do
 		return reconcile.Result{}, nil
 	}
 
-	if !config.GetControllerConfig().EnableMonitorDeletion {
+	switch {
+	case instance.Annotations[endpointmonitorv1alpha1.OrphanAnnotation] == "true":
+		log.Info("EndpointMonitor is annotated as orphan. Skipping deletion for monitor: " + monitorName)
+	case instance.Spec.CredentialsRef == nil && getConfigError(r.ConfigHealth, instance) != nil:
+		// Monitors of invalid providers can't be removed, the finalizer is kept until the configuration is fixed
+		return reconcile.Result{}, fmt.Errorf("configuration is degraded, keeping monitor %s: %w", monitorName, getConfigError(r.ConfigHealth, instance))
+	case instance.Spec.CredentialsRef == nil && r.hasUnavailableProvider(instance):
+		// The ProviderConfig of a monitor is invalid, the finalizer is kept until it is fixed
+		return reconcile.Result{}, fmt.Errorf("provider of monitor %s is unavailable, keeping monitor", monitorName)
+	case !config.GetControllerConfig().EnableMonitorDeletion:
 		log.Info("Monitor deletion is disabled. Skipping deletion for monitor: " + monitorName)
-		return reconcile.Result{}, nil
-	}
+	default:
+		log.Info("Removing Monitor: " + monitorName)
 
-	log.Info("Removing Monitor: " + monitorName)
+		monitorServices, err := r.getMonitorServicesForDelete(instance)
+		if instance.Spec.CredentialsRef != nil && errors.IsNotFound(err) {
+			// The credentials secret is gone and its services aren't cached, the monitors can't be removed anymore.
+			// Retrying won't help, it would only block the deletion of the namespace.
+			r.reportOrphanedMonitors(ctx, instance)
+			break
+		}
+		if err != nil {
+			return reconcile.Result{}, err
+		}
 
-	// Remove monitor if it exists
-	for index := 0; index < len(r.MonitorServices); index++ {
-		r.removeMonitorIfExists(r.MonitorServices[index], monitorName)
+		var errs []error
+		for index := 0; index < len(monitorServices); index++ {
+			err := r.removeMonitorsIfExist(ctx, monitorServices[index], instance, monitorName)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) != 0 {
+			// Returning an error requeues the request with exponential backoff
+			return reconcile.Result{}, utilerrors.NewAggregate(errs)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, endpointmonitorv1alpha1.EndpointMonitorFinalizer)
+	if err := r.Update(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+	if instance.Spec.CredentialsRef != nil {
+		r.releaseCredentials(ctx, instance)
 	}
 	return reconcile.Result{}, nil
 }
 
-func (r *EndpointMonitorReconciler) removeMonitorIfExists(monitorService monitors.MonitorServiceProxy, monitorName string) {
-	log := r.Log.WithValues("monitor", monitorName)
+// reportOrphanedMonitors records a warning event with the monitors of the instance that are left at their providers
+func (r *EndpointMonitorReconciler) reportOrphanedMonitors(ctx context.Context, instance *endpointmonitorv1alpha1.EndpointMonitor) {
+	var orphaned []string
+	for _, status := range instance.Status.Monitors {
+		orphaned = append(orphaned, fmt.Sprintf("%s of provider %s (ID %s)", status.Name, status.Provider, status.ID))
+	}
+	message := fmt.Sprintf("Credentials secret %s has been deleted, the monitors have to be removed from their providers manually: %s",
+		instance.Spec.CredentialsRef.Name, strings.Join(orphaned, ", "))
+	if len(orphaned) == 0 {
+		message = fmt.Sprintf("Credentials secret %s has been deleted, no monitor has been recorded for removal", instance.Spec.CredentialsRef.Name)
+	}
+	r.Log.Info(message, "endpointMonitor", instance.Namespace+"/"+instance.Name)
+	r.recordEvent(ctx, instance, corev1.EventTypeWarning, ReasonMonitorsOrphaned, message)
+}
+
+// releaseCredentials evicts the monitor services of the credentials secret of a finalized instance from the cache,
+// unless other instances still reference the secret
+func (r *EndpointMonitorReconciler) releaseCredentials(ctx context.Context, instance *endpointmonitorv1alpha1.EndpointMonitor) {
+	instances := &endpointmonitorv1alpha1.EndpointMonitorList{}
+	err := r.List(ctx, instances, client.InNamespace(instance.Namespace), client.MatchingFields{credentialsRefIndex: instance.Spec.CredentialsRef.Name})
+	if err != nil {
+		r.Log.Error(err, "Failed to list EndpointMonitors, keeping cached credentials")
+		return
+	}
+	for index := range instances.Items {
+		other := &instances.Items[index]
+		if other.UID != instance.UID && other.Spec.CredentialsRef != nil && other.Spec.CredentialsRef.Name == instance.Spec.CredentialsRef.Name {
+			return
+		}
+	}
+	r.CredentialsCache.Remove(getCredentialsKey(instance))
+}
+
+// getConfigError returns the problems of the configuration of the controller if they affect a provider of the instance
+func getConfigError(health *config.Health, instance *endpointmonitorv1alpha1.EndpointMonitor) error {
+	if len(instance.Spec.Providers) == 0 {
+		// The monitor is registered with all configured providers
+		return health.ProviderError(nil)
+	}
+	providers := append([]string{}, instance.Spec.Providers...)
+	for _, monitorStatus := range instance.Status.Monitors {
+		providers = append(providers, monitorStatus.Provider)
+	}
+	return health.ProviderError(providers)
+}
+
+// hasUnavailableProvider returns true if a monitor of the instance belongs to a provider whose ProviderConfig is invalid
+func (r *EndpointMonitorReconciler) hasUnavailableProvider(instance *endpointmonitorv1alpha1.EndpointMonitor) bool {
+	for _, monitorStatus := range instance.Status.Monitors {
+		if r.MonitorServices.IsUnavailable(monitorStatus.Provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// getMonitorServicesForDelete returns the monitor services to remove the monitors of the instance from. The services
+// last built from its credentials secret are used if the secret is gone, e.g. because the namespace is being deleted.
+// The error of the secret is returned if they aren't cached, e.g. after a restart of the controller.
+func (r *EndpointMonitorReconciler) getMonitorServicesForDelete(instance *endpointmonitorv1alpha1.EndpointMonitor) ([]monitors.MonitorServiceProxy, error) {
+	monitorServices, err := r.getMonitorServices(instance)
+	if err == nil {
+		return monitorServices, nil
+	}
+	if instance.Spec.CredentialsRef != nil {
+		if cached, ok := r.CredentialsCache.GetCached(getCredentialsKey(instance)); ok {
+			r.Log.Info("Using cached credentials to remove monitors: " + err.Error())
+			return cached, nil
+		}
+	}
+	return nil, err
+}
 
-	monitor, _ := monitorService.GetByName(monitorName)
-	// Monitor Exists
-	if monitor != nil {
-		// Monitor Exists, remove the monitor
-		log.Info("Removing monitor with name: " + monitorName + " for provider: " + monitorService.GetType())
-		monitorService.Remove(*monitor)
-	} else {
-		log.Info("Cannot find monitor with name: " + monitorName + " for provider: " + monitorService.GetType())
+// handleProviderRemoved removes the remote monitors of a provider that the instance doesn't target anymore.
+// Monitors are kept at the provider if monitor deletion is disabled, only their status is dropped.
+func (r *EndpointMonitorReconciler) handleProviderRemoved(ctx context.Context, instance *endpointmonitorv1alpha1.EndpointMonitor, monitorService monitors.MonitorServiceProxy) error {
+	return r.removeTargets(ctx, instance, monitorService, func(string) bool { return true })
+}
+
+// handleTargetsRemoved removes the remote monitors of targets that the URL source doesn't resolve to anymore,
+// e.g. because a rule has been removed from the Ingress
+func (r *EndpointMonitorReconciler) handleTargetsRemoved(ctx context.Context, instance *endpointmonitorv1alpha1.EndpointMonitor, monitorService monitors.MonitorServiceProxy, targets []kubeutil.MonitorTarget) error {
+	current := make(map[string]bool)
+	for _, target := range targets {
+		current[target.Name] = true
+	}
+	return r.removeTargets(ctx, instance, monitorService, func(target string) bool { return !current[target] })
+}
+
+// removeTargets removes the remote monitors of the selected targets registered with the provider, along with their status
+func (r *EndpointMonitorReconciler) removeTargets(ctx context.Context, instance *endpointmonitorv1alpha1.EndpointMonitor, monitorService monitors.MonitorServiceProxy, removed func(target string) bool) error {
+	var errs []error
+	for _, status := range instance.Status.GetMonitorStatuses(monitorService.GetName()) {
+		if !removed(status.Target) {
+			continue
+		}
+
+		if config.GetControllerConfig().EnableMonitorDeletion {
+			if err := r.removeMonitor(ctx, instance, monitorService, status); err != nil {
+				setMonitorStatus(instance, monitorService.GetName(), status.Target, models.Monitor{Name: status.Name}, err)
+				errs = append(errs, err)
+				continue
+			}
+		} else {
+			r.Log.Info("Monitor deletion is disabled. Keeping monitor: " + status.Name + " at provider: " + monitorService.GetName())
+		}
+		instance.Status.RemoveMonitorStatus(monitorService.GetName(), status.Target)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// removeMonitorsIfExist removes all remote monitors of the instance from the provider
+func (r *EndpointMonitorReconciler) removeMonitorsIfExist(ctx context.Context, monitorService monitors.MonitorServiceProxy, instance *endpointmonitorv1alpha1.EndpointMonitor, monitorName string) error {
+	statuses := instance.Status.GetMonitorStatuses(monitorService.GetName())
+	if len(statuses) == 0 && instance.Spec.HasProvider(monitorService.GetName()) {
+		// Instances created before the remote monitors were recorded in the status are looked up by name
+		statuses = []endpointmonitorv1alpha1.MonitorStatus{{Provider: monitorService.GetName(), Name: monitorName}}
+	}
+
+	var errs []error
+	for _, status := range statuses {
+		if err := r.removeMonitor(ctx, instance, monitorService, status); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// removeMonitor removes the remote monitor recorded in the given status of the instance from the provider
+func (r *EndpointMonitorReconciler) removeMonitor(ctx context.Context, instance *endpointmonitorv1alpha1.EndpointMonitor, monitorService monitors.MonitorServiceProxy, status endpointmonitorv1alpha1.MonitorStatus) error {
+	log := r.Log.WithValues("monitor", status.Name)
+
+	monitor, err := findMonitorFromStatus(monitorService, status)
+	if err != nil {
+		log.Error(err, "Failed to find monitor with name: "+status.Name+" for provider: "+monitorService.GetName())
+		return err
+	}
+	if monitor == nil {
+		log.Info("Cannot find monitor with name: " + status.Name + " for provider: " + monitorService.GetName())
+		return nil
+	}
+
+	log.Info("Removing monitor with name: " + monitor.Name + " for provider: " + monitorService.GetName())
+	err = monitorService.Remove(*monitor)
+	if monitorerrors.IsNotFound(err) {
+		log.Info("Monitor with name: " + monitor.Name + " has already been removed from provider: " + monitorService.GetName())
+		return nil
+	}
+	if err != nil {
+		log.Error(err, "Failed to remove monitor with name: "+monitor.Name+" for provider: "+monitorService.GetName())
+		r.recordEvent(ctx, instance, corev1.EventTypeWarning, ReasonProviderError,
+			fmt.Sprintf("Failed to remove monitor %s from provider %s: %v", monitor.Name, monitorService.GetName(), err))
+		return err
+	}
+	r.recordEvent(ctx, instance, corev1.EventTypeNormal, ReasonMonitorDeleted,
+		fmt.Sprintf("Monitor %s has been removed from provider %s", monitor.Name, monitorService.GetName()))
+	return nil
+}
+
+// findMonitorFromStatus returns the remote monitor recorded in the given status
+func findMonitorFromStatus(monitorService monitors.MonitorServiceProxy, status endpointmonitorv1alpha1.MonitorStatus) (*models.Monitor, error) {
+	if len(status.Name) == 0 {
+		return nil, nil
+	}
+	if len(status.ID) == 0 {
+		// The ID hasn't been recorded, e.g. because the monitor couldn't be retrieved after its creation
+		return findMonitorByName(monitorService, status.Name)
 	}
+	return &models.Monitor{
+		Name: status.Name,
+		ID:   status.ID,
+		URL:  status.URL,
+	}, nil
 }
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+)
+
+func TestReleaseCredentialsEvictsUnreferencedSecrets(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := endpointmonitorv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	deleted := newTestEndpointMonitor("deleted", "team")
+	deleted.UID = "deleted"
+	deleted.Spec.CredentialsRef = &endpointmonitorv1alpha1.CredentialsReference{Name: "credentials"}
+	other := newTestEndpointMonitor("other", "team")
+	other.UID = "other"
+	other.Spec.CredentialsRef = &endpointmonitorv1alpha1.CredentialsReference{Name: "credentials"}
+	fakeClient := fakekubeclient.NewClientBuilder().WithScheme(scheme).WithObjects(other).Build()
+
+	cache := &monitors.MonitorServiceCache{}
+	key := types.NamespacedName{Namespace: "team", Name: "credentials"}
+	if _, err := cache.Get(key, "providers:\n  - name: StatusCake\n"); err != nil {
+		t.Fatal(err)
+	}
+	reconciler := &EndpointMonitorReconciler{Client: fakeClient, Log: logr.Discard(), CredentialsCache: cache}
+
+	reconciler.releaseCredentials(context.TODO(), deleted)
+	if _, ok := cache.GetCached(key); !ok {
+		t.Error("Expected the credentials referenced by another EndpointMonitor to be kept")
+	}
+
+	if err := fakeClient.Delete(context.TODO(), other); err != nil {
+		t.Fatal(err)
+	}
+	reconciler.releaseCredentials(context.TODO(), other)
+	if _, ok := cache.GetCached(key); ok {
+		t.Error("Expected the credentials of the last EndpointMonitor to be evicted")
+	}
+}
+
+func TestHandleDeleteReleasesFinalizerIfCredentialsSecretIsGone(t *testing.T) {
+	config.SetControllerConfig(config.Config{EnableMonitorDeletion: true})
+	defer config.SetControllerConfig(config.Config{})
+
+	scheme := runtime.NewScheme()
+	if err := endpointmonitorv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	instance := newTestEndpointMonitor("shop", "team")
+	instance.Finalizers = []string{endpointmonitorv1alpha1.EndpointMonitorFinalizer}
+	now := metav1.Now()
+	instance.DeletionTimestamp = &now
+	instance.Spec.CredentialsRef = &endpointmonitorv1alpha1.CredentialsReference{Name: "credentials"}
+	instance.Status.Monitors = []endpointmonitorv1alpha1.MonitorStatus{{Provider: "team-pingdom", Name: "shop-team", ID: "42"}}
+	fakeClient := fakekubeclient.NewClientBuilder().WithScheme(scheme).WithObjects(instance).Build()
+
+	recorder := record.NewFakeRecorder(10)
+	reconciler := &EndpointMonitorReconciler{
+		Client:           fakeClient,
+		APIReader:        fakeClient,
+		Log:              logr.Discard(),
+		MonitorServices:  &monitors.MonitorServiceRegistry{},
+		CredentialsCache: &monitors.MonitorServiceCache{},
+		ConfigHealth:     &config.Health{},
+		Recorder:         recorder,
+	}
+
+	// The secret has been deleted and the cache is empty, e.g. after a restart of the controller
+	if _, err := reconciler.handleDelete(context.TODO(), instance, "shop-team"); err != nil {
+		t.Fatal(err)
+	}
+	// The instance is gone once its finalizer is released
+	updated := &endpointmonitorv1alpha1.EndpointMonitor{}
+	err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "shop", Namespace: "team"}, updated)
+	if !errors.IsNotFound(err) {
+		t.Errorf("Expected the finalizer to be released, got %v and finalizers %v", err, updated.Finalizers)
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, ReasonMonitorsOrphaned) || !strings.Contains(event, "ID 42") {
+			t.Errorf("Expected a warning event with the orphaned monitor, got %s", event)
+		}
+	default:
+		t.Error("Expected a warning event with the orphaned monitor")
+	}
+}
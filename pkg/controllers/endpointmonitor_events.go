@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"context"
+
+	routev1 "github.com/openshift/api/route/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/kube"
+)
+
+// Reasons of the events recorded for the monitors of an EndpointMonitor
+const (
+	ReasonMonitorCreated   = "MonitorCreated"
+	ReasonMonitorUpdated   = "MonitorUpdated"
+	ReasonMonitorDeleted   = "MonitorDeleted"
+	ReasonProviderError    = "ProviderError"
+	ReasonMonitorsOrphaned = "MonitorsOrphaned"
+)
+
+// recordEvent records an event on the instance and on the Ingress or Route its URL is derived from
+func (r *EndpointMonitorReconciler) recordEvent(ctx context.Context, instance *endpointmonitorv1alpha1.EndpointMonitor, eventType string, reason string, message string) {
+	r.Recorder.Event(instance, eventType, reason, message)
+	if source := r.getURLSourceObject(ctx, instance); source != nil {
+		r.Recorder.Event(source, eventType, reason, "EndpointMonitor "+instance.Name+": "+message)
+	}
+}
+
+// getURLSourceObject returns the Ingress or Route the URL of the instance is derived from, or nil if there is none
+// or it doesn't exist
+func (r *EndpointMonitorReconciler) getURLSourceObject(ctx context.Context, instance *endpointmonitorv1alpha1.EndpointMonitor) client.Object {
+	urlFrom := instance.Spec.URLFrom
+	if urlFrom == nil {
+		return nil
+	}
+
+	var source client.Object
+	var name string
+	switch {
+	case urlFrom.IngressRef != nil:
+		source, name = &networkingv1.Ingress{}, urlFrom.IngressRef.Name
+	case urlFrom.RouteRef != nil && kube.IsOpenshift:
+		source, name = &routev1.Route{}, urlFrom.RouteRef.Name
+	default:
+		return nil
+	}
+
+	// The event must reference the UID of the source to be listed with it
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.Namespace}, source); err != nil {
+		return nil
+	}
+	return source
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
+	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+)
+
+// MonitorStatusExporter periodically polls the providers for the state of the checks of the EndpointMonitors if the
+// status exporter is enabled. The state is mirrored into the status of the EndpointMonitors and published as metrics.
+// It runs under the manager and only on the leader, so that the providers are polled once.
+type MonitorStatusExporter struct {
+	client.Client
+	Log             logr.Logger
+	MonitorServices *monitors.MonitorServiceRegistry
+	// APIReader reads the credentials secrets without caching all secrets of the cluster
+	APIReader client.Reader
+	// CredentialsCache holds the monitor services built from the credentials secrets of the instances
+	CredentialsCache *monitors.MonitorServiceCache
+}
+
+// checkSample holds the state of a check published as metrics
+type checkSample struct {
+	labels []string
+	status *endpointmonitorv1alpha1.CheckStatus
+}
+
+// Start polls the providers until the context is cancelled
+func (e *MonitorStatusExporter) Start(ctx context.Context) error {
+	for {
+		// The config is read on every run so that changes are picked up without a restart
+		exporterConfig := config.GetControllerConfig().StatusExporter
+		if !exporterConfig.Enabled {
+			recordCheckSamples(nil)
+		} else {
+			// Only the providers with a valid configuration are polled
+			e.export(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(exporterConfig.GetInterval()):
+		}
+	}
+}
+
+// NeedLeaderElection makes sure that only a single replica polls the providers
+func (e *MonitorStatusExporter) NeedLeaderElection() bool {
+	return true
+}
+
+func (e *MonitorStatusExporter) export(ctx context.Context) {
+	instances := &endpointmonitorv1alpha1.EndpointMonitorList{}
+	if err := e.List(ctx, instances); err != nil {
+		e.Log.Error(err, "Failed to list EndpointMonitors, skipping status export")
+		return
+	}
+
+	var samples []checkSample
+	for index := range instances.Items {
+		instance := &instances.Items[index]
+		if instance.DeletionTimestamp.IsZero() {
+			e.pollInstance(ctx, instance)
+		}
+		samples = append(samples, getCheckSamples(instance)...)
+	}
+	recordCheckSamples(samples)
+}
+
+// pollInstance updates the state of the checks of the monitors of the instance. Checks that can't be polled keep
+// their last known state.
+func (e *MonitorStatusExporter) pollInstance(ctx context.Context, instance *endpointmonitorv1alpha1.EndpointMonitor) {
+	log := e.Log.WithValues("endpointMonitor", client.ObjectKeyFromObject(instance))
+
+	monitorServices, err := loadMonitorServices(e.APIReader, e.MonitorServices, e.CredentialsCache, instance)
+	if err != nil {
+		log.Error(err, "Failed to load monitor services, skipping status export")
+		return
+	}
+	services := make(map[string]monitors.MonitorServiceProxy)
+	for _, monitorService := range monitorServices {
+		services[monitorService.GetName()] = monitorService
+	}
+
+	base := instance.DeepCopy()
+	polled := false
+	for index := range instance.Status.Monitors {
+		status := &instance.Status.Monitors[index]
+		monitorService, exists := services[status.Provider]
+		if !exists || !monitorService.SupportsCheckStatus() || len(status.ID) == 0 {
+			continue
+		}
+
+		checkStatus, err := monitorService.GetCheckStatus(models.Monitor{Name: status.Name, ID: status.ID, URL: status.URL})
+		if err != nil {
+			log.Error(err, "Failed to get status of monitor "+status.Name+" from provider "+status.Provider)
+			continue
+		}
+		status.CheckStatus = toCheckStatus(checkStatus, metav1.Now())
+		polled = true
+	}
+	if !polled {
+		return
+	}
+
+	// Conflicting changes of the reconciler win, the state is polled again on the next run
+	patch := client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})
+	if err := e.Status().Patch(ctx, instance, patch); err != nil {
+		log.Error(err, "Failed to update check statuses")
+	}
+}
+
+// toCheckStatus converts the state of a check reported by a provider to its status
+func toCheckStatus(checkStatus *models.CheckStatus, pollTime metav1.Time) *endpointmonitorv1alpha1.CheckStatus {
+	status := &endpointmonitorv1alpha1.CheckStatus{State: checkStatus.State, LastPollTime: pollTime}
+	if checkStatus.ResponseTime != nil {
+		responseTime := checkStatus.ResponseTime.Milliseconds()
+		status.ResponseTimeMilliseconds = &responseTime
+	}
+	if checkStatus.UptimeRatio != nil {
+		status.UptimeRatio = strconv.FormatFloat(math.Round(*checkStatus.UptimeRatio*1e6)/1e6, 'f', -1, 64)
+	}
+	return status
+}
+
+// getCheckSamples returns the state of the checks recorded in the status of the instance
+func getCheckSamples(instance *endpointmonitorv1alpha1.EndpointMonitor) []checkSample {
+	var samples []checkSample
+	for _, status := range instance.Status.Monitors {
+		if status.CheckStatus == nil {
+			continue
+		}
+		samples = append(samples, checkSample{
+			labels: []string{status.Provider, instance.Namespace, instance.Name, status.Target},
+			status: status.CheckStatus,
+		})
+	}
+	return samples
+}
+
+// recordCheckSamples replaces the check metrics with the given samples
+func recordCheckSamples(samples []checkSample) {
+	metrics.CheckUp.Reset()
+	metrics.CheckResponseTime.Reset()
+	metrics.CheckUptimeRatio.Reset()
+	for _, sample := range samples {
+		switch sample.status.State {
+		case models.CheckStateUp:
+			metrics.CheckUp.WithLabelValues(sample.labels...).Set(1)
+		case models.CheckStateDown:
+			metrics.CheckUp.WithLabelValues(sample.labels...).Set(0)
+		}
+		if sample.status.ResponseTimeMilliseconds != nil {
+			responseTime := time.Duration(*sample.status.ResponseTimeMilliseconds) * time.Millisecond
+			metrics.CheckResponseTime.WithLabelValues(sample.labels...).Set(responseTime.Seconds())
+		}
+		if ratio, err := strconv.ParseFloat(sample.status.UptimeRatio, 64); err == nil {
+			metrics.CheckUptimeRatio.WithLabelValues(sample.labels...).Set(ratio)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
+	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+)
+
+// MonitorGarbageCollector periodically removes the remote monitors whose EndpointMonitor doesn't exist anymore,
+// e.g. because it was deleted while the controller was down or because the MonitorNameTemplate changed.
+// It runs under the manager and only on the leader.
+type MonitorGarbageCollector struct {
+	client.Client
+	Log             logr.Logger
+	MonitorServices *monitors.MonitorServiceRegistry
+	ConfigHealth    *config.Health
+}
+
+// Start runs the garbage collection until the context is cancelled
+func (gc *MonitorGarbageCollector) Start(ctx context.Context) error {
+	for {
+		gcConfig := config.GetControllerConfig().GarbageCollection
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(gcConfig.GetInterval()):
+		}
+
+		// The config is read on every run so that changes are picked up without a restart
+		gcConfig = config.GetControllerConfig().GarbageCollection
+		if !gcConfig.Enabled {
+			continue
+		}
+		gc.collect(ctx, gcConfig)
+	}
+}
+
+// NeedLeaderElection makes sure that only a single replica removes monitors
+func (gc *MonitorGarbageCollector) NeedLeaderElection() bool {
+	return true
+}
+
+func (gc *MonitorGarbageCollector) collect(ctx context.Context, gcConfig config.GarbageCollection) {
+	// Remove monitors only if deletion is enabled, report them otherwise. Without a prefix the monitors of the
+	// controller can't be told apart from the other monitors of the accounts, which are never removed.
+	dryRun := gcConfig.DryRun || !config.GetControllerConfig().EnableMonitorDeletion
+	if !dryRun && len(gcConfig.MonitorNamePrefix) == 0 {
+		gc.Log.Info("Garbage collection runs in dry run mode because monitorNamePrefix is empty")
+		dryRun = true
+	}
+
+	log := gc.Log.WithValues("dryRun", dryRun)
+	log.Info("Starting garbage collection of orphaned monitors")
+
+	// Remote monitors are listed before the EndpointMonitors, so that a monitor created in between
+	// always belongs to an EndpointMonitor that is part of the list
+	monitorServices := gc.MonitorServices.Get()
+	remoteMonitors := make([][]models.Monitor, len(monitorServices))
+	for index := 0; index < len(monitorServices); index++ {
+		remoteMonitors[index] = monitorServices[index].GetAll()
+	}
+
+	instances := &endpointmonitorv1alpha1.EndpointMonitorList{}
+	if err := gc.List(ctx, instances); err != nil {
+		log.Error(err, "Failed to list EndpointMonitors, skipping garbage collection")
+		return
+	}
+
+	for index := 0; index < len(monitorServices); index++ {
+		monitorService := monitorServices[index]
+		if err := gc.ConfigHealth.ProviderError([]string{monitorService.GetName()}); err != nil {
+			// The monitors of a duplicate provider can't be told apart
+			log.Error(err, "Configuration is degraded, skipping garbage collection for provider: "+monitorService.GetName())
+			continue
+		}
+		ownedIDs, ownedNames, ownedPrefixes := getOwnedMonitors(instances.Items, monitorService.GetName())
+
+		orphaned := 0
+		for _, monitor := range remoteMonitors[index] {
+			if !strings.HasPrefix(monitor.Name, gcConfig.MonitorNamePrefix) {
+				// Not managed by the controller
+				continue
+			}
+			if ownedIDs[monitor.ID] || ownedNames[monitor.Name] || hasAnyPrefix(monitor.Name, ownedPrefixes) {
+				continue
+			}
+
+			orphaned++
+			if dryRun {
+				log.Info("Found orphaned monitor with name: " + monitor.Name + " and id: " + monitor.ID + " for provider: " + monitorService.GetName())
+				continue
+			}
+
+			log.Info("Removing orphaned monitor with name: " + monitor.Name + " and id: " + monitor.ID + " for provider: " + monitorService.GetName())
+			if err := monitorService.Remove(monitor); err != nil && !monitorerrors.IsNotFound(err) {
+				log.Error(err, "Failed to remove orphaned monitor with name: "+monitor.Name+" for provider: "+monitorService.GetName())
+			}
+		}
+		metrics.OrphanedMonitors.WithLabelValues(monitorService.GetName()).Set(float64(orphaned))
+	}
+}
+
+// getOwnedMonitors returns the IDs, names and name prefixes of the remote monitors of a provider that belong to the
+// given instances. The accounts of the credentials secrets may be the same as the account of the provider, so the
+// monitors recorded for any provider and the monitors of instances with credentials secrets are owned as well.
+func getOwnedMonitors(instances []endpointmonitorv1alpha1.EndpointMonitor, provider string) (map[string]bool, map[string]bool, []string) {
+	ids := make(map[string]bool)
+	names := make(map[string]bool)
+	var prefixes []string
+	for index := range instances {
+		instance := &instances[index]
+
+		// Monitors that haven't been recorded in the status yet are matched by their name
+		if instance.Spec.HasProvider(provider) || instance.Spec.CredentialsRef != nil {
+			monitorName, _ := getMonitorName(instance.Name, instance.Namespace)
+			names[monitorName] = true
+			if urlFrom := instance.Spec.URLFrom; urlFrom != nil && urlFrom.IngressRef != nil && urlFrom.IngressRef.AllRules {
+				// The names of the monitors of the targets are derived from the name of the instance
+				prefixes = append(prefixes, monitorName+"-")
+			}
+		}
+
+		for _, status := range instance.Status.Monitors {
+			if len(status.ID) != 0 {
+				ids[status.ID] = true
+			}
+			if len(status.Name) != 0 {
+				names[status.Name] = true
+			}
+		}
+	}
+	return ids, names, prefixes
+}
+
+func hasAnyPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+)
+
+// fakeMonitorService keeps the monitors of a provider in memory
+type fakeMonitorService struct {
+	monitors []models.Monitor
+	removed  []string
+}
+
+func (s *fakeMonitorService) GetAll() []models.Monitor { return s.monitors }
+
+func (s *fakeMonitorService) Add(m models.Monitor) error { return nil }
+
+func (s *fakeMonitorService) Update(m models.Monitor) error { return nil }
+
+func (s *fakeMonitorService) GetByName(name string) (*models.Monitor, error) { return nil, nil }
+
+func (s *fakeMonitorService) Remove(m models.Monitor) error {
+	s.removed = append(s.removed, m.Name)
+	return nil
+}
+
+func (s *fakeMonitorService) Setup(p config.Provider) error { return nil }
+
+func (s *fakeMonitorService) Equal(oldMonitor models.Monitor, newMonitor models.Monitor) bool {
+	return true
+}
+
+func newTestEndpointMonitor(name string, namespace string, providers ...string) *endpointmonitorv1alpha1.EndpointMonitor {
+	return &endpointmonitorv1alpha1.EndpointMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       endpointmonitorv1alpha1.EndpointMonitorSpec{URL: "https://" + name + ".example.com", Providers: providers},
+	}
+}
+
+func newTestGarbageCollector(t *testing.T, monitorService *fakeMonitorService, instances ...*endpointmonitorv1alpha1.EndpointMonitor) *MonitorGarbageCollector {
+	scheme := runtime.NewScheme()
+	if err := endpointmonitorv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	builder := fakekubeclient.NewClientBuilder().WithScheme(scheme)
+	for _, instance := range instances {
+		builder = builder.WithObjects(instance)
+	}
+
+	registry := &monitors.MonitorServiceRegistry{}
+	registry.Set([]monitors.MonitorServiceProxy{monitors.NewMonitorServiceProxy("UptimeRobot", "UptimeRobot", monitorService)})
+	return &MonitorGarbageCollector{
+		Client:          builder.Build(),
+		Log:             logr.Discard(),
+		MonitorServices: registry,
+		ConfigHealth:    &config.Health{},
+	}
+}
+
+func TestGetOwnedMonitors(t *testing.T) {
+	config.SetControllerConfig(config.Config{MonitorNameTemplate: "imc-{{.Namespace}}-{{.Name}}"})
+	defer config.SetControllerConfig(config.Config{})
+
+	allRules := newTestEndpointMonitor("shop", "web")
+	allRules.Spec.URLFrom = &endpointmonitorv1alpha1.URLSource{
+		IngressRef: &endpointmonitorv1alpha1.IngressURLSource{Name: "shop", AllRules: true},
+	}
+	recorded := newTestEndpointMonitor("api", "web", "Pingdom")
+	recorded.Status.Monitors = []endpointmonitorv1alpha1.MonitorStatus{
+		{Provider: "UptimeRobot", Name: "api-renamed", ID: "42"},
+		{Provider: "Pingdom", Name: "api-pingdom", ID: "7"},
+	}
+	// The providers of the credentials secret may share the account of a configured provider
+	credentials := newTestEndpointMonitor("blog", "web", "UptimeRobotBlog")
+	credentials.Spec.CredentialsRef = &endpointmonitorv1alpha1.CredentialsReference{Name: "blog-credentials"}
+	credentials.Status.Monitors = []endpointmonitorv1alpha1.MonitorStatus{{Provider: "UptimeRobotBlog", Name: "imc-web-blog", ID: "9"}}
+	instances := []endpointmonitorv1alpha1.EndpointMonitor{*allRules, *recorded, *credentials}
+
+	ids, names, prefixes := getOwnedMonitors(instances, "UptimeRobot")
+	// The monitors recorded for any provider are owned
+	if !reflect.DeepEqual(ids, map[string]bool{"42": true, "7": true, "9": true}) {
+		t.Errorf("Unexpected owned IDs %v", ids)
+	}
+	// The monitor of an instance registered with other providers is only owned by its recorded names
+	if !reflect.DeepEqual(names, map[string]bool{"imc-web-shop": true, "api-renamed": true, "api-pingdom": true, "imc-web-blog": true}) {
+		t.Errorf("Unexpected owned names %v", names)
+	}
+	if !reflect.DeepEqual(prefixes, []string{"imc-web-shop-"}) {
+		t.Errorf("Unexpected owned prefixes %v", prefixes)
+	}
+
+	ids, names, prefixes = getOwnedMonitors(instances, "Pingdom")
+	if !reflect.DeepEqual(ids, map[string]bool{"42": true, "7": true, "9": true}) ||
+		!reflect.DeepEqual(names, map[string]bool{"imc-web-shop": true, "imc-web-api": true, "api-renamed": true, "api-pingdom": true, "imc-web-blog": true}) ||
+		len(prefixes) != 1 {
+		t.Errorf("Unexpected owned monitors %v, %v and %v", ids, names, prefixes)
+	}
+}
+
+func TestGarbageCollectionKeepsMonitorsOfCredentialsSecrets(t *testing.T) {
+	config.SetControllerConfig(config.Config{MonitorNameTemplate: "imc-{{.Namespace}}-{{.Name}}", EnableMonitorDeletion: true})
+	defer config.SetControllerConfig(config.Config{})
+
+	// The credentials secrets use the same account as the configured provider
+	monitorService := &fakeMonitorService{monitors: []models.Monitor{
+		{Name: "imc-web-blog", ID: "1"},
+		{Name: "imc-web-renamed", ID: "2"},
+		{Name: "imc-web-deleted", ID: "3"},
+	}}
+	pending := newTestEndpointMonitor("blog", "web")
+	pending.Spec.CredentialsRef = &endpointmonitorv1alpha1.CredentialsReference{Name: "blog-credentials"}
+	recorded := newTestEndpointMonitor("api", "web")
+	recorded.Spec.CredentialsRef = &endpointmonitorv1alpha1.CredentialsReference{Name: "api-credentials"}
+	recorded.Status.Monitors = []endpointmonitorv1alpha1.MonitorStatus{{Provider: "UptimeRobotTeam", Name: "imc-web-renamed", ID: "2"}}
+	gc := newTestGarbageCollector(t, monitorService, pending, recorded)
+
+	gc.collect(context.TODO(), config.GarbageCollection{Enabled: true, MonitorNamePrefix: "imc-"})
+	if !reflect.DeepEqual(monitorService.removed, []string{"imc-web-deleted"}) {
+		t.Errorf("Expected only the orphaned monitor to be removed, got %v", monitorService.removed)
+	}
+}
+
+func TestGarbageCollectionRemovesOrphanedMonitors(t *testing.T) {
+	config.SetControllerConfig(config.Config{MonitorNameTemplate: "imc-{{.Namespace}}-{{.Name}}", EnableMonitorDeletion: true})
+	defer config.SetControllerConfig(config.Config{})
+
+	monitorService := &fakeMonitorService{monitors: []models.Monitor{
+		{Name: "imc-web-shop", ID: "1"},
+		{Name: "imc-web-deleted", ID: "2"},
+		{Name: "imc-web-renamed", ID: "3"},
+		{Name: "manual-check", ID: "4"},
+	}}
+	recorded := newTestEndpointMonitor("api", "web")
+	recorded.Status.Monitors = []endpointmonitorv1alpha1.MonitorStatus{{Provider: "UptimeRobot", Name: "imc-web-renamed", ID: "3"}}
+	gc := newTestGarbageCollector(t, monitorService, newTestEndpointMonitor("shop", "web"), recorded)
+
+	gc.collect(context.TODO(), config.GarbageCollection{Enabled: true, MonitorNamePrefix: "imc-"})
+	sort.Strings(monitorService.removed)
+	if !reflect.DeepEqual(monitorService.removed, []string{"imc-web-deleted"}) {
+		t.Errorf("Expected only the orphaned monitor with the prefix to be removed, got %v", monitorService.removed)
+	}
+}
+
+func TestGarbageCollectionOnlyReportsOrphanedMonitors(t *testing.T) {
+	monitorService := &fakeMonitorService{monitors: []models.Monitor{{Name: "imc-web-deleted", ID: "2"}, {Name: "manual-check", ID: "4"}}}
+	gc := newTestGarbageCollector(t, monitorService)
+
+	for name, test := range map[string]struct {
+		enableMonitorDeletion bool
+		gcConfig              config.GarbageCollection
+	}{
+		"dry run":                     {true, config.GarbageCollection{Enabled: true, DryRun: true, MonitorNamePrefix: "imc-"}},
+		"deletion disabled":           {false, config.GarbageCollection{Enabled: true, MonitorNamePrefix: "imc-"}},
+		"empty name prefix":           {true, config.GarbageCollection{Enabled: true}},
+		"no deletion nor name prefix": {false, config.GarbageCollection{Enabled: true}},
+	} {
+		config.SetControllerConfig(config.Config{EnableMonitorDeletion: test.enableMonitorDeletion})
+		gc.collect(context.TODO(), test.gcConfig)
+		if len(monitorService.removed) != 0 {
+			t.Errorf("Expected no monitor to be removed with %s, got %v", name, monitorService.removed)
+		}
+	}
+	config.SetControllerConfig(config.Config{})
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
+)
+
+// monitorMetricsInterval is the interval at which the monitor gauges are recorded
+const monitorMetricsInterval = time.Minute
+
+// MonitorMetricsRecorder periodically records the number of managed and failing monitors of each provider from the
+// statuses of the EndpointMonitors. It runs under the manager on every replica.
+type MonitorMetricsRecorder struct {
+	client.Client
+	Log logr.Logger
+}
+
+// Start records the metrics until the context is cancelled
+func (m *MonitorMetricsRecorder) Start(ctx context.Context) error {
+	for {
+		m.record(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(monitorMetricsInterval):
+		}
+	}
+}
+
+// NeedLeaderElection lets standby replicas report the metrics as well
+func (m *MonitorMetricsRecorder) NeedLeaderElection() bool {
+	return false
+}
+
+func (m *MonitorMetricsRecorder) record(ctx context.Context) {
+	instances := &endpointmonitorv1alpha1.EndpointMonitorList{}
+	if err := m.List(ctx, instances); err != nil {
+		m.Log.Error(err, "Failed to list EndpointMonitors, skipping monitor metrics")
+		return
+	}
+
+	managed, failing := countMonitors(instances.Items)
+	metrics.ManagedMonitors.Reset()
+	for provider, count := range managed {
+		metrics.ManagedMonitors.WithLabelValues(provider).Set(float64(count))
+	}
+	metrics.FailingMonitors.Reset()
+	for provider, count := range failing {
+		metrics.FailingMonitors.WithLabelValues(provider).Set(float64(count))
+	}
+}
+
+// countMonitors returns the number of monitors registered with each provider and the number of those failing to sync
+func countMonitors(instances []endpointmonitorv1alpha1.EndpointMonitor) (map[string]int, map[string]int) {
+	managed := make(map[string]int)
+	failing := make(map[string]int)
+	for index := range instances {
+		for _, status := range instances[index].Status.Monitors {
+			// Providers are reported even if none of their monitors could be created
+			if _, exists := managed[status.Provider]; !exists {
+				managed[status.Provider] = 0
+				failing[status.Provider] = 0
+			}
+			if len(status.ID) != 0 {
+				managed[status.Provider]++
+			}
+			if !meta.IsStatusConditionTrue(status.Conditions, endpointmonitorv1alpha1.ConditionTypeSynced) {
+				failing[status.Provider]++
+			}
+		}
+	}
+	return managed, failing
+}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	kubeutil "github.com/stakater/IngressMonitorController/v2/pkg/kube/util"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
+	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+)
+
+const (
+	ReasonSynced                 = "Synced"
+	ReasonSyncFailed             = "SyncFailed"
+	ReasonURLResolutionFailed    = "URLResolutionFailed"
+	ReasonNoProviders            = "NoProviders"
+	ReasonInvalidProviders       = "InvalidProviders"
+	ReasonCredentialsUnavailable = "CredentialsUnavailable"
+)
+
+// setMonitorStatus records the outcome of syncing the monitor of a target with a provider in the status of the instance
+func setMonitorStatus(instance *endpointmonitorv1alpha1.EndpointMonitor, provider string, target string, monitor models.Monitor, err error) {
+	status := endpointmonitorv1alpha1.MonitorStatus{Provider: provider, Target: target}
+	if existing := instance.Status.GetMonitorStatus(provider, target); existing != nil {
+		status = *existing
+	}
+
+	status.Name = monitor.Name
+	if len(monitor.ID) != 0 {
+		status.ID = monitor.ID
+	}
+	if len(monitor.URL) != 0 {
+		status.URL = monitor.URL
+	}
+
+	condition := metav1.Condition{
+		Type:               endpointmonitorv1alpha1.ConditionTypeSynced,
+		ObservedGeneration: instance.Generation,
+	}
+	if err == nil {
+		now := metav1.Now()
+		status.LastSyncTime = &now
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ReasonSynced
+		condition.Message = "Monitor is synced with " + provider
+	} else {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonSyncFailed
+		condition.Message = err.Error()
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	instance.Status.SetMonitorStatus(status)
+}
+
+// setHealthEndpointStatuses records how the health endpoints of the targets have been found in the status of the
+// instance. Static URLs have no health endpoint to discover.
+func setHealthEndpointStatuses(instance *endpointmonitorv1alpha1.EndpointMonitor, targets []kubeutil.MonitorTarget) {
+	var statuses []endpointmonitorv1alpha1.HealthEndpointStatus
+	for _, target := range targets {
+		if len(target.HealthEndpoint.Reason) == 0 {
+			continue
+		}
+		statuses = append(statuses, endpointmonitorv1alpha1.HealthEndpointStatus{
+			Target:  target.Name,
+			Path:    target.HealthEndpoint.Path,
+			Reason:  target.HealthEndpoint.Reason,
+			Message: target.HealthEndpoint.Message,
+		})
+	}
+	instance.Status.HealthEndpoints = statuses
+}
+
+// setURLResolutionFailed marks the instance as not ready because its URL could not be resolved
+func setURLResolutionFailed(instance *endpointmonitorv1alpha1.EndpointMonitor, err error) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               endpointmonitorv1alpha1.ConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             ReasonURLResolutionFailed,
+		Message:            err.Error(),
+	})
+}
+
+// setInvalidProviders marks the instance as not ready because it targets providers that aren't configured
+func setInvalidProviders(instance *endpointmonitorv1alpha1.EndpointMonitor, err error) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               endpointmonitorv1alpha1.ConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             ReasonInvalidProviders,
+		Message:            err.Error(),
+	})
+}
+
+// setCredentialsUnavailable marks the instance as not ready because its credentials secret could not be loaded
+func setCredentialsUnavailable(instance *endpointmonitorv1alpha1.EndpointMonitor, err error) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               endpointmonitorv1alpha1.ConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: instance.Generation,
+		Reason:             ReasonCredentialsUnavailable,
+		Message:            err.Error(),
+	})
+}
+
+// setReadyCondition aggregates the Synced conditions of all providers into the Ready condition of the instance
+func setReadyCondition(instance *endpointmonitorv1alpha1.EndpointMonitor) {
+	condition := metav1.Condition{
+		Type:               endpointmonitorv1alpha1.ConditionTypeReady,
+		ObservedGeneration: instance.Generation,
+	}
+
+	var failures []string
+	for _, status := range instance.Status.Monitors {
+		synced := meta.FindStatusCondition(status.Conditions, endpointmonitorv1alpha1.ConditionTypeSynced)
+		if synced == nil || synced.Status != metav1.ConditionTrue {
+			message := "not synced"
+			if synced != nil {
+				message = synced.Message
+			}
+			provider := status.Provider
+			if len(status.Target) != 0 {
+				provider += " (" + status.Target + ")"
+			}
+			failures = append(failures, fmt.Sprintf("%s: %s", provider, message))
+		}
+	}
+
+	switch {
+	case len(instance.Status.Monitors) == 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonNoProviders
+		condition.Message = "Monitor is not registered with any provider"
+	case len(failures) != 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonSyncFailed
+		condition.Message = strings.Join(failures, "; ")
+	default:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ReasonSynced
+		condition.Message = "Monitor is synced with all providers"
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, condition)
+}
+
+// getReconcileOutcome returns the outcome of a reconcile of the instance recorded in the metrics
+func getReconcileOutcome(instance *endpointmonitorv1alpha1.EndpointMonitor, err error) string {
+	if err != nil {
+		return metrics.ReconcileErrored
+	}
+	if meta.IsStatusConditionFalse(instance.Status.Conditions, endpointmonitorv1alpha1.ConditionTypeReady) {
+		return metrics.ReconcileFailed
+	}
+	return metrics.ReconcileSucceeded
+}
+
+// updateStatus persists the status of the instance
+func (r *EndpointMonitorReconciler) updateStatus(ctx context.Context, instance *endpointmonitorv1alpha1.EndpointMonitor) error {
+	instance.Status.ObservedGeneration = instance.Generation
+	return r.Status().Update(ctx, instance)
+}
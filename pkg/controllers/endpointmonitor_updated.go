@@ -2,28 +2,23 @@ package controllers
 
 import (
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
-	"github.com/stakater/IngressMonitorController/v2/pkg/kube/util"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
 	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
 
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-func (r *EndpointMonitorReconciler) handleUpdate(request reconcile.Request, instance *endpointmonitorv1alpha1.EndpointMonitor, monitor models.Monitor, monitorService monitors.MonitorServiceProxy) error {
-	url, err := util.GetMonitorURL(r.Client, instance)
-	if err != nil {
-		return err
-	}
-
+// handleUpdate updates the remote monitor if it differs from the instance and returns whether it has been updated
+func (r *EndpointMonitorReconciler) handleUpdate(request reconcile.Request, instance *endpointmonitorv1alpha1.EndpointMonitor, monitor models.Monitor, url string, basicAuthPassword string, monitorService monitors.MonitorServiceProxy) (bool, error) {
 	// Extract provider specific configuration
 	config := monitorService.ExtractConfig(instance.Spec)
 
 	// Create monitor Model
-	updatedMonitor := models.Monitor{Name: monitor.Name, ID: monitor.ID, URL: url, Config: config}
+	updatedMonitor := models.Monitor{Name: monitor.Name, ID: monitor.ID, URL: url, Config: config, Check: getCheck(instance.Spec.Check, url), BasicAuthPassword: basicAuthPassword}
 
 	// Compare and Update monitor for provider if required
 	if !monitorService.Equal(monitor, updatedMonitor) {
-		monitorService.Update(updatedMonitor)
+		return true, monitorService.Update(updatedMonitor)
 	}
-	return nil
+	return false, nil
 }
@@ -1,16 +1,165 @@
 package controllers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
 	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
+	"github.com/stakater/IngressMonitorController/v2/pkg/secret"
+	"github.com/stakater/IngressMonitorController/v2/pkg/util"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 )
 
-func findMonitorByName(monitorService monitors.MonitorServiceProxy, monitorName string) *models.Monitor {
+// getMonitorName returns the name of the remote monitor of an instance based on the MonitorNameTemplate.
+// If the template can't be parsed, the name is built from the default template and the error is returned.
+func getMonitorName(name string, namespace string) (string, error) {
+	format, err := util.GetNameTemplateFormat(config.GetControllerConfig().MonitorNameTemplate)
+	if err != nil {
+		return name + "-" + namespace, err
+	}
+	return fmt.Sprintf(format, name, namespace), nil
+}
+
+// getTargetMonitorName returns the name of the remote monitor of a target. Targets of URL sources that resolve to
+// several of them get a suffix derived from their host and path.
+func getTargetMonitorName(monitorName string, target string) string {
+	if len(target) == 0 {
+		return monitorName
+	}
+	suffix := strings.Trim(targetNameReplacer.ReplaceAllString(target, "-"), "-.")
+	return monitorName + "-" + suffix
+}
+
+var targetNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9.]+`)
+
+// findMonitor returns the remote monitor of a target, or nil if it doesn't exist. It is retrieved by the ID recorded in
+// its status if the provider supports it, so that it is found even if its name changed, and by its name otherwise.
+func findMonitor(monitorService monitors.MonitorServiceProxy, status *endpointmonitorv1alpha1.MonitorStatus, monitorName string) (*models.Monitor, error) {
+	if status != nil && len(status.ID) != 0 {
+		monitor, err := monitorService.GetByID(status.ID)
+		if err == nil && monitor != nil {
+			return monitor, nil
+		}
+		if err != nil && !errors.Is(err, monitors.ErrGetByIDNotSupported) && !monitorerrors.IsNotFound(err) {
+			return nil, err
+		}
+		// The monitor has been removed from the provider, or the provider can only look it up by name
+	}
+	return findMonitorByName(monitorService, monitorName)
+}
+
+// findMonitorByName returns the remote monitor with the given name, or nil if it doesn't exist. Other failures, e.g.
+// of an unavailable provider, are returned so that they aren't mistaken for a missing monitor, which would be created
+// again.
+func findMonitorByName(monitorService monitors.MonitorServiceProxy, monitorName string) (*models.Monitor, error) {
+	monitor, err := monitorService.GetByName(monitorName)
+	if monitorerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return monitor, nil
+}
+
+// validateProviders returns an error if the instance targets a provider that isn't configured for the controller
+func validateProviders(instance *endpointmonitorv1alpha1.EndpointMonitor, monitorServices []monitors.MonitorServiceProxy) error {
+	configured := make(map[string]bool)
+	for index := range monitorServices {
+		configured[monitorServices[index].GetName()] = true
+	}
 
-	monitor, _ := monitorService.GetByName(monitorName)
-	// Monitor Exists
-	if monitor != nil {
-		return monitor
+	var unknown []string
+	for _, provider := range instance.Spec.Providers {
+		if !configured[provider] {
+			unknown = append(unknown, provider)
+		}
+	}
+	if len(unknown) != 0 {
+		return fmt.Errorf("providers are not configured for the controller: %s", strings.Join(unknown, ", "))
 	}
 	return nil
 }
+
+// getMonitorServices returns the monitor services of the providers the instance can be registered with. These are
+// the providers of its credentials secret if it references one, and the configured providers otherwise.
+func (r *EndpointMonitorReconciler) getMonitorServices(instance *endpointmonitorv1alpha1.EndpointMonitor) ([]monitors.MonitorServiceProxy, error) {
+	return loadMonitorServices(r.APIReader, r.MonitorServices, r.CredentialsCache, instance)
+}
+
+// loadMonitorServices returns the monitor services of the instance from the registry, or from the cache of the
+// credentials secrets if it references one
+func loadMonitorServices(reader client.Reader, registry *monitors.MonitorServiceRegistry, credentialsCache *monitors.MonitorServiceCache, instance *endpointmonitorv1alpha1.EndpointMonitor) ([]monitors.MonitorServiceProxy, error) {
+	credentialsRef := instance.Spec.CredentialsRef
+	if credentialsRef == nil {
+		return registry.Get(), nil
+	}
+
+	data, err := secret.LoadSecretData(reader, credentialsRef.Name, instance.Namespace, credentialsRef.GetKey())
+	if err != nil {
+		return nil, fmt.Errorf("unable to load credentials secret %s: %w", credentialsRef.Name, err)
+	}
+	// The services are only rebuilt when the secret changes
+	return credentialsCache.Get(getCredentialsKey(instance), data)
+}
+
+// getBasicAuthPassword returns the password of the basic authentication of the check of the instance. The secret is
+// read from the namespace of the instance, other namespaces can't be referenced.
+func (r *EndpointMonitorReconciler) getBasicAuthPassword(instance *endpointmonitorv1alpha1.EndpointMonitor) (string, error) {
+	check := instance.Spec.Check
+	if check == nil || check.BasicAuth == nil {
+		return "", nil
+	}
+	ref := check.BasicAuth.PasswordSecretRef
+	password, err := secret.LoadSecretData(r.APIReader, ref.Name, instance.Namespace, ref.Key)
+	if err != nil {
+		return "", fmt.Errorf("unable to load basic auth password secret %s: %w", ref.Name, err)
+	}
+	return password, nil
+}
+
+// getCredentialsKey returns the namespaced name of the credentials secret of the instance
+func getCredentialsKey(instance *endpointmonitorv1alpha1.EndpointMonitor) types.NamespacedName {
+	return types.NamespacedName{Namespace: instance.Namespace, Name: instance.Spec.CredentialsRef.Name}
+}
+
+// enqueueEndpointMonitors re-enqueues all instances so that they are synced with the current configuration,
+// e.g. to register them with a new provider
+func enqueueEndpointMonitors(ctx context.Context, c client.Client, events chan<- event.GenericEvent, log logr.Logger) {
+	instances := &endpointmonitorv1alpha1.EndpointMonitorList{}
+	if err := c.List(ctx, instances); err != nil {
+		log.Error(err, "Failed to list EndpointMonitors to sync them with the configuration")
+		return
+	}
+	for index := range instances.Items {
+		select {
+		case events <- event.GenericEvent{Object: &instances.Items[index]}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// getCheck returns the check of the monitor of a URL. tcp URLs, e.g. of a serviceRef, default to a TCP check.
+func getCheck(check *endpointmonitorv1alpha1.CheckConfig, url string) *endpointmonitorv1alpha1.CheckConfig {
+	if !strings.HasPrefix(url, endpointmonitorv1alpha1.ServiceSchemeTCP+"://") || (check != nil && len(check.Type) != 0) {
+		return check
+	}
+	tcpCheck := &endpointmonitorv1alpha1.CheckConfig{}
+	if check != nil {
+		tcpCheck = check.DeepCopy()
+	}
+	tcpCheck.Type = endpointmonitorv1alpha1.CheckTypeTCP
+	return tcpCheck
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
+)
+
+// lookupMonitorService looks up the monitors of a provider by name and ID, or fails every lookup with lookupErr
+type lookupMonitorService struct {
+	fakeMonitorService
+	lookupErr error
+	added     []string
+	updated   []models.Monitor
+}
+
+func (s *lookupMonitorService) GetByName(name string) (*models.Monitor, error) {
+	if s.lookupErr != nil {
+		return nil, s.lookupErr
+	}
+	for index := range s.monitors {
+		if s.monitors[index].Name == name {
+			return &s.monitors[index], nil
+		}
+	}
+	return nil, monitorerrors.NewNotFound("Unable to locate monitor with name "+name, nil)
+}
+
+func (s *lookupMonitorService) GetByID(id string) (*models.Monitor, error) {
+	if s.lookupErr != nil {
+		return nil, s.lookupErr
+	}
+	for index := range s.monitors {
+		if s.monitors[index].ID == id {
+			return &s.monitors[index], nil
+		}
+	}
+	return nil, monitorerrors.NewNotFound("Unable to locate monitor with ID "+id, nil)
+}
+
+func (s *lookupMonitorService) Add(m models.Monitor) error {
+	s.added = append(s.added, m.Name)
+	m.ID = "new"
+	s.monitors = append(s.monitors, m)
+	return nil
+}
+
+func (s *lookupMonitorService) Update(m models.Monitor) error {
+	s.updated = append(s.updated, m)
+	return nil
+}
+
+func (s *lookupMonitorService) Equal(oldMonitor models.Monitor, newMonitor models.Monitor) bool {
+	return oldMonitor.URL == newMonitor.URL
+}
+
+func newTestReconciler(t *testing.T, monitorService monitors.MonitorService, objects ...client.Object) *EndpointMonitorReconciler {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := endpointmonitorv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	registry := &monitors.MonitorServiceRegistry{}
+	registry.Set([]monitors.MonitorServiceProxy{monitors.NewMonitorServiceProxy("UptimeRobot", "UptimeRobot", monitorService)})
+	kubeClient := fakekubeclient.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	return &EndpointMonitorReconciler{
+		Client:          kubeClient,
+		APIReader:       kubeClient,
+		Log:             logr.Discard(),
+		MonitorServices: registry,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+}
+
+func TestReconcileDoesNotCreateMonitorIfLookupFails(t *testing.T) {
+	config.SetControllerConfig(config.Config{MonitorNameTemplate: "{{.Name}}-{{.Namespace}}"})
+	defer config.SetControllerConfig(config.Config{})
+
+	monitorService := &lookupMonitorService{lookupErr: monitorerrors.FromStatusCode(503, "GetByName Request failed")}
+	instance := newTestEndpointMonitor("shop", "web")
+	reconciler := newTestReconciler(t, monitorService, instance)
+
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "shop", Namespace: "web"}}
+	if _, err := reconciler.Reconcile(context.TODO(), request); err == nil {
+		t.Error("Expected the failed lookup to be retried")
+	}
+	if len(monitorService.added) != 0 {
+		t.Errorf("Expected no monitor to be created while the provider is unavailable, got %v", monitorService.added)
+	}
+
+	// A missing monitor is created
+	monitorService.lookupErr = nil
+	if _, err := reconciler.Reconcile(context.TODO(), request); err != nil {
+		t.Fatal(err)
+	}
+	if len(monitorService.added) != 1 || monitorService.added[0] != "shop-web" {
+		t.Errorf("Expected the missing monitor to be created, got %v", monitorService.added)
+	}
+}
+
+func TestReconcileFindsMonitorByRecordedID(t *testing.T) {
+	config.SetControllerConfig(config.Config{MonitorNameTemplate: "{{.Name}}-{{.Namespace}}"})
+	defer config.SetControllerConfig(config.Config{})
+
+	// The monitor has been created with a previous name template
+	monitorService := &lookupMonitorService{fakeMonitorService: fakeMonitorService{monitors: []models.Monitor{
+		{Name: "web-shop", ID: "42", URL: "https://old.example.com"},
+	}}}
+	instance := newTestEndpointMonitor("shop", "web")
+	instance.Status.Monitors = []endpointmonitorv1alpha1.MonitorStatus{{Provider: "UptimeRobot", Name: "web-shop", ID: "42"}}
+	reconciler := newTestReconciler(t, monitorService, instance)
+
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "shop", Namespace: "web"}}
+	if _, err := reconciler.Reconcile(context.TODO(), request); err != nil {
+		t.Fatal(err)
+	}
+	if len(monitorService.added) != 0 {
+		t.Errorf("Expected the recorded monitor to be reused, got new monitors %v", monitorService.added)
+	}
+	if len(monitorService.updated) != 1 || monitorService.updated[0].ID != "42" {
+		t.Errorf("Expected the recorded monitor to be updated, got %v", monitorService.updated)
+	}
+}
+
+func TestReconcileSyncsOtherMonitorsDuringCreationDelay(t *testing.T) {
+	config.SetControllerConfig(config.Config{MonitorNameTemplate: "{{.Name}}-{{.Namespace}}", CreationDelay: time.Minute})
+	defer config.SetControllerConfig(config.Config{})
+
+	pending := &lookupMonitorService{}
+	existing := &lookupMonitorService{fakeMonitorService: fakeMonitorService{monitors: []models.Monitor{
+		{Name: "shop-web", ID: "42", URL: "https://old.example.com"},
+	}}}
+	instance := newTestEndpointMonitor("shop", "web")
+	instance.CreationTimestamp = metav1.Now()
+	reconciler := newTestReconciler(t, pending, instance)
+	reconciler.MonitorServices.Set([]monitors.MonitorServiceProxy{
+		monitors.NewMonitorServiceProxy("Pingdom", "Pingdom", pending),
+		monitors.NewMonitorServiceProxy("UptimeRobot", "UptimeRobot", existing),
+	})
+
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "shop", Namespace: "web"}}
+	result, err := reconciler.Reconcile(context.TODO(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RequeueAfter <= 0 || result.RequeueAfter > time.Minute {
+		t.Errorf("Expected the request to be requeued after the creation delay, got %v", result.RequeueAfter)
+	}
+	if len(pending.added) != 0 {
+		t.Errorf("Expected the creation of the monitor to be delayed, got %v", pending.added)
+	}
+	if len(existing.updated) != 1 {
+		t.Errorf("Expected the existing monitor to be updated during the creation delay, got %v", existing.updated)
+	}
+
+	updated := &endpointmonitorv1alpha1.EndpointMonitor{}
+	if err := reconciler.Get(context.TODO(), request.NamespacedName, updated); err != nil {
+		t.Fatal(err)
+	}
+	if status := updated.Status.GetMonitorStatus("UptimeRobot", ""); status == nil || status.ID != "42" {
+		t.Errorf("Expected the status of the synced monitor to be recorded, got %+v", status)
+	}
+	if status := updated.Status.GetMonitorStatus("Pingdom", ""); status != nil {
+		t.Errorf("Expected no status for the delayed monitor, got %+v", status)
+	}
+}
+
+func TestReconcileReadsBasicAuthPasswordFromInstanceNamespace(t *testing.T) {
+	config.SetControllerConfig(config.Config{MonitorNameTemplate: "{{.Name}}-{{.Namespace}}"})
+	defer config.SetControllerConfig(config.Config{})
+
+	monitorService := &lookupMonitorService{}
+	instance := newTestEndpointMonitor("shop", "web")
+	instance.Spec.Check = &endpointmonitorv1alpha1.CheckConfig{BasicAuth: &endpointmonitorv1alpha1.BasicAuth{
+		Username: "health",
+		PasswordSecretRef: corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "health"},
+			Key:                  "password",
+		},
+	}}
+	// Secrets of other namespaces can't be referenced
+	otherSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "health", Namespace: "other"},
+		Data:       map[string][]byte{"password": []byte("other")},
+	}
+	reconciler := newTestReconciler(t, monitorService, instance, otherSecret)
+
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "shop", Namespace: "web"}}
+	if _, err := reconciler.Reconcile(context.TODO(), request); err == nil {
+		t.Error("Expected the missing password secret to be retried")
+	}
+	if len(monitorService.added) != 0 {
+		t.Errorf("Expected no monitor to be created without its password, got %v", monitorService.added)
+	}
+	updated := &endpointmonitorv1alpha1.EndpointMonitor{}
+	if err := reconciler.Get(context.TODO(), request.NamespacedName, updated); err != nil {
+		t.Fatal(err)
+	}
+	ready := meta.FindStatusCondition(updated.Status.Conditions, endpointmonitorv1alpha1.ConditionTypeReady)
+	if ready == nil || ready.Reason != ReasonCredentialsUnavailable {
+		t.Errorf("Expected the Ready condition to report the missing secret, got %v", ready)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "health", Namespace: "web"},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+	if err := reconciler.Create(context.TODO(), secret); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reconciler.Reconcile(context.TODO(), request); err != nil {
+		t.Fatal(err)
+	}
+	if len(monitorService.monitors) != 1 || monitorService.monitors[0].BasicAuthPassword != "secret" {
+		t.Errorf("Expected the monitor to be created with the password of the secret, got %v", monitorService.monitors)
+	}
+}
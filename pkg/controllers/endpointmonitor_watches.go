@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"context"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/kube"
+	"github.com/stakater/IngressMonitorController/v2/pkg/kube/wrappers"
+)
+
+// Field indexes to find the EndpointMonitors depending on an Ingress, Route, HTTPRoute, Service or credentials secret
+const (
+	ingressRefIndex     = ".spec.urlFrom.ingressRef.name"
+	routeRefIndex       = ".spec.urlFrom.routeRef.name"
+	httpRouteRefIndex   = ".spec.urlFrom.httpRouteRef.name"
+	serviceRefIndex     = ".spec.urlFrom.serviceRef.name"
+	credentialsRefIndex = ".spec.credentialsRef.name"
+	ingressServiceIndex = ".spec.rules.http.paths.backend.service.name"
+	routeServiceIndex   = ".spec.to.name"
+)
+
+// setupIndexes registers the field indexes used to map changes of the URL sources back to the EndpointMonitors
+func setupIndexes(ctx context.Context, mgr ctrl.Manager) error {
+	indexer := mgr.GetFieldIndexer()
+	err := indexer.IndexField(ctx, &endpointmonitorv1alpha1.EndpointMonitor{}, ingressRefIndex, func(obj client.Object) []string {
+		urlFrom := obj.(*endpointmonitorv1alpha1.EndpointMonitor).Spec.URLFrom
+		if urlFrom == nil || urlFrom.IngressRef == nil {
+			return nil
+		}
+		return []string{urlFrom.IngressRef.Name}
+	})
+	if err != nil {
+		return err
+	}
+	err = indexer.IndexField(ctx, &endpointmonitorv1alpha1.EndpointMonitor{}, httpRouteRefIndex, func(obj client.Object) []string {
+		urlFrom := obj.(*endpointmonitorv1alpha1.EndpointMonitor).Spec.URLFrom
+		if urlFrom == nil || urlFrom.HTTPRouteRef == nil {
+			return nil
+		}
+		return []string{urlFrom.HTTPRouteRef.Name}
+	})
+	if err != nil {
+		return err
+	}
+	err = indexer.IndexField(ctx, &endpointmonitorv1alpha1.EndpointMonitor{}, serviceRefIndex, func(obj client.Object) []string {
+		urlFrom := obj.(*endpointmonitorv1alpha1.EndpointMonitor).Spec.URLFrom
+		if urlFrom == nil || urlFrom.ServiceRef == nil {
+			return nil
+		}
+		return []string{urlFrom.ServiceRef.Name}
+	})
+	if err != nil {
+		return err
+	}
+	err = indexer.IndexField(ctx, &endpointmonitorv1alpha1.EndpointMonitor{}, credentialsRefIndex, func(obj client.Object) []string {
+		credentialsRef := obj.(*endpointmonitorv1alpha1.EndpointMonitor).Spec.CredentialsRef
+		if credentialsRef == nil {
+			return nil
+		}
+		return []string{credentialsRef.Name}
+	})
+	if err != nil {
+		return err
+	}
+	err = indexer.IndexField(ctx, &networkingv1.Ingress{}, ingressServiceIndex, func(obj client.Object) []string {
+		return getIngressServices(obj.(*networkingv1.Ingress))
+	})
+	if err != nil {
+		return err
+	}
+
+	if !kube.IsOpenshift {
+		return nil
+	}
+	err = indexer.IndexField(ctx, &endpointmonitorv1alpha1.EndpointMonitor{}, routeRefIndex, func(obj client.Object) []string {
+		urlFrom := obj.(*endpointmonitorv1alpha1.EndpointMonitor).Spec.URLFrom
+		if urlFrom == nil || urlFrom.RouteRef == nil {
+			return nil
+		}
+		return []string{urlFrom.RouteRef.Name}
+	})
+	if err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx, &routev1.Route{}, routeServiceIndex, func(obj client.Object) []string {
+		route := obj.(*routev1.Route)
+		if len(route.Spec.To.Name) == 0 {
+			return nil
+		}
+		return []string{route.Spec.To.Name}
+	})
+}
+
+// getIngressServices returns the names of all backend services of the Ingress
+func getIngressServices(ingress *networkingv1.Ingress) []string {
+	var services []string
+	if ingress.Spec.DefaultBackend != nil && ingress.Spec.DefaultBackend.Service != nil {
+		services = append(services, ingress.Spec.DefaultBackend.Service.Name)
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service != nil {
+				services = append(services, path.Backend.Service.Name)
+			}
+		}
+	}
+	return services
+}
+
+// urlAnnotations are the annotations of a URL source that change the URL derived from it
+var urlAnnotations = []string{
+	endpointmonitorv1alpha1.HealthPathAnnotation,
+	endpointmonitorv1alpha1.SchemeAnnotation,
+	endpointmonitorv1alpha1.QueryAnnotation,
+}
+
+// serviceChanged filters the updates of Services that don't change the URLs derived from them, e.g. updates of their
+// labels, of unrelated annotations or of the status of their conditions
+var serviceChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldService, ok := e.ObjectOld.(*corev1.Service)
+		if !ok {
+			return true
+		}
+		newService, ok := e.ObjectNew.(*corev1.Service)
+		if !ok {
+			return true
+		}
+		return oldService.Spec.Type != newService.Spec.Type ||
+			oldService.Spec.ClusterIP != newService.Spec.ClusterIP ||
+			!equality.Semantic.DeepEqual(oldService.Spec.Ports, newService.Spec.Ports) ||
+			!equality.Semantic.DeepEqual(oldService.Spec.Selector, newService.Spec.Selector) ||
+			!equality.Semantic.DeepEqual(oldService.Status.LoadBalancer, newService.Status.LoadBalancer) ||
+			urlAnnotationsChanged(oldService, newService)
+	},
+}
+
+// urlAnnotationsChanged returns whether any of the annotations changing the URL differs between the objects
+func urlAnnotationsChanged(oldObj client.Object, newObj client.Object) bool {
+	oldAnnotations, newAnnotations := oldObj.GetAnnotations(), newObj.GetAnnotations()
+	for _, annotation := range urlAnnotations {
+		oldValue, oldOk := oldAnnotations[annotation]
+		newValue, newOk := newAnnotations[annotation]
+		if oldOk != newOk || oldValue != newValue {
+			return true
+		}
+	}
+	return false
+}
+
+// mapIngress enqueues the EndpointMonitors referencing the Ingress
+func (r *EndpointMonitorReconciler) mapIngress(obj client.Object) []reconcile.Request {
+	return r.findEndpointMonitors(obj.GetNamespace(), ingressRefIndex, obj.GetName())
+}
+
+// mapRoute enqueues the EndpointMonitors referencing the Route
+func (r *EndpointMonitorReconciler) mapRoute(obj client.Object) []reconcile.Request {
+	return r.findEndpointMonitors(obj.GetNamespace(), routeRefIndex, obj.GetName())
+}
+
+// mapHTTPRoute enqueues the EndpointMonitors referencing the HTTPRoute
+func (r *EndpointMonitorReconciler) mapHTTPRoute(obj client.Object) []reconcile.Request {
+	return r.findEndpointMonitors(obj.GetNamespace(), httpRouteRefIndex, obj.GetName())
+}
+
+// getHTTPRouteKind returns the kind of the HTTPRoute in the first served version of the Gateway API, or false if the
+// Gateway API isn't installed
+func getHTTPRouteKind(mapper meta.RESTMapper) (schema.GroupVersionKind, bool, error) {
+	mapping, err := mapper.RESTMapping(schema.GroupKind{Group: wrappers.GatewayAPIGroup, Kind: "HTTPRoute"}, wrappers.GatewayAPIVersions...)
+	if meta.IsNoMatchError(err) {
+		return schema.GroupVersionKind{}, false, nil
+	}
+	if err != nil {
+		return schema.GroupVersionKind{}, false, err
+	}
+	return mapping.GroupVersionKind, true, nil
+}
+
+// mapService enqueues the EndpointMonitors referencing the Service, whose load balancer address may have changed,
+// and those referencing an Ingress or Route with the Service as backend, because the health endpoint is discovered
+// from its pods
+func (r *EndpointMonitorReconciler) mapService(obj client.Object) []reconcile.Request {
+	requests := r.findEndpointMonitors(obj.GetNamespace(), serviceRefIndex, obj.GetName())
+	return append(requests, r.findEndpointMonitorsForServices(obj.GetNamespace(), []string{obj.GetName()})...)
+}
+
+// mapPod enqueues the EndpointMonitors depending on the Services selecting the pod, because the health endpoint is
+// discovered from its readiness probe
+func (r *EndpointMonitorReconciler) mapPod(obj client.Object) []reconcile.Request {
+	services := &corev1.ServiceList{}
+	if err := r.List(context.Background(), services, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list services of pod", "namespace", obj.GetNamespace(), "name", obj.GetName())
+		return nil
+	}
+
+	var names []string
+	for _, service := range services.Items {
+		if len(service.Spec.Selector) != 0 && labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(obj.GetLabels())) {
+			names = append(names, service.Name)
+		}
+	}
+	return r.findEndpointMonitorsForServices(obj.GetNamespace(), names)
+}
+
+// findEndpointMonitorsForServices returns requests for the EndpointMonitors referencing an Ingress or Route with one
+// of the Services as backend
+func (r *EndpointMonitorReconciler) findEndpointMonitorsForServices(namespace string, services []string) []reconcile.Request {
+	var requests []reconcile.Request
+	for _, service := range services {
+		ingresses := &networkingv1.IngressList{}
+		if err := r.List(context.Background(), ingresses, client.InNamespace(namespace), client.MatchingFields{ingressServiceIndex: service}); err != nil {
+			r.Log.Error(err, "Failed to list ingresses of service", "namespace", namespace, "name", service)
+		}
+		for _, ingress := range ingresses.Items {
+			requests = append(requests, r.findEndpointMonitors(namespace, ingressRefIndex, ingress.Name)...)
+		}
+
+		if !kube.IsOpenshift {
+			continue
+		}
+		routes := &routev1.RouteList{}
+		if err := r.List(context.Background(), routes, client.InNamespace(namespace), client.MatchingFields{routeServiceIndex: service}); err != nil {
+			r.Log.Error(err, "Failed to list routes of service", "namespace", namespace, "name", service)
+		}
+		for _, route := range routes.Items {
+			requests = append(requests, r.findEndpointMonitors(namespace, routeRefIndex, route.Name)...)
+		}
+	}
+	return requests
+}
+
+// findEndpointMonitors returns requests for the EndpointMonitors whose indexed field has the given value
+func (r *EndpointMonitorReconciler) findEndpointMonitors(namespace string, index string, value string) []reconcile.Request {
+	instances := &endpointmonitorv1alpha1.EndpointMonitorList{}
+	if err := r.List(context.Background(), instances, client.InNamespace(namespace), client.MatchingFields{index: value}); err != nil {
+		r.Log.Error(err, "Failed to list EndpointMonitors", "namespace", namespace, index, value)
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(instances.Items))
+	for _, instance := range instances.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace},
+		})
+	}
+	return requests
+}
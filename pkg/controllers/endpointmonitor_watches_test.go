@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+)
+
+func TestServiceChanged(t *testing.T) {
+	newService := func() *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "web", Annotations: map[string]string{endpointmonitorv1alpha1.SchemeAnnotation: "https"}},
+			Spec: corev1.ServiceSpec{
+				Type:      corev1.ServiceTypeClusterIP,
+				ClusterIP: "10.0.0.1",
+				Selector:  map[string]string{"app": "shop"},
+				Ports:     []corev1.ServicePort{{Name: "http", Port: 80}},
+			},
+		}
+	}
+
+	for name, test := range map[string]struct {
+		update   func(service *corev1.Service)
+		expected bool
+	}{
+		"labels":          {func(s *corev1.Service) { s.Labels = map[string]string{"team": "web"} }, false},
+		"resourceVersion": {func(s *corev1.Service) { s.ResourceVersion = "2" }, false},
+		"conditions":      {func(s *corev1.Service) { s.Status.Conditions = []metav1.Condition{{Type: "Ready"}} }, false},
+		"ports":           {func(s *corev1.Service) { s.Spec.Ports[0].Port = 8080 }, true},
+		"type":            {func(s *corev1.Service) { s.Spec.Type = corev1.ServiceTypeLoadBalancer }, true},
+		"clusterIP":       {func(s *corev1.Service) { s.Spec.ClusterIP = "10.0.0.2" }, true},
+		"selector":        {func(s *corev1.Service) { s.Spec.Selector["app"] = "api" }, true},
+		"scheme":          {func(s *corev1.Service) { s.Annotations[endpointmonitorv1alpha1.SchemeAnnotation] = "http" }, true},
+		"health path":     {func(s *corev1.Service) { s.Annotations[endpointmonitorv1alpha1.HealthPathAnnotation] = "/healthz" }, true},
+		"query":           {func(s *corev1.Service) { s.Annotations[endpointmonitorv1alpha1.QueryAnnotation] = "probe=external" }, true},
+		"removed scheme":  {func(s *corev1.Service) { delete(s.Annotations, endpointmonitorv1alpha1.SchemeAnnotation) }, true},
+		"other annotation": {func(s *corev1.Service) {
+			s.Annotations["kubectl.kubernetes.io/last-applied-configuration"] = "{}"
+		}, false},
+		"loadBalancer": {func(s *corev1.Service) {
+			s.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "shop.example.com"}}
+		}, true},
+	} {
+		oldService, updatedService := newService(), newService()
+		test.update(updatedService)
+		if changed := serviceChanged.Update(event.UpdateEvent{ObjectOld: oldService, ObjectNew: updatedService}); changed != test.expected {
+			t.Errorf("Expected an update of the %s to return %v, got %v", name, test.expected, changed)
+		}
+	}
+
+	if !serviceChanged.Create(event.CreateEvent{Object: newService()}) || !serviceChanged.Delete(event.DeleteEvent{Object: newService()}) {
+		t.Errorf("Expected the creation and deletion of a Service to be handled")
+	}
+}
+
+func TestGetHTTPRouteKind(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	if _, ok, err := getHTTPRouteKind(mapper); err != nil || ok {
+		t.Errorf("Expected HTTPRoutes not to be watched without the Gateway API, got %v, %v", ok, err)
+	}
+
+	v1beta1 := schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "HTTPRoute"}
+	mapper.Add(v1beta1, meta.RESTScopeNamespace)
+	kind, ok, err := getHTTPRouteKind(mapper)
+	if err != nil || !ok || kind != v1beta1 {
+		t.Errorf("Expected HTTPRoutes to be watched in version v1beta1, got %v, %v, %v", kind, ok, err)
+	}
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/webhooks"
+)
+
+const (
+	ReasonEndpointMonitorGenerated = "EndpointMonitorGenerated"
+	ReasonInvalidAnnotations       = "InvalidAnnotations"
+	ReasonEndpointMonitorConflict  = "EndpointMonitorConflict"
+)
+
+// EndpointMonitorGenerator keeps the EndpointMonitor generated for an annotated Ingress or Route in sync
+// with its annotations. It is shared by the Ingress and Route controllers.
+type EndpointMonitorGenerator struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// reconcileSource creates or updates the EndpointMonitor of the source, or removes it if the source isn't annotated
+// anymore. The EndpointMonitor has the name of the source and is owned by it, so it is garbage collected with it.
+func (r *EndpointMonitorGenerator) reconcileSource(ctx context.Context, source client.Object, urlFrom endpointmonitorv1alpha1.URLSource) error {
+	log := r.Log.WithValues("namespace", source.GetNamespace(), "name", source.GetName())
+
+	if !source.GetDeletionTimestamp().IsZero() || source.GetAnnotations()[endpointmonitorv1alpha1.EnabledAnnotation] != "true" {
+		return r.removeGenerated(ctx, source)
+	}
+
+	spec, err := getGeneratedEndpointMonitorSpec(source.GetAnnotations(), urlFrom)
+	if err != nil {
+		// The annotations have to be fixed by the user, retrying won't help
+		log.Error(err, "Invalid annotations")
+		r.Recorder.Event(source, corev1.EventTypeWarning, ReasonInvalidAnnotations, err.Error())
+		return nil
+	}
+
+	instance := &endpointmonitorv1alpha1.EndpointMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: source.GetName(), Namespace: source.GetNamespace()},
+	}
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, instance, func() error {
+		if !instance.CreationTimestamp.IsZero() && !metav1.IsControlledBy(instance, source) {
+			return errNotGenerated
+		}
+		// The spec is defaulted as by the webhook, otherwise every reconcile would reset the defaulted fields
+		instance.Spec = spec
+		webhooks.DefaultSpec(&instance.Spec)
+		return controllerutil.SetControllerReference(source, instance, r.Scheme)
+	})
+	if err == errNotGenerated {
+		// EndpointMonitors written by hand are never touched
+		message := "EndpointMonitor " + instance.Name + " already exists and hasn't been generated for this resource"
+		log.Info(message)
+		r.Recorder.Event(source, corev1.EventTypeWarning, ReasonEndpointMonitorConflict, message)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if result != controllerutil.OperationResultNone {
+		log.Info("EndpointMonitor has been " + string(result))
+		r.Recorder.Event(source, corev1.EventTypeNormal, ReasonEndpointMonitorGenerated, "EndpointMonitor "+instance.Name+" has been "+string(result))
+	}
+	return nil
+}
+
+var errNotGenerated = fmt.Errorf("EndpointMonitor hasn't been generated")
+
+// removeGenerated deletes the EndpointMonitor generated for the source, if any
+func (r *EndpointMonitorGenerator) removeGenerated(ctx context.Context, source client.Object) error {
+	instance := &endpointmonitorv1alpha1.EndpointMonitor{}
+	err := r.Get(ctx, types.NamespacedName{Name: source.GetName(), Namespace: source.GetNamespace()}, instance)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(instance, source) || !instance.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	r.Log.Info("Removing generated EndpointMonitor", "namespace", instance.Namespace, "name", instance.Name)
+	err = r.Delete(ctx, instance)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// getGeneratedEndpointMonitorSpec builds the spec of the EndpointMonitor generated for a source from its annotations
+func getGeneratedEndpointMonitorSpec(annotations map[string]string, urlFrom endpointmonitorv1alpha1.URLSource) (endpointmonitorv1alpha1.EndpointMonitorSpec, error) {
+	spec := endpointmonitorv1alpha1.EndpointMonitorSpec{URLFrom: &urlFrom}
+
+	if providers, ok := annotations[endpointmonitorv1alpha1.ProvidersAnnotation]; ok {
+		for _, provider := range strings.Split(providers, ",") {
+			if provider = strings.TrimSpace(provider); len(provider) != 0 {
+				spec.Providers = append(spec.Providers, provider)
+			}
+		}
+	}
+
+	if name, ok := annotations[endpointmonitorv1alpha1.CredentialsRefAnnotation]; ok && len(name) != 0 {
+		spec.CredentialsRef = &endpointmonitorv1alpha1.CredentialsReference{Name: name}
+	}
+
+	if forceHTTPS, ok := annotations[endpointmonitorv1alpha1.ForceHTTPSAnnotation]; ok {
+		value, err := strconv.ParseBool(forceHTTPS)
+		if err != nil {
+			return spec, fmt.Errorf("invalid annotation %s: %w", endpointmonitorv1alpha1.ForceHTTPSAnnotation, err)
+		}
+		spec.ForceHTTPS = value
+	}
+
+	configs := []struct {
+		annotation string
+		config     interface{}
+	}{
+		{endpointmonitorv1alpha1.CheckAnnotation, &spec.Check},
+		{endpointmonitorv1alpha1.UptimeRobotConfigAnnotation, &spec.UptimeRobotConfig},
+		{endpointmonitorv1alpha1.UptimeConfigAnnotation, &spec.UptimeConfig},
+		{endpointmonitorv1alpha1.UpdownConfigAnnotation, &spec.UpdownConfig},
+		{endpointmonitorv1alpha1.StatusCakeConfigAnnotation, &spec.StatusCakeConfig},
+		{endpointmonitorv1alpha1.PingdomConfigAnnotation, &spec.PingdomConfig},
+		{endpointmonitorv1alpha1.AppInsightsConfigAnnotation, &spec.AppInsightsConfig},
+		{endpointmonitorv1alpha1.GCloudConfigAnnotation, &spec.GCloudConfig},
+	}
+	for _, config := range configs {
+		value, ok := annotations[config.annotation]
+		if !ok {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewBufferString(value))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(config.config); err != nil {
+			return spec, fmt.Errorf("invalid annotation %s: %w", config.annotation, err)
+		}
+	}
+	return spec, nil
+}
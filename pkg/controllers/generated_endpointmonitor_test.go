@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+)
+
+func TestReconcileSourceKeepsDefaultedSpec(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := endpointmonitorv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	ingress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{
+		Name:      "shop",
+		Namespace: "web",
+		UID:       "1",
+		Annotations: map[string]string{
+			endpointmonitorv1alpha1.EnabledAnnotation:           "true",
+			endpointmonitorv1alpha1.UptimeRobotConfigAnnotation: `{"keywordValue": "Welcome"}`,
+		},
+	}}
+	recorder := record.NewFakeRecorder(10)
+	generator := &EndpointMonitorGenerator{
+		Client:   fakekubeclient.NewClientBuilder().WithScheme(scheme).WithObjects(ingress).Build(),
+		Log:      logr.Discard(),
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+	urlFrom := endpointmonitorv1alpha1.URLSource{IngressRef: &endpointmonitorv1alpha1.IngressURLSource{Name: ingress.Name}}
+
+	if err := generator.reconcileSource(context.TODO(), ingress, urlFrom); err != nil {
+		t.Fatal(err)
+	}
+	instance := &endpointmonitorv1alpha1.EndpointMonitor{}
+	if err := generator.Get(context.TODO(), types.NamespacedName{Name: "shop", Namespace: "web"}, instance); err != nil {
+		t.Fatal(err)
+	}
+	if config := instance.Spec.UptimeRobotConfig; config == nil || config.MonitorType != "keyword" || config.KeywordExists != "yes" || config.Interval == 0 {
+		t.Errorf("Expected the generated spec to be defaulted, got %+v", config)
+	}
+
+	if err := generator.reconcileSource(context.TODO(), ingress, urlFrom); err != nil {
+		t.Fatal(err)
+	}
+	updated := &endpointmonitorv1alpha1.EndpointMonitor{}
+	if err := generator.Get(context.TODO(), types.NamespacedName{Name: "shop", Namespace: "web"}, updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.ResourceVersion != instance.ResourceVersion {
+		t.Errorf("Expected the defaulted EndpointMonitor not to be updated, got resource version %s instead of %s", updated.ResourceVersion, instance.ResourceVersion)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected a single event for the creation, got %d", len(recorder.Events))
+	}
+}
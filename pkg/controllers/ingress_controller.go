@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"context"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+)
+
+// IngressReconciler generates EndpointMonitors for Ingresses annotated with endpointmonitor.stakater.com/enabled
+type IngressReconciler struct {
+	EndpointMonitorGenerator
+}
+
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
+// Owner references blocking the deletion of the owner require the finalizers permission on OpenShift
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses/finalizers,verbs=update
+//+kubebuilder:rbac:groups=endpointmonitor.stakater.com,resources=endpointmonitors,verbs=create;delete
+
+// Reconcile creates, updates or removes the EndpointMonitor generated for an Ingress
+func (r *IngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ingress := &networkingv1.Ingress{}
+	if err := r.Get(ctx, req.NamespacedName, ingress); err != nil {
+		// The generated EndpointMonitor of a deleted Ingress is garbage collected
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	urlFrom := endpointmonitorv1alpha1.URLSource{
+		IngressRef: &endpointmonitorv1alpha1.IngressURLSource{Name: ingress.Name},
+	}
+	return reconcile.Result{}, r.reconcileSource(ctx, ingress, urlFrom)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("ingress").
+		// Only the annotations are used to generate the EndpointMonitor
+		For(&networkingv1.Ingress{}, builder.WithPredicates(predicate.AnnotationChangedPredicate{})).
+		// Changes to the generated EndpointMonitor are reverted
+		Owns(&endpointmonitorv1alpha1.EndpointMonitor{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
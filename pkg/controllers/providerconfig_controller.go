@@ -0,0 +1,396 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
+	"github.com/stakater/IngressMonitorController/v2/pkg/secret"
+)
+
+// providerConfigsRetryInterval is the interval between two attempts to load the ProviderConfigs
+const providerConfigsRetryInterval = 10 * time.Second
+
+const (
+	ReasonProviderReady       = "ProviderReady"
+	ReasonInvalidProvider     = "InvalidProvider"
+	ReasonCredentialsVerified = "CredentialsVerified"
+)
+
+// ProviderConfigReconciler builds the monitor services of the ProviderConfigs and verifies their credentials
+type ProviderConfigReconciler struct {
+	client.Client
+	Log logr.Logger
+	// APIReader reads the credentials secrets without caching all secrets of the cluster
+	APIReader       client.Reader
+	MonitorServices *monitors.MonitorServiceRegistry
+	// ConfigEvents re-enqueues all EndpointMonitors when a provider changes
+	ConfigEvents chan<- event.GenericEvent
+
+	mutex       sync.Mutex
+	loaded      bool
+	providers   map[string]config.Provider
+	services    map[string]monitors.MonitorServiceProxy
+	unavailable map[string]bool
+}
+
+//+kubebuilder:rbac:groups=endpointmonitor.stakater.com,resources=providerconfigs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=endpointmonitor.stakater.com,resources=providerconfigs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+
+// LoadProviderConfigs builds the monitor services of all ProviderConfigs before the manager is started,
+// so that EndpointMonitors are never reconciled without them. All providers are marked as unavailable if the
+// ProviderConfigs can't be listed, loading them is retried once the manager is started, see Start.
+func (r *ProviderConfigReconciler) LoadProviderConfigs(ctx context.Context) error {
+	providerConfigs := &endpointmonitorv1alpha1.ProviderConfigList{}
+	if err := r.APIReader.List(ctx, providerConfigs); err != nil {
+		r.MonitorServices.SetProviderConfigsUnavailable()
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for index := range providerConfigs.Items {
+		providerConfig := &providerConfigs.Items[index]
+		if _, _, err := r.setProvider(ctx, providerConfig); err != nil {
+			r.Log.Error(err, "Invalid ProviderConfig "+providerConfig.Name)
+		}
+	}
+	r.loaded = true
+	r.publish()
+	return nil
+}
+
+// Start retries loading the ProviderConfigs until it succeeds if they couldn't be loaded before the manager was
+// started. It runs under the manager and only on the leader, like the controllers.
+func (r *ProviderConfigReconciler) Start(ctx context.Context) error {
+	for !r.isLoaded() {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(providerConfigsRetryInterval):
+		}
+
+		if err := r.LoadProviderConfigs(ctx); err != nil {
+			r.Log.Error(err, "Unable to load ProviderConfigs, all providers are unavailable")
+			continue
+		}
+		r.Log.Info("ProviderConfigs have been loaded")
+		// Instances are synced with the providers that were unavailable
+		enqueueEndpointMonitors(ctx, r.Client, r.ConfigEvents, r.Log)
+	}
+	return nil
+}
+
+func (r *ProviderConfigReconciler) isLoaded() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.loaded
+}
+
+// Reconcile rebuilds the monitor service of a ProviderConfig when it changes and verifies its credentials
+func (r *ProviderConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("providerconfig", req.Name)
+
+	instance := &endpointmonitorv1alpha1.ProviderConfig{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("ProviderConfig has been deleted, removing provider")
+			r.removeProvider(ctx, req.Name)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	r.mutex.Lock()
+	service, changed, err := r.setProvider(ctx, instance)
+	r.publish()
+	r.mutex.Unlock()
+
+	if changed {
+		// Instances are registered with new providers and updated with new credentials
+		go enqueueEndpointMonitors(ctx, r.Client, r.ConfigEvents, log)
+	}
+
+	if err != nil {
+		log.Error(err, "Invalid ProviderConfig")
+		setProviderConfigCondition(instance, endpointmonitorv1alpha1.ConditionTypeReady, metav1.ConditionFalse, ReasonInvalidProvider, err.Error())
+		meta.RemoveStatusCondition(&instance.Status.Conditions, endpointmonitorv1alpha1.ConditionTypeCredentialsValid)
+	} else {
+		setProviderConfigCondition(instance, endpointmonitorv1alpha1.ConditionTypeReady, metav1.ConditionTrue, ReasonProviderReady, "Provider is ready")
+		if err := service.VerifyCredentials(); err != nil {
+			log.Error(err, "Failed to verify credentials")
+			setProviderConfigCondition(instance, endpointmonitorv1alpha1.ConditionTypeCredentialsValid, metav1.ConditionFalse,
+				string(monitorerrors.ReasonForError(err)), err.Error())
+		} else {
+			setProviderConfigCondition(instance, endpointmonitorv1alpha1.ConditionTypeCredentialsValid, metav1.ConditionTrue,
+				ReasonCredentialsVerified, "Credentials have been accepted by the provider")
+		}
+	}
+
+	instance.Status.ObservedGeneration = instance.Generation
+	if err := r.Status().Update(ctx, instance); err != nil {
+		log.Error(err, "Failed to update status")
+		return reconcile.Result{}, err
+	}
+
+	// Credentials are verified periodically and changes of the credentials secrets are picked up
+	return reconcile.Result{RequeueAfter: config.ReconciliationRequeueTime}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ProviderConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&endpointmonitorv1alpha1.ProviderConfig{}).
+		Complete(r)
+}
+
+// setProvider builds the monitor service of the ProviderConfig. The service is only rebuilt if the provider has
+// changed. The provider is marked as unavailable if it is invalid. Callers must hold the mutex.
+func (r *ProviderConfigReconciler) setProvider(ctx context.Context, providerConfig *endpointmonitorv1alpha1.ProviderConfig) (monitors.MonitorServiceProxy, bool, error) {
+	if r.services == nil {
+		r.providers = make(map[string]config.Provider)
+		r.services = make(map[string]monitors.MonitorServiceProxy)
+		r.unavailable = make(map[string]bool)
+	}
+	name := providerConfig.Name
+
+	provider, err := r.getProvider(ctx, providerConfig)
+	if err == nil {
+		if service, ok := r.services[name]; ok && reflect.DeepEqual(r.providers[name], provider) {
+			return service, false, nil
+		}
+
+		var service monitors.MonitorServiceProxy
+		service, err = monitors.CreateMonitorService(&provider)
+		if err == nil {
+			r.closeService(name)
+			r.providers[name] = provider
+			r.services[name] = service
+			delete(r.unavailable, name)
+			return service, true, nil
+		}
+	}
+
+	_, existed := r.services[name]
+	r.closeService(name)
+	delete(r.providers, name)
+	delete(r.services, name)
+	changed := existed || !r.unavailable[name]
+	r.unavailable[name] = true
+	return monitors.MonitorServiceProxy{}, changed, err
+}
+
+// removeProvider removes the monitor service of a deleted ProviderConfig
+func (r *ProviderConfigReconciler) removeProvider(ctx context.Context, name string) {
+	r.mutex.Lock()
+	_, existed := r.services[name]
+	r.closeService(name)
+	delete(r.providers, name)
+	delete(r.services, name)
+	delete(r.unavailable, name)
+	r.publish()
+	r.mutex.Unlock()
+
+	if existed {
+		go enqueueEndpointMonitors(ctx, r.Client, r.ConfigEvents, r.Log)
+	}
+}
+
+// closeService closes the monitor service of a ProviderConfig that is replaced or removed. Callers must hold the mutex.
+func (r *ProviderConfigReconciler) closeService(name string) {
+	if service, ok := r.services[name]; ok {
+		monitors.CloseMonitorServices([]monitors.MonitorServiceProxy{service})
+	}
+}
+
+// publish hands the monitor services over to the registry. Callers must hold the mutex.
+func (r *ProviderConfigReconciler) publish() {
+	if !r.loaded {
+		// All providers stay unavailable until all ProviderConfigs are known
+		return
+	}
+	names := make([]string, 0, len(r.services))
+	for name := range r.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	services := make([]monitors.MonitorServiceProxy, 0, len(names))
+	for _, name := range names {
+		services = append(services, r.services[name])
+	}
+
+	unavailable := make([]string, 0, len(r.unavailable))
+	for name := range r.unavailable {
+		unavailable = append(unavailable, name)
+	}
+	r.MonitorServices.SetProviderConfigServices(services, unavailable)
+}
+
+// getHTTPConfig converts the HTTP configuration of a ProviderConfig, the certificates and the proxy secret are loaded
+// by the caller
+func getHTTPConfig(httpConfig *endpointmonitorv1alpha1.HTTPClientConfig) config.HTTP {
+	result := config.HTTP{
+		RequestsPerMinute: int(httpConfig.RequestsPerMinute),
+		Burst:             int(httpConfig.Burst),
+		Proxy:             httpConfig.Proxy,
+		NoProxy:           httpConfig.NoProxy,
+	}
+	if httpConfig.Timeout != nil {
+		result.Timeout = httpConfig.Timeout.Duration
+	}
+	if httpConfig.MaxRetries != nil {
+		maxRetries := int(*httpConfig.MaxRetries)
+		result.MaxRetries = &maxRetries
+	}
+	for _, bundle := range httpConfig.CABundles {
+		var caBundle config.CABundle
+		if ref := bundle.SecretKeyRef; ref != nil {
+			caBundle.SecretRef = &config.KeyReference{Name: ref.Name, Namespace: ref.Namespace, Key: ref.Key}
+		}
+		if ref := bundle.ConfigMapKeyRef; ref != nil {
+			caBundle.ConfigMapRef = &config.KeyReference{Name: ref.Name, Namespace: ref.Namespace, Key: ref.Key}
+		}
+		result.CABundles = append(result.CABundles, caBundle)
+	}
+	if ref := httpConfig.ClientCertificateSecretRef; ref != nil {
+		result.ClientCertificate = &config.ClientCertificate{SecretName: ref.Name, Namespace: ref.Namespace}
+	}
+	return result
+}
+
+// getProvider converts the ProviderConfig into the provider configuration used by the monitor services
+func (r *ProviderConfigReconciler) getProvider(ctx context.Context, providerConfig *endpointmonitorv1alpha1.ProviderConfig) (config.Provider, error) {
+	spec := providerConfig.Spec
+	provider := config.Provider{Name: providerConfig.Name, Type: spec.Type}
+	if spec.HTTP != nil {
+		provider.HTTP = getHTTPConfig(spec.HTTP)
+		if spec.HTTP.ProxySecretRef != nil {
+			proxy, err := r.getSecretValue(*spec.HTTP.ProxySecretRef)
+			if err != nil {
+				return provider, fmt.Errorf("unable to load proxy: %w", err)
+			}
+			provider.HTTP.Proxy = proxy
+		}
+		if err := provider.HTTP.Load(r.APIReader); err != nil {
+			return provider, err
+		}
+	}
+	// Changes of the HTTP configuration of the controller are picked up when the provider is reconciled next
+	provider.HTTP = provider.HTTP.WithDefaults(config.GetControllerConfig().HTTP)
+
+	var err error
+	switch spec.Type {
+	case endpointmonitorv1alpha1.ProviderTypeUptimeRobot:
+		if spec.UptimeRobot == nil {
+			return provider, fmt.Errorf("uptimeRobot configuration is missing")
+		}
+		provider.ApiKey, err = r.getSecretValue(spec.UptimeRobot.APIKeySecretRef)
+		provider.ApiURL = spec.UptimeRobot.APIURL
+		provider.AlertContacts = spec.UptimeRobot.AlertContacts
+	case endpointmonitorv1alpha1.ProviderTypePingdom:
+		if spec.Pingdom == nil {
+			return provider, fmt.Errorf("pingdom configuration is missing")
+		}
+		provider.ApiToken, err = r.getSecretValue(spec.Pingdom.APITokenSecretRef)
+		provider.ApiURL = spec.Pingdom.APIURL
+		provider.AlertContacts = spec.Pingdom.AlertContacts
+		provider.AlertIntegrations = spec.Pingdom.AlertIntegrations
+		provider.TeamAlertContacts = spec.Pingdom.TeamAlertContacts
+	case endpointmonitorv1alpha1.ProviderTypeStatusCake:
+		if spec.StatusCake == nil {
+			return provider, fmt.Errorf("statusCake configuration is missing")
+		}
+		provider.ApiKey, err = r.getSecretValue(spec.StatusCake.APIKeySecretRef)
+		provider.ApiURL = spec.StatusCake.APIURL
+		provider.Username = spec.StatusCake.Username
+		provider.AlertContacts = spec.StatusCake.AlertContacts
+	case endpointmonitorv1alpha1.ProviderTypeUptime:
+		if spec.Uptime == nil {
+			return provider, fmt.Errorf("uptime configuration is missing")
+		}
+		provider.ApiKey, err = r.getSecretValue(spec.Uptime.APIKeySecretRef)
+		provider.ApiURL = spec.Uptime.APIURL
+		provider.AlertContacts = spec.Uptime.AlertContacts
+	case endpointmonitorv1alpha1.ProviderTypeUpdown:
+		if spec.Updown == nil {
+			return provider, fmt.Errorf("updown configuration is missing")
+		}
+		provider.ApiKey, err = r.getSecretValue(spec.Updown.APIKeySecretRef)
+	case endpointmonitorv1alpha1.ProviderTypeAppInsights:
+		if spec.AppInsights == nil {
+			return provider, fmt.Errorf("appInsights configuration is missing")
+		}
+		provider.AppInsightsConfig = getAppInsightsConfig(spec.AppInsights)
+	case endpointmonitorv1alpha1.ProviderTypeGCloud:
+		if spec.GCloud == nil {
+			return provider, fmt.Errorf("gcloud configuration is missing")
+		}
+		provider.ApiKey, err = r.getSecretValue(spec.GCloud.CredentialsSecretRef)
+		provider.GcloudConfig = config.Gcloud{ProjectID: spec.GCloud.ProjectID}
+	default:
+		return provider, fmt.Errorf("no such provider found: %s", spec.Type)
+	}
+	return provider, err
+}
+
+// getSecretValue returns the value of the given secret key, secrets without namespace are looked up in the
+// namespace of the operator
+func (r *ProviderConfigReconciler) getSecretValue(ref endpointmonitorv1alpha1.SecretKeyReference) (string, error) {
+	namespace := ref.Namespace
+	if len(namespace) == 0 {
+		operatorNamespace, err := config.GetOperatorNamespace()
+		if err != nil {
+			return "", err
+		}
+		namespace = operatorNamespace
+	}
+	return secret.LoadSecretData(r.APIReader, ref.Name, namespace, ref.Key)
+}
+
+func getAppInsightsConfig(spec *endpointmonitorv1alpha1.AppInsightsProviderConfig) config.AppInsights {
+	geoLocation := make([]interface{}, 0, len(spec.GeoLocation))
+	for _, location := range spec.GeoLocation {
+		geoLocation = append(geoLocation, location)
+	}
+	return config.AppInsights{
+		Name:          spec.Name,
+		Location:      spec.Location,
+		ResourceGroup: spec.ResourceGroup,
+		Frequency:     spec.Frequency,
+		GeoLocation:   geoLocation,
+		EmailAction: config.EmailAction{
+			SendToServiceOwners: spec.EmailToServiceOwners,
+			CustomEmails:        spec.CustomEmails,
+		},
+		WebhookAction: config.WebhookAction{ServiceURI: spec.WebhookServiceURI},
+	}
+}
+
+func setProviderConfigCondition(instance *endpointmonitorv1alpha1.ProviderConfig, conditionType string, status metav1.ConditionStatus, reason string, message string) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: instance.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+)
+
+func TestLoadProviderConfigsMarksProvidersUnavailableOnFailure(t *testing.T) {
+	registry := &monitors.MonitorServiceRegistry{}
+	registry.Set([]monitors.MonitorServiceProxy{monitors.NewMonitorServiceProxy("UptimeRobot", "UptimeRobot", &fakeMonitorService{})})
+
+	// ProviderConfigs can't be listed without their kind
+	reconciler := &ProviderConfigReconciler{
+		Log:             logr.Discard(),
+		APIReader:       fakekubeclient.NewClientBuilder().WithScheme(runtime.NewScheme()).Build(),
+		MonitorServices: registry,
+	}
+	if err := reconciler.LoadProviderConfigs(context.TODO()); err == nil {
+		t.Fatal("Expected listing the ProviderConfigs to fail")
+	}
+	if len(registry.Get()) != 0 || !registry.IsUnavailable("UptimeRobot") {
+		t.Error("Expected all providers to be unavailable")
+	}
+
+	// Providers stay unavailable until all ProviderConfigs are known
+	reconciler.mutex.Lock()
+	reconciler.publish()
+	reconciler.mutex.Unlock()
+	if !registry.IsUnavailable("UptimeRobot") {
+		t.Error("Expected all providers to be unavailable before the ProviderConfigs have been loaded")
+	}
+
+	scheme := runtime.NewScheme()
+	if err := endpointmonitorv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	reconciler.APIReader = fakekubeclient.NewClientBuilder().WithScheme(scheme).Build()
+	if err := reconciler.LoadProviderConfigs(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if !reconciler.isLoaded() || len(registry.Get()) != 1 || registry.IsUnavailable("UptimeRobot") {
+		t.Error("Expected the providers to be available once the ProviderConfigs have been loaded")
+	}
+}
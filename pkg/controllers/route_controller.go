@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"context"
+
+	routev1 "github.com/openshift/api/route/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+)
+
+// RouteReconciler generates EndpointMonitors for OpenShift Routes annotated with endpointmonitor.stakater.com/enabled
+type RouteReconciler struct {
+	EndpointMonitorGenerator
+}
+
+//+kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;watch
+// Owner references blocking the deletion of the owner require the finalizers permission on OpenShift
+//+kubebuilder:rbac:groups=route.openshift.io,resources=routes/finalizers,verbs=update
+//+kubebuilder:rbac:groups=endpointmonitor.stakater.com,resources=endpointmonitors,verbs=create;delete
+
+// Reconcile creates, updates or removes the EndpointMonitor generated for a Route
+func (r *RouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	route := &routev1.Route{}
+	if err := r.Get(ctx, req.NamespacedName, route); err != nil {
+		// The generated EndpointMonitor of a deleted Route is garbage collected
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	urlFrom := endpointmonitorv1alpha1.URLSource{
+		RouteRef: &endpointmonitorv1alpha1.RouteURLSource{Name: route.Name},
+	}
+	return reconcile.Result{}, r.reconcileSource(ctx, route, urlFrom)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("route").
+		// Only the annotations are used to generate the EndpointMonitor
+		For(&routev1.Route{}, builder.WithPredicates(predicate.AnnotationChangedPredicate{})).
+		// Changes to the generated EndpointMonitor are reverted
+		Owns(&endpointmonitorv1alpha1.EndpointMonitor{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -2,16 +2,29 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 var log = logf.Log.WithName("http-client")
 
+// defaultClient sends the requests of clients created without a client of their provider
+var defaultClient = NewClient(config.HTTP{})
+
 type HttpClient struct {
-	url string
+	url    string
+	client *http.Client
+
+	// provider and operation label the metrics of the requests, requests aren't recorded without provider
+	provider  string
+	operation string
 }
 
 type HttpResponse struct {
@@ -20,7 +33,17 @@ type HttpResponse struct {
 }
 
 func CreateHttpClient(url string) *HttpClient {
-	client := HttpClient{url: url}
+	client := HttpClient{url: url, client: defaultClient}
+	return &client
+}
+
+// CreateProviderHttpClient creates a client whose requests are sent with the given client of a provider, see
+// NewClient, and recorded as calls of the given operation of its API. The default client is used if it is nil.
+func CreateProviderHttpClient(httpClient *http.Client, url string, provider string, operation string) *HttpClient {
+	if httpClient == nil {
+		httpClient = defaultClient
+	}
+	client := HttpClient{url: url, client: httpClient, provider: provider, operation: operation}
 	return &client
 }
 
@@ -30,28 +53,60 @@ func (client *HttpClient) addHeaders(request *http.Request, headers map[string]s
 	}
 }
 
+// markIdempotent allows the requests of read operations to be retried after server errors, whatever their method
+func (client *HttpClient) markIdempotent(request *http.Request) {
+	if isReadOperation(client.operation) {
+		// Like for net/http, the header is only used to mark the request and isn't sent
+		request.Header["Idempotency-Key"] = nil
+	}
+}
+
+// isReadOperation returns true for the operations that don't change anything in the provider
+func isReadOperation(operation string) bool {
+	switch operation {
+	case metrics.OperationList, metrics.OperationGet, metrics.OperationVerify:
+		return true
+	}
+	return strings.HasPrefix(operation, metrics.OperationList+"_") || strings.HasPrefix(operation, metrics.OperationGet+"_")
+}
+
 func (client *HttpClient) RequestWithHeaders(requestType string, body []byte, headers map[string]string) HttpResponse {
+	return client.RequestWithHeadersContext(context.Background(), requestType, body, headers)
+}
+
+// RequestWithHeadersContext sends the request until the context is cancelled
+func (client *HttpClient) RequestWithHeadersContext(ctx context.Context, requestType string, body []byte, headers map[string]string) HttpResponse {
 	reader := bytes.NewReader(body)
 
 	//   log.Info("NewRequest: METHOD: " + requestType + " URL: " + client.url + " PAYLOAD: " + string(body))
 
-	request, err := http.NewRequest(requestType, client.url, reader)
+	request, err := http.NewRequestWithContext(ctx, requestType, client.url, reader)
 	if err != nil {
 		log.Error(err, "Failed to craft HTTP Request. METHOD: "+requestType+
 			" URL: "+client.url+
 			" PAYLOAD: "+string(body))
+		return HttpResponse{}
 	}
 
 	if headers != nil {
 		client.addHeaders(request, headers)
 	}
+	client.markIdempotent(request)
 
-	response, err := http.DefaultClient.Do(request)
+	httpClient := client.client
+	if httpClient == nil {
+		httpClient = defaultClient
+	}
+
+	start := time.Now()
+	response, err := httpClient.Do(request)
 	if err != nil {
+		// Without response the status code stays 0
 		log.Error(err, "")
-	} else if response == nil {
-		log.Error(nil, "got empty response")
+		client.observeRequest(0, start)
+		return HttpResponse{}
 	}
+	client.observeRequest(response.StatusCode, start)
 
 	httpResponse := HttpResponse{StatusCode: response.StatusCode}
 
@@ -62,6 +117,13 @@ func (client *HttpClient) RequestWithHeaders(requestType string, body []byte, he
 	return httpResponse
 }
 
+// observeRequest records the request in the metrics of the provider, if any
+func (client *HttpClient) observeRequest(statusCode int, start time.Time) {
+	if len(client.provider) != 0 {
+		metrics.ObserveProviderRequest(client.provider, client.operation, statusCode, start)
+	}
+}
+
 func (client *HttpClient) DeleteUrl(requestHeaders map[string]string, body []byte) HttpResponse {
 	return client.RequestWithHeaders("DELETE", body, requestHeaders)
 }
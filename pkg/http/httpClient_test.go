@@ -2,7 +2,12 @@ package http
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
 )
 
 func TestCreateHttpClient(t *testing.T) {
@@ -66,3 +71,30 @@ func TestDeleteUrlShouldReturn405Status(t *testing.T) {
 		t.Error("Status code mismatch")
 	}
 }
+
+func TestProviderHttpClientRecordsMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	maxRetries := 0
+	client := CreateProviderHttpClient(NewClient(config.HTTP{MaxRetries: &maxRetries}), server.URL, "Test", metrics.OperationList)
+	response := client.GetUrl(make(map[string]string), []byte(""))
+	if response.StatusCode != http.StatusTooManyRequests {
+		t.Error("Status code mismatch")
+	}
+	if count := testutil.ToFloat64(metrics.ProviderRequests.WithLabelValues("Test", metrics.OperationList, "429")); count != 1 {
+		t.Errorf("Expected the request to be recorded once, got %v", count)
+	}
+
+	// Requests without response are recorded as errors
+	server.Close()
+	response = client.GetUrl(make(map[string]string), []byte(""))
+	if response.StatusCode != 0 {
+		t.Error("Status code of a request without response should be 0")
+	}
+	if count := testutil.ToFloat64(metrics.ProviderRequests.WithLabelValues("Test", metrics.OperationList, metrics.StatusError)); count != 1 {
+		t.Errorf("Expected the failed request to be recorded once, got %v", count)
+	}
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+)
+
+// getProxyFunc returns the proxy of the requests to a URL. The configured proxy and no-proxy list take precedence over
+// the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables. Requests to localhost are never proxied.
+func getProxyFunc(httpConfig config.HTTP) func(*url.URL) (*url.URL, error) {
+	proxyConfig := httpproxy.FromEnvironment()
+	if len(httpConfig.Proxy) != 0 {
+		proxyConfig.HTTPProxy = httpConfig.Proxy
+		proxyConfig.HTTPSProxy = httpConfig.Proxy
+	}
+	if len(httpConfig.NoProxy) != 0 {
+		proxyConfig.NoProxy = strings.Join(httpConfig.NoProxy, ",")
+	}
+	return proxyConfig.ProxyFunc()
+}
+
+// DialContext opens a connection to the given address through the proxy of the transport, if any. It is used by
+// clients that don't send their requests with the transport, e.g. gRPC clients, which establish TLS themselves.
+func (t *Transport) DialContext(ctx context.Context, address string) (net.Conn, error) {
+	proxyURL, err := t.proxy(&url.URL{Scheme: "https", Host: address})
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{}
+	if proxyURL == nil {
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", getProxyAddress(proxyURL))
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to proxy %s: %w", proxyURL.Host, err)
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if t.tlsConfig != nil {
+			tlsConfig = t.tlsConfig.Clone()
+		}
+		tlsConfig.ServerName = proxyURL.Hostname()
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("unable to connect to proxy %s: %w", proxyURL.Host, err)
+		}
+		conn = tlsConn
+	}
+	tunnel, err := connect(ctx, conn, proxyURL, address)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tunnel, nil
+}
+
+// connect opens a tunnel to the given address with a CONNECT request to the proxy
+func connect(ctx context.Context, conn net.Conn, proxyURL *url.URL, address string) (net.Conn, error) {
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		request.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	if err := request.Write(conn); err != nil {
+		return nil, fmt.Errorf("unable to connect to %s through proxy %s: %w", address, proxyURL.Host, err)
+	}
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to %s through proxy %s: %w", address, proxyURL.Host, err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("proxy %s refused to connect to %s: %s", proxyURL.Host, address, response.Status)
+	}
+	if reader.Buffered() != 0 {
+		// Data sent by the server right after the response must not be lost
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn reads the data buffered while reading the response to the CONNECT request before the connection
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(data []byte) (int, error) {
+	return c.reader.Read(data)
+}
+
+// getProxyAddress returns the host and port of the proxy, the port defaults to the one of its scheme
+func getProxyAddress(proxyURL *url.URL) string {
+	if len(proxyURL.Port()) != 0 {
+		return proxyURL.Host
+	}
+	port := "80"
+	if proxyURL.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
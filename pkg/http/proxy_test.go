@@ -0,0 +1,163 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+)
+
+// generateCertificate returns a PEM encoded self-signed client certificate and its key
+func generateCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ingressmonitorcontroller"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+}
+
+func TestProxyFunc(t *testing.T) {
+	proxy := getProxyFunc(config.HTTP{Proxy: "http://proxy.example.com:3128", NoProxy: []string{"internal.example.com", "10.0.0.0/8"}})
+
+	for target, expected := range map[string]string{
+		"https://api.example.com/v2/":      "http://proxy.example.com:3128",
+		"http://api.example.com/v2/":       "http://proxy.example.com:3128",
+		"https://internal.example.com/":    "",
+		"https://api.internal.example.com": "",
+		"https://10.1.2.3/":                "",
+		"https://127.0.0.1/":               "",
+	} {
+		targetURL, _ := url.Parse(target)
+		proxyURL, err := proxy(targetURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (proxyURL == nil && len(expected) != 0) || (proxyURL != nil && proxyURL.String() != expected) {
+			t.Errorf("Expected proxy %q for %s, got %v", expected, target, proxyURL)
+		}
+	}
+}
+
+func TestTransportSendsRequestsThroughProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credentials := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+		if r.Header.Get("Proxy-Authorization") != "Basic "+credentials {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		if r.URL.Host != "api.example.com" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("user", "secret")
+	client := CreateProviderHttpClient(NewClient(config.HTTP{Proxy: proxyURL.String()}), "http://api.example.com/v2/", "Test", "get")
+	if response := client.GetUrl(nil, nil); response.StatusCode != http.StatusOK {
+		t.Errorf("Expected the request to be sent through the proxy, got %v", response.StatusCode)
+	}
+}
+
+func TestTransportDialContextThroughProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect || r.Host != "api.example.com:443" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\ntunnel"))
+	}))
+	defer proxy.Close()
+
+	transport := NewTransport(config.HTTP{Proxy: proxy.URL})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := transport.DialContext(ctx, "api.example.com:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	data, _ := ioutil.ReadAll(conn)
+	if string(data) != "tunnel" {
+		t.Errorf("Expected the connection to be tunnelled, got %q", data)
+	}
+
+	if _, err := transport.DialContext(ctx, "other.example.com:443"); err == nil {
+		t.Error("Expected the refused tunnel to fail")
+	}
+}
+
+func TestTransportTrustsCABundlesAndPresentsClientCertificate(t *testing.T) {
+	clientCertificate, clientKey := generateCertificate(t)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) != 1 || r.TLS.PeerCertificates[0].Subject.CommonName != "ingressmonitorcontroller" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	maxRetries := 0
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	// The certificate of the server is unknown without its CA bundle
+	client := CreateHttpClient(server.URL)
+	client.client = NewClient(config.HTTP{MaxRetries: &maxRetries})
+	if response := client.GetUrl(nil, nil); response.StatusCode != 0 {
+		t.Errorf("Expected the certificate of the server to be rejected, got %v", response.StatusCode)
+	}
+
+	client.client = NewClient(config.HTTP{MaxRetries: &maxRetries, Certificates: config.Certificates{CAs: serverCA}})
+	if response := client.GetUrl(nil, nil); response.StatusCode != 0 {
+		t.Errorf("Expected the request without client certificate to fail, got %v", response.StatusCode)
+	}
+
+	client.client = NewClient(config.HTTP{MaxRetries: &maxRetries, Certificates: config.Certificates{
+		CAs:               serverCA,
+		ClientCertificate: clientCertificate,
+		ClientKey:         clientKey,
+	}})
+	if response := client.GetUrl(nil, nil); response.StatusCode != http.StatusOK {
+		t.Errorf("Expected the request with client certificate to succeed, got %v", response.StatusCode)
+	}
+}
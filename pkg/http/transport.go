@@ -0,0 +1,271 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+)
+
+// The delay before the first retry of a request, it doubles with every retry up to maxBackoff
+var (
+	baseBackoff = time.Second
+	maxBackoff  = 30 * time.Second
+)
+
+// maxRetryAfter is the longest Retry-After a request is retried after. Requests asked to wait longer fail, so that
+// the reconcile is retried later instead of blocking a worker.
+const maxRetryAfter = time.Minute
+
+// Transport sends the requests to the API of a provider. It limits the rate of the requests, applies a timeout to
+// every attempt and retries requests that have been rate limited or failed with a server error with exponential
+// backoff, honouring the Retry-After header. Requests are cancelled with their context. The requests are sent
+// through the configured proxy and trust the configured certificate authorities.
+type Transport struct {
+	base       http.RoundTripper
+	limiter    *rate.Limiter
+	timeout    time.Duration
+	maxRetries int
+	proxy      func(*url.URL) (*url.URL, error)
+	tlsConfig  *tls.Config
+}
+
+// NewTransport creates a transport with the given configuration. The rate limit is shared by all requests sent with
+// the transport, a single transport should be used per provider instance. The certificates of the configuration
+// must have been loaded, see config.HTTP.Load.
+func NewTransport(httpConfig config.HTTP) *Transport {
+	transport := &Transport{
+		timeout:    httpConfig.GetTimeout(),
+		maxRetries: httpConfig.GetMaxRetries(),
+		proxy:      getProxyFunc(httpConfig),
+		tlsConfig:  getTLSConfig(httpConfig.Certificates),
+	}
+	if httpConfig.RequestsPerMinute > 0 {
+		transport.limiter = rate.NewLimiter(rate.Limit(float64(httpConfig.RequestsPerMinute)/60), httpConfig.GetBurst())
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = func(request *http.Request) (*url.URL, error) {
+		return transport.proxy(request.URL)
+	}
+	base.TLSClientConfig = transport.tlsConfig
+	transport.base = base
+	return transport
+}
+
+// NewClient creates a client sending its requests with a new Transport of the given configuration
+func NewClient(httpConfig config.HTTP) *http.Client {
+	return &http.Client{Transport: NewTransport(httpConfig)}
+}
+
+// Wait blocks until a request may be sent within the rate limit, or fails if the context is done first
+func (t *Transport) Wait(ctx context.Context) error {
+	if t.limiter == nil {
+		return nil
+	}
+	return t.limiter.Wait(ctx)
+}
+
+// Timeout returns the timeout of a single attempt of a request
+func (t *Transport) Timeout() time.Duration {
+	return t.timeout
+}
+
+// TLSConfig returns the TLS configuration of the connections, nil if the defaults are used
+func (t *Transport) TLSConfig() *tls.Config {
+	return t.tlsConfig
+}
+
+// getTLSConfig returns the TLS configuration trusting the given certificate authorities in addition to the system
+// ones and presenting the client certificate, nil if there are none
+func getTLSConfig(certificates config.Certificates) *tls.Config {
+	if len(certificates.CAs) == 0 && len(certificates.ClientCertificate) == 0 {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(certificates.CAs) != 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			log.Error(err, "Failed to load system certificate authorities, only trusting the configured ones")
+			pool = x509.NewCertPool()
+		}
+		pool.AppendCertsFromPEM(certificates.CAs)
+		tlsConfig.RootCAs = pool
+	}
+	if len(certificates.ClientCertificate) != 0 {
+		// The certificate has been validated when it was loaded
+		certificate, err := tls.X509KeyPair(certificates.ClientCertificate, certificates.ClientKey)
+		if err != nil {
+			log.Error(err, "Invalid client certificate, connecting without it")
+		} else {
+			tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+	}
+	return tlsConfig
+}
+
+// RoundTrip sends the request until it succeeds, fails with an error that can't be retried or runs out of retries
+func (t *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+	for attempt := 0; ; attempt++ {
+		if err := t.Wait(ctx); err != nil {
+			closeRequestBody(request)
+			return nil, err
+		}
+
+		attemptRequest, err := getAttemptRequest(request, attempt)
+		if err != nil {
+			return nil, err
+		}
+		response, err := t.send(attemptRequest)
+
+		delay, retry := t.getRetryDelay(request, response, err, attempt)
+		if !retry {
+			return response, err
+		}
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = response.Status
+			// The connection is only reused if the body has been read
+			_, _ = io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+		log.Info(fmt.Sprintf("Retrying request %s %s%s in %v: %s", request.Method, request.URL.Host, request.URL.Path, delay, reason))
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// send sends a single attempt of the request with the timeout of the transport
+func (t *Transport) send(request *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(request.Context(), t.timeout)
+	response, err := t.base.RoundTrip(request.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The timeout covers reading the body, it is released once the body is closed
+	response.Body = &cancelOnClose{ReadCloser: response.Body, cancel: cancel}
+	return response, nil
+}
+
+// getRetryDelay returns the delay before the next attempt of the request, or false if it must not be retried.
+// Rate limited requests are retried for every method, as they haven't been processed. Server and network errors
+// are only retried for idempotent requests.
+func (t *Transport) getRetryDelay(request *http.Request, response *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= t.maxRetries || request.Context().Err() != nil || !isReplayable(request) {
+		return 0, false
+	}
+
+	switch {
+	case err != nil:
+		if !isIdempotent(request) {
+			return 0, false
+		}
+	case response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusServiceUnavailable:
+		if retryAfter, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+			return retryAfter, retryAfter <= maxRetryAfter
+		}
+	case response.StatusCode >= http.StatusInternalServerError:
+		if !isIdempotent(request) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return getBackoff(attempt), true
+}
+
+// getBackoff returns the exponential backoff of the given attempt with jitter, so that the requests of several
+// monitors don't retry in lockstep
+func getBackoff(attempt int) time.Duration {
+	backoff := maxBackoff
+	if attempt < 16 && baseBackoff<<attempt < maxBackoff {
+		backoff = baseBackoff << attempt
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// parseRetryAfter parses the Retry-After header, which holds either a number of seconds or an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// isIdempotent returns true if the request can be sent again after a network or server error. Requests are marked
+// as idempotent with an Idempotency-Key header like for net/http, e.g. POST requests that only read.
+func isIdempotent(request *http.Request) bool {
+	switch request.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, hasKey := request.Header["Idempotency-Key"]
+	_, hasXKey := request.Header["X-Idempotency-Key"]
+	return hasKey || hasXKey
+}
+
+// isReplayable returns true if the body of the request can be sent again
+func isReplayable(request *http.Request) bool {
+	return request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+}
+
+// getAttemptRequest returns the request to send for the given attempt, with a fresh body for retries
+func getAttemptRequest(request *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || request.Body == nil || request.Body == http.NoBody {
+		return request, nil
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	attemptRequest := request.Clone(request.Context())
+	attemptRequest.Body = body
+	return attemptRequest, nil
+}
+
+func closeRequestBody(request *http.Request) {
+	if request.Body != nil {
+		request.Body.Close()
+	}
+}
+
+// cancelOnClose releases the context of a request when its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,184 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
+)
+
+func init() {
+	baseBackoff = time.Millisecond
+}
+
+// newFailingServer returns a server failing the first requests with the given status
+func newFailingServer(failures int32, status int, header http.Header, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != "payload" && r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if atomic.AddInt32(requests, 1) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestTransportRetriesRateLimitedRequests(t *testing.T) {
+	var requests int32
+	server := newFailingServer(2, http.StatusTooManyRequests, http.Header{"Retry-After": {"0"}}, &requests)
+	defer server.Close()
+
+	// Rate limited requests are retried whatever their method
+	client := CreateHttpClient(server.URL)
+	response := client.PostUrl(nil, []byte("payload"))
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("Expected the request to succeed after retries, got %v", response.StatusCode)
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %v", requests)
+	}
+}
+
+func TestTransportDoesNotRetryLongRetryAfter(t *testing.T) {
+	var requests int32
+	server := newFailingServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": {"3600"}}, &requests)
+	defer server.Close()
+
+	response := CreateHttpClient(server.URL).GetUrl(nil, nil)
+	if response.StatusCode != http.StatusTooManyRequests || requests != 1 {
+		t.Errorf("Expected a single rate limited request, got %v after %v requests", response.StatusCode, requests)
+	}
+}
+
+func TestTransportRetriesServerErrorsOfIdempotentRequests(t *testing.T) {
+	var requests int32
+	server := newFailingServer(5, http.StatusInternalServerError, nil, &requests)
+	defer server.Close()
+
+	response := CreateHttpClient(server.URL).GetUrl(nil, nil)
+	if response.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected the request to fail after its retries, got %v", response.StatusCode)
+	}
+	if requests != config.DefaultHTTPMaxRetries+1 {
+		t.Errorf("Expected %v requests, got %v", config.DefaultHTTPMaxRetries+1, requests)
+	}
+
+	// Requests of read operations are retried whatever their method
+	requests = 0
+	readServer := newFailingServer(1, http.StatusInternalServerError, nil, &requests)
+	defer readServer.Close()
+	response = CreateProviderHttpClient(nil, readServer.URL, "Test", metrics.OperationList).PostUrl(nil, []byte("payload"))
+	if response.StatusCode != http.StatusOK || requests != 2 {
+		t.Errorf("Expected the read request to be retried, got %v after %v requests", response.StatusCode, requests)
+	}
+}
+
+func TestTransportDoesNotRetryServerErrorsOfOtherRequests(t *testing.T) {
+	var requests int32
+	server := newFailingServer(1, http.StatusInternalServerError, nil, &requests)
+	defer server.Close()
+
+	response := CreateProviderHttpClient(nil, server.URL, "Test", metrics.OperationCreate).PostUrl(nil, []byte("payload"))
+	if response.StatusCode != http.StatusInternalServerError || requests != 1 {
+		t.Errorf("Expected a single failed request, got %v after %v requests", response.StatusCode, requests)
+	}
+}
+
+func TestTransportTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	maxRetries := 0
+	client := NewClient(config.HTTP{Timeout: 10 * time.Millisecond, MaxRetries: &maxRetries})
+	_, err := client.Get(server.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the request to time out, got %v", err)
+	}
+}
+
+func TestTransportRateLimit(t *testing.T) {
+	var requests int32
+	server := newFailingServer(0, http.StatusOK, nil, &requests)
+	defer server.Close()
+
+	// A request is allowed every 100ms
+	client := NewClient(config.HTTP{RequestsPerMinute: 600})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		response, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected the requests to be rate limited, took %v", elapsed)
+	}
+}
+
+func TestTransportContextCancellation(t *testing.T) {
+	var requests int32
+	server := newFailingServer(5, http.StatusServiceUnavailable, http.Header{"Retry-After": {"30"}}, &requests)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	response := CreateHttpClient(server.URL).RequestWithHeadersContext(ctx, http.MethodGet, nil, nil)
+	if response.StatusCode != 0 {
+		t.Errorf("Expected the request to be cancelled, got %v", response.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the retry to be cancelled with the context, took %v", elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if delay, ok := parseRetryAfter("120"); !ok || delay != 2*time.Minute {
+		t.Errorf("Expected a delay of 2m, got %v", delay)
+	}
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if delay, ok := parseRetryAfter(date); !ok || delay < 59*time.Minute || delay > time.Hour {
+		t.Errorf("Expected a delay of about 1h, got %v", delay)
+	}
+	for _, value := range []string{"", "-1", "soon"} {
+		if _, ok := parseRetryAfter(value); ok {
+			t.Errorf("Expected %q to be invalid", value)
+		}
+	}
+}
+
+func TestIsReadOperation(t *testing.T) {
+	for operation, expected := range map[string]bool{
+		metrics.OperationGet:    true,
+		metrics.OperationList:   true,
+		metrics.OperationVerify: true,
+		"list_status_pages":     true,
+		metrics.OperationCreate: false,
+		"update_status_page":    false,
+		"getter":                false,
+	} {
+		if isReadOperation(operation) != expected {
+			t.Errorf("Expected isReadOperation(%q) to be %v", operation, expected)
+		}
+	}
+}
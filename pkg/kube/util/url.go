@@ -3,10 +3,14 @@ package util
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
+	"strings"
 
 	routev1 "github.com/openshift/api/route/v1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/kube"
 	"github.com/stakater/IngressMonitorController/v2/pkg/kube/wrappers"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -17,7 +21,37 @@ import (
 
 var log = logf.Log.WithName("config")
 
+// MonitorTarget is a URL monitored for an EndpointMonitor. The name identifies the target if the URL source resolves
+// to several of them and is empty otherwise.
+type MonitorTarget struct {
+	Name string
+	URL  string
+
+	// HealthEndpoint is how the health endpoint of the URL has been found, empty for static URLs
+	HealthEndpoint wrappers.HealthEndpoint
+}
+
+// GetMonitorTargets returns the URLs to monitor for the EndpointMonitor. An Ingress with allRules resolves to a
+// target for every host and path, all other URL sources to a single target.
+func GetMonitorTargets(client client.Client, ingressMonitor *endpointmonitorv1alpha1.EndpointMonitor) ([]MonitorTarget, error) {
+	urlFrom := ingressMonitor.Spec.URLFrom
+	if len(ingressMonitor.Spec.URL) == 0 && urlFrom != nil && urlFrom.IngressRef != nil && urlFrom.IngressRef.AllRules {
+		return discoverTargetsFromIngressRef(client, urlFrom.IngressRef, ingressMonitor.Namespace, ingressMonitor.Spec.ForceHTTPS, ingressMonitor.Spec.HealthEndpoint)
+	}
+
+	target, err := getMonitorTarget(client, ingressMonitor)
+	if err != nil {
+		return nil, err
+	}
+	return []MonitorTarget{target}, nil
+}
+
 func GetMonitorURL(client client.Client, ingressMonitor *endpointmonitorv1alpha1.EndpointMonitor) (string, error) {
+	target, err := getMonitorTarget(client, ingressMonitor)
+	return target.URL, err
+}
+
+func getMonitorTarget(client client.Client, ingressMonitor *endpointmonitorv1alpha1.EndpointMonitor) (MonitorTarget, error) {
 	if len(ingressMonitor.Spec.URL) == 0 {
 		return discoverURLFromRefs(client, ingressMonitor)
 	}
@@ -27,44 +61,164 @@ func GetMonitorURL(client client.Client, ingressMonitor *endpointmonitorv1alpha1
 	if len(ingressMonitor.Spec.HealthEndpoint) > 0 {
 		log.V(1).Info("Ignoring HealthEndpoint since url field is specified")
 	}
-	return ingressMonitor.Spec.URL, nil
+	return MonitorTarget{URL: ingressMonitor.Spec.URL}, nil
+}
+
+func discoverURLFromIngressRef(client client.Client, ingressRef *endpointmonitorv1alpha1.IngressURLSource, namespace string, forceHttps bool, healthEndpoint string) (MonitorTarget, error) {
+	ingressObject := &v1.Ingress{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: ingressRef.Name, Namespace: namespace}, ingressObject)
+	if err != nil {
+		log.V(1).Info("Ingress not found with name " + ingressRef.Name)
+		return MonitorTarget{}, err
+	}
+
+	healthEndpoint, annotated := getAnnotatedHealthEndpoint(ingressObject, healthEndpoint)
+	ingressWrapper := wrappers.NewIngressWrapper(ingressObject, client)
+	if len(ingressRef.Host) != 0 || len(ingressRef.Path) != 0 {
+		url, err := ingressWrapper.GetURLForRule(ingressRef.Host, ingressRef.Path, forceHttps, healthEndpoint)
+		if err != nil {
+			return MonitorTarget{}, err
+		}
+		return applyURLAnnotations(ingressObject, MonitorTarget{URL: url, HealthEndpoint: ingressWrapper.HealthEndpoint}, annotated, forceHttps)
+	}
+	url := ingressWrapper.GetURL(forceHttps, healthEndpoint)
+	return applyURLAnnotations(ingressObject, MonitorTarget{URL: url, HealthEndpoint: ingressWrapper.HealthEndpoint}, annotated, forceHttps)
 }
 
-func discoverURLFromIngressRef(client client.Client, ingressRef *endpointmonitorv1alpha1.IngressURLSource, namespace string, forceHttps bool, healthEndpoint string) (string, error) {
+func discoverTargetsFromIngressRef(client client.Client, ingressRef *endpointmonitorv1alpha1.IngressURLSource, namespace string, forceHttps bool, healthEndpoint string) ([]MonitorTarget, error) {
 	ingressObject := &v1.Ingress{}
 	err := client.Get(context.TODO(), types.NamespacedName{Name: ingressRef.Name, Namespace: namespace}, ingressObject)
 	if err != nil {
 		log.V(1).Info("Ingress not found with name " + ingressRef.Name)
-		return "", err
+		return nil, err
 	}
 
+	healthEndpoint, annotated := getAnnotatedHealthEndpoint(ingressObject, healthEndpoint)
 	ingressWrapper := wrappers.NewIngressWrapper(ingressObject, client)
-	return ingressWrapper.GetURL(forceHttps, healthEndpoint), nil
+	var targets []MonitorTarget
+	for _, target := range ingressWrapper.GetURLs(forceHttps, healthEndpoint) {
+		monitorTarget, err := applyURLAnnotations(ingressObject, MonitorTarget{Name: target.Host + target.Path, URL: target.URL, HealthEndpoint: target.HealthEndpoint}, annotated, forceHttps)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, monitorTarget)
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("No rules with host found in ingress: " + ingressRef.Name)
+	}
+	return targets, nil
 }
 
-func discoverURLFromRouteRef(client client.Client, routeRef *endpointmonitorv1alpha1.RouteURLSource, namespace string, forceHttps bool, healthEndpoint string) (string, error) {
+func discoverURLFromRouteRef(client client.Client, routeRef *endpointmonitorv1alpha1.RouteURLSource, namespace string, forceHttps bool, healthEndpoint string) (MonitorTarget, error) {
 	routeObject := &routev1.Route{}
 	err := client.Get(context.TODO(), types.NamespacedName{Name: routeRef.Name, Namespace: namespace}, routeObject)
 	if err != nil {
 		log.V(1).Info("Route not found with name " + routeRef.Name)
-		return "", err
+		return MonitorTarget{}, err
 	}
 
+	healthEndpoint, annotated := getAnnotatedHealthEndpoint(routeObject, healthEndpoint)
 	routeWrapper := wrappers.NewRouteWrapper(routeObject, client)
-	return routeWrapper.GetURL(forceHttps, healthEndpoint), nil
+	url := routeWrapper.GetURL(forceHttps, healthEndpoint)
+	return applyURLAnnotations(routeObject, MonitorTarget{URL: url, HealthEndpoint: routeWrapper.HealthEndpoint}, annotated, forceHttps)
 }
 
-func discoverURLFromRefs(client client.Client, ingressMonitor *endpointmonitorv1alpha1.EndpointMonitor) (string, error) {
+func discoverURLFromHTTPRouteRef(client client.Client, httpRouteRef *endpointmonitorv1alpha1.HTTPRouteURLSource, namespace string, forceHttps bool, healthEndpoint string) (MonitorTarget, error) {
+	httpRouteObject, err := wrappers.GetHTTPRoute(client, httpRouteRef.Name, namespace)
+	if err != nil {
+		log.V(1).Info("HTTPRoute not found with name " + httpRouteRef.Name)
+		return MonitorTarget{}, err
+	}
+
+	healthEndpoint, annotated := getAnnotatedHealthEndpoint(httpRouteObject, healthEndpoint)
+	httpRouteWrapper := wrappers.NewHTTPRouteWrapper(httpRouteObject, client)
+	url, err := httpRouteWrapper.GetURL(forceHttps, healthEndpoint)
+	if err != nil {
+		return MonitorTarget{}, err
+	}
+	return applyURLAnnotations(httpRouteObject, MonitorTarget{URL: url, HealthEndpoint: httpRouteWrapper.HealthEndpoint}, annotated, forceHttps)
+}
+
+func discoverURLFromServiceRef(client client.Client, serviceRef *endpointmonitorv1alpha1.ServiceURLSource, namespace string, forceHttps bool, healthEndpoint string) (MonitorTarget, error) {
+	serviceObject := &corev1.Service{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: serviceRef.Name, Namespace: namespace}, serviceObject)
+	if err != nil {
+		log.V(1).Info("Service not found with name " + serviceRef.Name)
+		return MonitorTarget{}, err
+	}
+
+	serviceWrapper := wrappers.NewServiceWrapper(serviceObject, client)
+	url, err := serviceWrapper.GetURL(serviceRef.Port, serviceRef.Scheme, forceHttps, healthEndpoint)
+	return MonitorTarget{URL: url, HealthEndpoint: serviceWrapper.HealthEndpoint}, err
+}
+
+// getAnnotatedHealthEndpoint returns the health endpoint to use for the URL source. The healthEndpoint of the
+// EndpointMonitor takes precedence over the health path annotation of the source, which is reported as true.
+func getAnnotatedHealthEndpoint(source client.Object, healthEndpoint string) (string, bool) {
+	if len(healthEndpoint) != 0 {
+		return healthEndpoint, false
+	}
+	healthPath := source.GetAnnotations()[endpointmonitorv1alpha1.HealthPathAnnotation]
+	return healthPath, len(healthPath) != 0
+}
+
+// applyURLAnnotations applies the scheme and query annotations of the URL source to the URL of the target. The
+// forceHttps of the EndpointMonitor takes precedence over the scheme annotation.
+func applyURLAnnotations(source client.Object, target MonitorTarget, annotatedHealthEndpoint bool, forceHttps bool) (MonitorTarget, error) {
+	annotations := source.GetAnnotations()
+	if annotatedHealthEndpoint {
+		target.HealthEndpoint.Reason = wrappers.HealthEndpointAnnotation
+		target.HealthEndpoint.Message = fmt.Sprintf("Health endpoint is set by annotation %s of %s",
+			endpointmonitorv1alpha1.HealthPathAnnotation, source.GetName())
+	}
+
+	scheme, hasScheme := annotations[endpointmonitorv1alpha1.SchemeAnnotation]
+	query, hasQuery := annotations[endpointmonitorv1alpha1.QueryAnnotation]
+	if (!hasScheme && !hasQuery) || len(target.URL) == 0 {
+		return target, nil
+	}
+
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return target, err
+	}
+	if hasScheme {
+		scheme = strings.ToLower(scheme)
+		if scheme != "http" && scheme != "https" {
+			return target, fmt.Errorf("invalid annotation %s of %s: scheme must be http or https", endpointmonitorv1alpha1.SchemeAnnotation, source.GetName())
+		}
+		if !forceHttps {
+			u.Scheme = scheme
+		}
+	}
+	if query = strings.TrimPrefix(query, "?"); len(query) != 0 {
+		if len(u.RawQuery) != 0 {
+			u.RawQuery += "&" + query
+		} else {
+			u.RawQuery = query
+		}
+	}
+	target.URL = u.String()
+	return target, nil
+}
+
+func discoverURLFromRefs(client client.Client, ingressMonitor *endpointmonitorv1alpha1.EndpointMonitor) (MonitorTarget, error) {
 	urlFrom := ingressMonitor.Spec.URLFrom
 	if urlFrom == nil {
 		log.V(1).Info("No URL sources set for ingressMonitor: " + ingressMonitor.Name)
-		return "", errors.New("No URL sources set for ingressMonitor: " + ingressMonitor.Name)
+		return MonitorTarget{}, errors.New("No URL sources set for ingressMonitor: " + ingressMonitor.Name)
 	}
 
 	if urlFrom.IngressRef != nil {
 		// if ingressRef is mentioned, it can be openshift or non openshift cluster
 		return discoverURLFromIngressRef(client, urlFrom.IngressRef, ingressMonitor.Namespace, ingressMonitor.Spec.ForceHTTPS, ingressMonitor.Spec.HealthEndpoint)
 
+	} else if urlFrom.HTTPRouteRef != nil {
+		return discoverURLFromHTTPRouteRef(client, urlFrom.HTTPRouteRef, ingressMonitor.Namespace, ingressMonitor.Spec.ForceHTTPS, ingressMonitor.Spec.HealthEndpoint)
+
+	} else if urlFrom.ServiceRef != nil {
+		return discoverURLFromServiceRef(client, urlFrom.ServiceRef, ingressMonitor.Namespace, ingressMonitor.Spec.ForceHTTPS, ingressMonitor.Spec.HealthEndpoint)
+
 	} else if kube.IsOpenshift && urlFrom.RouteRef != nil {
 		// if routeRef is mentioned in openshift cluster
 		return discoverURLFromRouteRef(client, urlFrom.RouteRef, ingressMonitor.Namespace, ingressMonitor.Spec.ForceHTTPS, ingressMonitor.Spec.HealthEndpoint)
@@ -74,5 +228,5 @@ func discoverURLFromRefs(client client.Client, ingressMonitor *endpointmonitorv1
 	// if routeRef is mentioned in non openshift cluster
 	log.V(1).Info("RouteRef is only supported for openshift. Found non-openshift kubernetes cluster for ingressMonitor: " + ingressMonitor.Name)
 
-	return "", errors.New("Unsupported Ref set on ingressMonitor: " + ingressMonitor.Name)
+	return MonitorTarget{}, errors.New("Unsupported Ref set on ingressMonitor: " + ingressMonitor.Name)
 }
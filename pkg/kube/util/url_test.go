@@ -0,0 +1,198 @@
+package util
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/kube/wrappers"
+)
+
+func createAnnotatedIngress(annotations map[string]string) *v1.Ingress {
+	return &v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "web", Annotations: annotations},
+		Spec:       v1.IngressSpec{Rules: []v1.IngressRule{{Host: "shop.example.com"}}},
+	}
+}
+
+func TestGetAnnotatedHealthEndpoint(t *testing.T) {
+	tests := []struct {
+		name           string
+		annotations    map[string]string
+		healthEndpoint string
+		want           string
+		wantAnnotated  bool
+	}{
+		{
+			name: "NoAnnotation",
+		},
+		{
+			name:          "AnnotationWithLeadingSlash",
+			annotations:   map[string]string{endpointmonitorv1alpha1.HealthPathAnnotation: "/health"},
+			want:          "/health",
+			wantAnnotated: true,
+		},
+		{
+			name:          "AnnotationWithoutLeadingSlash",
+			annotations:   map[string]string{endpointmonitorv1alpha1.HealthPathAnnotation: "health"},
+			want:          "health",
+			wantAnnotated: true,
+		},
+		{
+			name:        "EmptyAnnotation",
+			annotations: map[string]string{endpointmonitorv1alpha1.HealthPathAnnotation: ""},
+		},
+		{
+			name:           "HealthEndpointOverAnnotation",
+			annotations:    map[string]string{endpointmonitorv1alpha1.HealthPathAnnotation: "/health"},
+			healthEndpoint: "/ready",
+			want:           "/ready",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, annotated := getAnnotatedHealthEndpoint(createAnnotatedIngress(tt.annotations), tt.healthEndpoint)
+			if got != tt.want || annotated != tt.wantAnnotated {
+				t.Errorf("getAnnotatedHealthEndpoint() = %v, %v, want %v, %v", got, annotated, tt.want, tt.wantAnnotated)
+			}
+		})
+	}
+}
+
+func TestApplyURLAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		url         string
+		forceHttps  bool
+		want        string
+		wantErr     bool
+	}{
+		{
+			name: "NoAnnotations",
+			url:  "http://shop.example.com/",
+			want: "http://shop.example.com/",
+		},
+		{
+			name:        "SchemeAnnotation",
+			annotations: map[string]string{endpointmonitorv1alpha1.SchemeAnnotation: "https"},
+			url:         "http://shop.example.com/",
+			want:        "https://shop.example.com/",
+		},
+		{
+			name:        "UppercaseSchemeAnnotation",
+			annotations: map[string]string{endpointmonitorv1alpha1.SchemeAnnotation: "HTTP"},
+			url:         "https://shop.example.com/",
+			want:        "http://shop.example.com/",
+		},
+		{
+			name:        "ForceHttpsOverSchemeAnnotation",
+			annotations: map[string]string{endpointmonitorv1alpha1.SchemeAnnotation: "http"},
+			url:         "https://shop.example.com/",
+			forceHttps:  true,
+			want:        "https://shop.example.com/",
+		},
+		{
+			name:        "InvalidSchemeAnnotation",
+			annotations: map[string]string{endpointmonitorv1alpha1.SchemeAnnotation: "ftp"},
+			url:         "http://shop.example.com/",
+			wantErr:     true,
+		},
+		{
+			name:        "InvalidURL",
+			annotations: map[string]string{endpointmonitorv1alpha1.SchemeAnnotation: "https"},
+			url:         "http://shop.example.com:port/",
+			wantErr:     true,
+		},
+		{
+			name:        "QueryAnnotation",
+			annotations: map[string]string{endpointmonitorv1alpha1.QueryAnnotation: "?probe=true"},
+			url:         "http://shop.example.com/health",
+			want:        "http://shop.example.com/health?probe=true",
+		},
+		{
+			name:        "QueryAnnotationWithQuery",
+			annotations: map[string]string{endpointmonitorv1alpha1.QueryAnnotation: "probe=true"},
+			url:         "http://shop.example.com/health?verbose=1",
+			want:        "http://shop.example.com/health?verbose=1&probe=true",
+		},
+		{
+			name:        "EmptyURL",
+			annotations: map[string]string{endpointmonitorv1alpha1.SchemeAnnotation: "https"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyURLAnnotations(createAnnotatedIngress(tt.annotations), MonitorTarget{URL: tt.url}, false, tt.forceHttps)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyURLAnnotations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.URL != tt.want {
+				t.Errorf("applyURLAnnotations() = %v, want %v", got.URL, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyURLAnnotationsReportsAnnotatedHealthEndpoint(t *testing.T) {
+	target, err := applyURLAnnotations(createAnnotatedIngress(nil), MonitorTarget{URL: "http://shop.example.com/health"}, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.HealthEndpoint.Reason != wrappers.HealthEndpointAnnotation {
+		t.Errorf("Expected the health endpoint to be reported as set by annotation, got %v", target.HealthEndpoint.Reason)
+	}
+}
+
+func TestDiscoverURLFromIngressRefWithAnnotations(t *testing.T) {
+	tests := []struct {
+		name           string
+		annotations    map[string]string
+		forceHttps     bool
+		healthEndpoint string
+		want           string
+	}{
+		{
+			name:        "HealthPathWithLeadingSlash",
+			annotations: map[string]string{endpointmonitorv1alpha1.HealthPathAnnotation: "/health"},
+			want:        "http://shop.example.com/health",
+		},
+		{
+			name:        "HealthPathWithoutLeadingSlash",
+			annotations: map[string]string{endpointmonitorv1alpha1.HealthPathAnnotation: "health"},
+			want:        "http://shop.example.com/health",
+		},
+		{
+			name:           "HealthEndpointOverHealthPath",
+			annotations:    map[string]string{endpointmonitorv1alpha1.HealthPathAnnotation: "/health"},
+			healthEndpoint: "/ready",
+			want:           "http://shop.example.com/ready",
+		},
+		{
+			name: "ForceHttpsOverSchemeAnnotation",
+			annotations: map[string]string{
+				endpointmonitorv1alpha1.HealthPathAnnotation: "health",
+				endpointmonitorv1alpha1.SchemeAnnotation:     "http",
+				endpointmonitorv1alpha1.QueryAnnotation:      "probe=true",
+			},
+			forceHttps: true,
+			want:       "https://shop.example.com/health?probe=true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fakekubeclient.NewClientBuilder().WithObjects(createAnnotatedIngress(tt.annotations)).Build()
+			ingressRef := &endpointmonitorv1alpha1.IngressURLSource{Name: "shop"}
+			got, err := discoverURLFromIngressRef(client, ingressRef, "web", tt.forceHttps, tt.healthEndpoint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.URL != tt.want {
+				t.Errorf("discoverURLFromIngressRef() = %v, want %v", got.URL, tt.want)
+			}
+		})
+	}
+}
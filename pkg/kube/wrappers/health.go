@@ -0,0 +1,150 @@
+package wrappers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Reasons of a HealthEndpoint
+const (
+	// HealthEndpointConfigured means the health endpoint is set on the EndpointMonitor
+	HealthEndpointConfigured = "Configured"
+	// HealthEndpointAnnotation means the health endpoint is set by an annotation of the URL source
+	HealthEndpointAnnotation = "Annotation"
+	// HealthEndpointReadinessProbe means the health endpoint is the path of a readiness probe
+	HealthEndpointReadinessProbe = "ReadinessProbe"
+	// HealthEndpointLivenessProbe means the health endpoint is the path of a liveness probe
+	HealthEndpointLivenessProbe = "LivenessProbe"
+	// HealthEndpointStartupProbe means the health endpoint is the path of a startup probe
+	HealthEndpointStartupProbe = "StartupProbe"
+	// HealthEndpointNotFound means no health endpoint could be discovered
+	HealthEndpointNotFound = "NotFound"
+)
+
+// HealthEndpoint is the health endpoint used for a URL and how it has been found
+type HealthEndpoint struct {
+	Path    string
+	Reason  string
+	Message string
+}
+
+// configuredHealthEndpoint returns the HealthEndpoint of a path set on the EndpointMonitor
+func configuredHealthEndpoint(path string) HealthEndpoint {
+	return HealthEndpoint{Path: path, Reason: HealthEndpointConfigured, Message: "Health endpoint is set on the EndpointMonitor"}
+}
+
+func healthEndpointNotFound(format string, args ...interface{}) HealthEndpoint {
+	return HealthEndpoint{Reason: HealthEndpointNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// discoverHealthEndpoint discovers the health endpoint from the HTTP probes of the pods selected by the service. The
+// container serving the given port of the service is used, the port is a name or number and may be empty if the
+// service has a single port. Readiness probes are preferred over liveness probes and those over startup probes.
+func discoverHealthEndpoint(c client.Client, namespace string, serviceName string, servicePort string) HealthEndpoint {
+	service := &corev1.Service{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: serviceName, Namespace: namespace}, service)
+	if err != nil {
+		log.Info(fmt.Sprintf("Get service from kubernetes cluster error:%v", err))
+		return healthEndpointNotFound("Failed to get service %s: %v", serviceName, err)
+	}
+	if len(service.Spec.Selector) == 0 {
+		return healthEndpointNotFound("Service %s has no selector", serviceName)
+	}
+
+	podList := &corev1.PodList{}
+	listOps := &client.ListOptions{
+		Namespace:     namespace,
+		LabelSelector: labels.Set(service.Spec.Selector).AsSelector(),
+	}
+	err = c.List(context.TODO(), podList, listOps)
+	if err != nil {
+		log.Info(fmt.Sprintf("List Pods of service[%s] error:%v", service.GetName(), err))
+		return healthEndpointNotFound("Failed to list pods of service %s: %v", serviceName, err)
+	}
+	if len(podList.Items) == 0 {
+		return healthEndpointNotFound("Service %s selects no pods", serviceName)
+	}
+
+	targetPort, hasTargetPort := getServiceTargetPort(service, servicePort)
+	notFound := healthEndpointNotFound("Pods of service %s have no container with an HTTP probe", serviceName)
+	for _, pod := range podList.Items {
+		container := getServingContainer(pod.Spec.Containers, targetPort, hasTargetPort)
+		if container == nil {
+			notFound = healthEndpointNotFound("Pod %s has no container serving the port of service %s", pod.Name, serviceName)
+			continue
+		}
+		if healthEndpoint, exists := getProbeHealthEndpoint(pod.Name, container); exists {
+			return healthEndpoint
+		}
+	}
+	return notFound
+}
+
+// getServiceTargetPort returns the target port of the given port of the service, which defaults to its only port
+func getServiceTargetPort(service *corev1.Service, servicePort string) (intstr.IntOrString, bool) {
+	for _, port := range service.Spec.Ports {
+		if len(servicePort) == 0 && len(service.Spec.Ports) != 1 {
+			break
+		}
+		if len(servicePort) != 0 && port.Name != servicePort && strconv.Itoa(int(port.Port)) != servicePort {
+			continue
+		}
+		if port.TargetPort.IntValue() == 0 && port.TargetPort.Type == intstr.Int {
+			// The target port defaults to the port of the service
+			return intstr.FromInt(int(port.Port)), true
+		}
+		return port.TargetPort, true
+	}
+	return intstr.IntOrString{}, false
+}
+
+// getServingContainer returns the container exposing the target port. Without target port, or if no container
+// declares it, a pod with a single container is served by that container.
+func getServingContainer(containers []corev1.Container, targetPort intstr.IntOrString, hasTargetPort bool) *corev1.Container {
+	if hasTargetPort {
+		for index, container := range containers {
+			for _, port := range container.Ports {
+				if (targetPort.Type == intstr.String && port.Name == targetPort.StrVal) ||
+					(targetPort.Type == intstr.Int && port.ContainerPort == targetPort.IntVal) {
+					return &containers[index]
+				}
+			}
+		}
+	}
+	if len(containers) == 1 {
+		return &containers[0]
+	}
+	log.Info(fmt.Sprintf("Pod has %d containers and none serves the port of the service so skipping health endpoint", len(containers)))
+	return nil
+}
+
+// getProbeHealthEndpoint returns the path of the first HTTP probe of the container, in order of preference
+func getProbeHealthEndpoint(podName string, container *corev1.Container) (HealthEndpoint, bool) {
+	probes := []struct {
+		probe  *corev1.Probe
+		reason string
+		name   string
+	}{
+		{container.ReadinessProbe, HealthEndpointReadinessProbe, "readiness"},
+		{container.LivenessProbe, HealthEndpointLivenessProbe, "liveness"},
+		{container.StartupProbe, HealthEndpointStartupProbe, "startup"},
+	}
+	for _, probe := range probes {
+		if probe.probe == nil || probe.probe.HTTPGet == nil {
+			continue
+		}
+		return HealthEndpoint{
+			Path:    probe.probe.HTTPGet.Path,
+			Reason:  probe.reason,
+			Message: fmt.Sprintf("Discovered from the %s probe of container %s of pod %s", probe.name, container.Name, podName),
+		}, true
+	}
+	return HealthEndpoint{}, false
+}
@@ -0,0 +1,93 @@
+package wrappers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func createHTTPProbe(path string) *corev1.Probe {
+	return &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: path}}}
+}
+
+func createServiceWithTargetPort(targetPort intstr.IntOrString) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "app"},
+			Ports:    []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: targetPort}},
+		},
+	}
+}
+
+func createPodWithContainers(containers ...corev1.Container) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-0", Namespace: "test", Labels: map[string]string{"app": "app"}},
+		Spec:       corev1.PodSpec{Containers: containers},
+	}
+}
+
+func TestDiscoverHealthEndpoint(t *testing.T) {
+	sidecar := corev1.Container{
+		Name:           "istio-proxy",
+		Ports:          []corev1.ContainerPort{{Name: "http-envoy-prom", ContainerPort: 15090}},
+		ReadinessProbe: createHTTPProbe("/healthz/ready"),
+	}
+	app := corev1.Container{
+		Name:           "app",
+		Ports:          []corev1.ContainerPort{{Name: "web", ContainerPort: 8080}},
+		ReadinessProbe: createHTTPProbe("/ready"),
+		LivenessProbe:  createHTTPProbe("/live"),
+	}
+	livenessOnly := app
+	livenessOnly.ReadinessProbe = nil
+
+	tests := []struct {
+		name       string
+		objects    []client.Object
+		wantPath   string
+		wantReason string
+	}{
+		{
+			name:       "TestContainerMatchingTargetPortNumber",
+			objects:    []client.Object{createServiceWithTargetPort(intstr.FromInt(8080)), createPodWithContainers(sidecar, app)},
+			wantPath:   "/ready",
+			wantReason: HealthEndpointReadinessProbe,
+		},
+		{
+			name:       "TestContainerMatchingTargetPortName",
+			objects:    []client.Object{createServiceWithTargetPort(intstr.FromString("web")), createPodWithContainers(sidecar, app)},
+			wantPath:   "/ready",
+			wantReason: HealthEndpointReadinessProbe,
+		},
+		{
+			name:       "TestLivenessProbeFallback",
+			objects:    []client.Object{createServiceWithTargetPort(intstr.FromInt(8080)), createPodWithContainers(sidecar, livenessOnly)},
+			wantPath:   "/live",
+			wantReason: HealthEndpointLivenessProbe,
+		},
+		{
+			name:       "TestNoContainerMatchingTargetPort",
+			objects:    []client.Object{createServiceWithTargetPort(intstr.FromInt(9090)), createPodWithContainers(sidecar, app)},
+			wantReason: HealthEndpointNotFound,
+		},
+		{
+			name:       "TestNoPods",
+			objects:    []client.Object{createServiceWithTargetPort(intstr.FromInt(8080))},
+			wantReason: HealthEndpointNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fakekubeclient.NewClientBuilder().WithObjects(tt.objects...).Build()
+			got := discoverHealthEndpoint(c, "test", "app", "http")
+			if got.Path != tt.wantPath || got.Reason != tt.wantReason {
+				t.Errorf("discoverHealthEndpoint() = %v, want path %v and reason %v", got, tt.wantPath, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package wrappers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GatewayAPIGroup is the API group of the Gateway API
+const GatewayAPIGroup = "gateway.networking.k8s.io"
+
+// GatewayAPIVersions are the versions of the Gateway API that are tried in order
+var GatewayAPIVersions = []string{"v1", "v1beta1"}
+
+// HTTPRouteWrapper resolves the URL of a Gateway API HTTPRoute. The Gateway API types are handled as unstructured
+// objects so that the controller doesn't depend on a specific version of them.
+type HTTPRouteWrapper struct {
+	HTTPRoute *unstructured.Unstructured
+	Client    client.Client
+
+	// HealthEndpoint of the URL returned by the last call of GetURL
+	HealthEndpoint HealthEndpoint
+}
+
+func NewHTTPRouteWrapper(httpRoute *unstructured.Unstructured, client client.Client) *HTTPRouteWrapper {
+	return &HTTPRouteWrapper{
+		HTTPRoute: httpRoute,
+		Client:    client,
+	}
+}
+
+// GetHTTPRoute fetches the HTTPRoute with the given name in any of the supported versions of the Gateway API
+func GetHTTPRoute(c client.Client, name string, namespace string) (*unstructured.Unstructured, error) {
+	return getGatewayAPIObject(c, "HTTPRoute", name, namespace)
+}
+
+func getGatewayAPIObject(c client.Client, kind string, name string, namespace string) (*unstructured.Unstructured, error) {
+	var err error
+	for _, version := range GatewayAPIVersions {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Group: GatewayAPIGroup, Version: version, Kind: kind})
+		err = c.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, obj)
+		if !meta.IsNoMatchError(err) {
+			return obj, err
+		}
+	}
+	return nil, err
+}
+
+// GetURL returns the URL of the first hostname and the path of the first match of the HTTPRoute. The scheme is
+// derived from the listener of the parent Gateway.
+func (hw *HTTPRouteWrapper) GetURL(forceHttps bool, healthEndpoint string) (string, error) {
+	listener := hw.getListener()
+
+	host := hw.getHost(listener)
+	if len(host) == 0 {
+		return "", fmt.Errorf("httproute %s has no hostname", hw.HTTPRoute.GetName())
+	}
+
+	u := url.URL{Scheme: "http", Host: host}
+	if forceHttps || listenerSupportsTLS(listener) {
+		u.Scheme = "https"
+	}
+
+	if len(healthEndpoint) != 0 {
+		u.Path = healthEndpoint
+		hw.HealthEndpoint = configuredHealthEndpoint(healthEndpoint)
+		return u.String(), nil
+	}
+
+	u.Path = hw.getSubPath()
+
+	// Find pod by backtracking httproute -> service -> pod
+	serviceName, servicePort, exists := hw.hasService()
+	if !exists {
+		hw.HealthEndpoint = healthEndpointNotFound("HTTPRoute %s has no service backend in its namespace", hw.HTTPRoute.GetName())
+		return u.String(), nil
+	}
+	hw.HealthEndpoint = discoverHealthEndpoint(hw.Client, hw.HTTPRoute.GetNamespace(), serviceName, servicePort)
+	if len(hw.HealthEndpoint.Path) != 0 {
+		u.Path = path.Join(u.Path, hw.HealthEndpoint.Path)
+	}
+	return u.String(), nil
+}
+
+// getHost returns the first hostname of the HTTPRoute, or the hostname of the listener if the route has none
+func (hw *HTTPRouteWrapper) getHost(listener map[string]interface{}) string {
+	hostnames, _, _ := unstructured.NestedStringSlice(hw.HTTPRoute.Object, "spec", "hostnames")
+	if len(hostnames) != 0 {
+		return hostnames[0]
+	}
+	if listener != nil {
+		hostname, _, _ := unstructured.NestedString(listener, "hostname")
+		if !strings.HasPrefix(hostname, "*") {
+			return hostname
+		}
+	}
+	return ""
+}
+
+// getSubPath returns the path of the first match of the first rule
+func (hw *HTTPRouteWrapper) getSubPath() string {
+	rule := hw.getFirstRule()
+	if rule == nil {
+		return ""
+	}
+	matches, _, _ := unstructured.NestedSlice(rule, "matches")
+	if len(matches) == 0 {
+		return ""
+	}
+	match, ok := matches[0].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	value, _, _ := unstructured.NestedString(match, "path", "value")
+	matchType, _, _ := unstructured.NestedString(match, "path", "type")
+	if matchType == "RegularExpression" {
+		// Remove regex capture groups from path if exists
+		value = strings.Split(value, "(")[0]
+	}
+	return strings.TrimRight(value, "*")
+}
+
+// hasService returns the name and port of the first backend of the first rule if it is a Service
+func (hw *HTTPRouteWrapper) hasService() (string, string, bool) {
+	rule := hw.getFirstRule()
+	if rule == nil {
+		return "", "", false
+	}
+	backendRefs, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+	if len(backendRefs) == 0 {
+		return "", "", false
+	}
+	backendRef, ok := backendRefs[0].(map[string]interface{})
+	if !ok {
+		return "", "", false
+	}
+	group, _, _ := unstructured.NestedString(backendRef, "group")
+	kind, _, _ := unstructured.NestedString(backendRef, "kind")
+	name, _, _ := unstructured.NestedString(backendRef, "name")
+	namespace, _, _ := unstructured.NestedString(backendRef, "namespace")
+	port, _, _ := unstructured.NestedInt64(backendRef, "port")
+	if len(group) != 0 || (len(kind) != 0 && kind != "Service") || len(name) == 0 {
+		return "", "", false
+	}
+	if len(namespace) != 0 && namespace != hw.HTTPRoute.GetNamespace() {
+		// Pods of other namespaces are not looked up
+		return "", "", false
+	}
+	if port == 0 {
+		return name, "", true
+	}
+	return name, strconv.FormatInt(port, 10), true
+}
+
+func (hw *HTTPRouteWrapper) getFirstRule() map[string]interface{} {
+	rules, _, _ := unstructured.NestedSlice(hw.HTTPRoute.Object, "spec", "rules")
+	if len(rules) == 0 {
+		return nil
+	}
+	rule, _ := rules[0].(map[string]interface{})
+	return rule
+}
+
+// getListener returns the listener of the first parent Gateway the HTTPRoute is attached to. The listener is selected
+// by the section name of the parent reference, otherwise by the hostname of the route.
+func (hw *HTTPRouteWrapper) getListener() map[string]interface{} {
+	parentRefs, _, _ := unstructured.NestedSlice(hw.HTTPRoute.Object, "spec", "parentRefs")
+	for _, item := range parentRefs {
+		parentRef, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		group, found, _ := unstructured.NestedString(parentRef, "group")
+		kind, _, _ := unstructured.NestedString(parentRef, "kind")
+		if (found && group != GatewayAPIGroup) || (len(kind) != 0 && kind != "Gateway") {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(parentRef, "name")
+		namespace, _, _ := unstructured.NestedString(parentRef, "namespace")
+		if len(namespace) == 0 {
+			namespace = hw.HTTPRoute.GetNamespace()
+		}
+
+		gateway, err := getGatewayAPIObject(hw.Client, "Gateway", name, namespace)
+		if err != nil {
+			log.Info(fmt.Sprintf("Get gateway %s/%s from kubernetes cluster error:%v", namespace, name, err))
+			continue
+		}
+		sectionName, _, _ := unstructured.NestedString(parentRef, "sectionName")
+		if listener := hw.selectListener(gateway, sectionName); listener != nil {
+			return listener
+		}
+	}
+	return nil
+}
+
+func (hw *HTTPRouteWrapper) selectListener(gateway *unstructured.Unstructured, sectionName string) map[string]interface{} {
+	listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	hostnames, _, _ := unstructured.NestedStringSlice(hw.HTTPRoute.Object, "spec", "hostnames")
+
+	var fallback map[string]interface{}
+	for _, item := range listeners {
+		listener, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(listener, "name")
+		if len(sectionName) != 0 {
+			if name == sectionName {
+				return listener
+			}
+			continue
+		}
+
+		hostname, _, _ := unstructured.NestedString(listener, "hostname")
+		if len(hostnames) != 0 && matchesHostname(hostname, hostnames[0]) {
+			// HTTPS listeners are preferred if the hostname is served by several of them
+			if listenerSupportsTLS(listener) {
+				return listener
+			}
+			if fallback == nil {
+				fallback = listener
+			}
+		}
+	}
+	if fallback == nil && len(sectionName) == 0 && len(listeners) != 0 {
+		fallback, _ = listeners[0].(map[string]interface{})
+	}
+	return fallback
+}
+
+// listenerSupportsTLS returns true if the listener terminates TLS
+func listenerSupportsTLS(listener map[string]interface{}) bool {
+	if listener == nil {
+		return false
+	}
+	protocol, _, _ := unstructured.NestedString(listener, "protocol")
+	if protocol == "HTTPS" || protocol == "TLS" {
+		return true
+	}
+	_, hasTLS, _ := unstructured.NestedMap(listener, "tls")
+	return hasTLS
+}
+
+// matchesHostname returns true if the hostname of a listener, which may be a wildcard or empty, matches the host
+func matchesHostname(listenerHostname string, host string) bool {
+	if len(listenerHostname) == 0 || listenerHostname == host {
+		return true
+	}
+	if strings.HasPrefix(listenerHostname, "*.") {
+		return strings.HasSuffix(host, listenerHostname[1:])
+	}
+	return false
+}
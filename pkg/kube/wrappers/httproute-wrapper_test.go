@@ -0,0 +1,111 @@
+package wrappers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func createGatewayAPIObject(kind string, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": GatewayAPIGroup + "/v1",
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "test",
+		},
+		"spec": spec,
+	}}
+}
+
+func createGateway() *unstructured.Unstructured {
+	return createGatewayAPIObject("Gateway", "gateway", map[string]interface{}{
+		"listeners": []interface{}{
+			map[string]interface{}{"name": "http", "protocol": "HTTP", "port": int64(80)},
+			map[string]interface{}{"name": "https", "protocol": "HTTPS", "port": int64(443), "hostname": "*.stackator.com"},
+		},
+	})
+}
+
+func createHTTPRoute(sectionName string, hostnames []interface{}) *unstructured.Unstructured {
+	parentRef := map[string]interface{}{"name": "gateway"}
+	if len(sectionName) != 0 {
+		parentRef["sectionName"] = sectionName
+	}
+	spec := map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"matches": []interface{}{
+					map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": "/api"}},
+				},
+			},
+		},
+	}
+	if hostnames != nil {
+		spec["hostnames"] = hostnames
+	}
+	return createGatewayAPIObject("HTTPRoute", "testHTTPRoute", spec)
+}
+
+func TestHTTPRouteWrapper_GetURL(t *testing.T) {
+	tests := []struct {
+		name           string
+		httpRoute      *unstructured.Unstructured
+		objects        []client.Object
+		forceHttps     bool
+		healthEndpoint string
+		want           string
+		wantErr        bool
+	}{
+		{
+			name:      "TestGetUrlFromHTTPSListener",
+			httpRoute: createHTTPRoute("", []interface{}{"testurl.stackator.com"}),
+			objects:   []client.Object{createGateway()},
+			want:      "https://testurl.stackator.com/api",
+		},
+		{
+			name:      "TestGetUrlFromSectionName",
+			httpRoute: createHTTPRoute("http", []interface{}{"testurl.stackator.com"}),
+			objects:   []client.Object{createGateway()},
+			want:      "http://testurl.stackator.com/api",
+		},
+		{
+			name:       "TestGetUrlWithForceHttps",
+			httpRoute:  createHTTPRoute("http", []interface{}{"testurl.stackator.com"}),
+			objects:    []client.Object{createGateway()},
+			forceHttps: true,
+			want:       "https://testurl.stackator.com/api",
+		},
+		{
+			name:      "TestGetUrlWithoutGateway",
+			httpRoute: createHTTPRoute("", []interface{}{"testurl.stackator.com"}),
+			want:      "http://testurl.stackator.com/api",
+		},
+		{
+			name:           "TestGetUrlWithHealthEndpoint",
+			httpRoute:      createHTTPRoute("", []interface{}{"testurl.stackator.com"}),
+			healthEndpoint: "/health",
+			want:           "http://testurl.stackator.com/health",
+		},
+		{
+			name:      "TestGetUrlWithoutHostname",
+			httpRoute: createHTTPRoute("", nil),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hw := NewHTTPRouteWrapper(tt.httpRoute, fakekubeclient.NewClientBuilder().WithObjects(tt.objects...).Build())
+			got, err := hw.GetURL(tt.forceHttps, tt.healthEndpoint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,22 +1,22 @@
 package wrappers
 
 import (
-	"context"
 	"fmt"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 
-	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
-	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type IngressWrapper struct {
 	Ingress *v1.Ingress
 	Client  client.Client
+
+	// HealthEndpoint of the URL returned by the last call of GetURL or GetURLForRule
+	HealthEndpoint HealthEndpoint
 }
 
 func NewIngressWrapper(ingress *v1.Ingress, client client.Client) *IngressWrapper {
@@ -98,71 +98,163 @@ func (iw *IngressWrapper) GetURL(forceHttps bool, healthEndpoint string) string
 
 	if len(healthEndpoint) != 0 {
 		u.Path = healthEndpoint
+		iw.HealthEndpoint = configuredHealthEndpoint(healthEndpoint)
 	} else {
 		// ingressSubPath
 		ingressSubPath := iw.getIngressSubPath()
 		u.Path = path.Join(u.Path, ingressSubPath)
 
 		// Find pod by backtracking ingress -> service -> pod
-		healthEndpoint, exists := iw.tryGetHealthEndpointFromIngress()
+		iw.HealthEndpoint = iw.tryGetHealthEndpointFromIngress()
 
 		// Health endpoint from pod successful
-		if exists {
-			u.Path = path.Join(u.Path, healthEndpoint)
+		if len(iw.HealthEndpoint.Path) != 0 {
+			u.Path = path.Join(u.Path, iw.HealthEndpoint.Path)
 		}
 	}
 	return u.String()
 }
 
-func (iw *IngressWrapper) hasService() (string, bool) {
+func (iw *IngressWrapper) hasService() (*v1.IngressServiceBackend, bool) {
 	ingress := iw.Ingress
 	if ingress.Spec.Rules[0].HTTP != nil &&
 		ingress.Spec.Rules[0].HTTP.Paths != nil &&
 		len(ingress.Spec.Rules[0].HTTP.Paths) > 0 &&
 		ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service != nil &&
 		ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name != "" {
-		return ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name, true
+		return ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service, true
 	}
-	return "", false
+	return nil, false
 }
 
-func (iw *IngressWrapper) tryGetHealthEndpointFromIngress() (string, bool) {
-	serviceName, exists := iw.hasService()
+func (iw *IngressWrapper) tryGetHealthEndpointFromIngress() HealthEndpoint {
+	backend, exists := iw.hasService()
 
 	if !exists {
-		return "", false
+		return healthEndpointNotFound("Ingress %s has no service backend", iw.Ingress.GetName())
 	}
+	return iw.tryGetHealthEndpointFromService(backend)
+}
 
-	service := &corev1.Service{}
-	err := iw.Client.Get(context.TODO(), types.NamespacedName{Name: serviceName, Namespace: iw.Ingress.Namespace}, service)
-	if err != nil {
-		log.Info(fmt.Sprintf("Get service from kubernetes cluster error:%v", err))
-		return "", false
+func (iw *IngressWrapper) tryGetHealthEndpointFromService(backend *v1.IngressServiceBackend) HealthEndpoint {
+	servicePort := backend.Port.Name
+	if backend.Port.Number != 0 {
+		servicePort = strconv.Itoa(int(backend.Port.Number))
 	}
+	return discoverHealthEndpoint(iw.Client, iw.Ingress.Namespace, backend.Name, servicePort)
+}
 
-	labels := labels.Set(service.Spec.Selector)
+// IngressTarget is a host and path of an Ingress and the URL to monitor it
+type IngressTarget struct {
+	Host           string
+	Path           string
+	URL            string
+	HealthEndpoint HealthEndpoint
+}
 
-	podList := &corev1.PodList{}
-	listOps := &client.ListOptions{
-		Namespace:     iw.Ingress.Namespace,
-		LabelSelector: labels.AsSelector(),
+// GetURLForRule returns the URL of the given host and path of the Ingress. The host defaults to the host of the
+// first rule and the path to the first path of the rule.
+func (iw *IngressWrapper) GetURLForRule(host string, path string, forceHttps bool, healthEndpoint string) (string, error) {
+	for _, rule := range iw.Ingress.Spec.Rules {
+		if len(host) != 0 && rule.Host != host {
+			continue
+		}
+		if len(path) == 0 {
+			if rule.HTTP == nil || len(rule.HTTP.Paths) == 0 {
+				return iw.getURLForRule(rule, nil, forceHttps, healthEndpoint), nil
+			}
+			return iw.getURLForRule(rule, &rule.HTTP.Paths[0], forceHttps, healthEndpoint), nil
+		}
+		if rule.HTTP == nil {
+			continue
+		}
+		for index := range rule.HTTP.Paths {
+			if rule.HTTP.Paths[index].Path == path {
+				return iw.getURLForRule(rule, &rule.HTTP.Paths[index], forceHttps, healthEndpoint), nil
+			}
+		}
 	}
-	err = iw.Client.List(context.TODO(), podList, listOps)
-	if err != nil {
-		log.Info(fmt.Sprintf("List Pods of service[%s] error:%v", service.GetName(), err))
-	} else if len(podList.Items) > 0 {
-		pod := podList.Items[0]
+	return "", fmt.Errorf("ingress %s has no rule with host %q and path %q", iw.Ingress.GetName(), host, path)
+}
 
-		podContainers := pod.Spec.Containers
+// GetURLs returns the URLs of every host and path of the Ingress. Rules without host are skipped, as well as
+// duplicate hosts and paths.
+func (iw *IngressWrapper) GetURLs(forceHttps bool, healthEndpoint string) []IngressTarget {
+	var targets []IngressTarget
+	seen := make(map[string]bool)
+	add := func(rule v1.IngressRule, ingressPath *v1.HTTPIngressPath) {
+		target := IngressTarget{Host: rule.Host}
+		if ingressPath != nil {
+			target.Path = ingressPath.Path
+		}
+		if seen[target.Host+target.Path] {
+			return
+		}
+		seen[target.Host+target.Path] = true
+		target.URL, target.HealthEndpoint = iw.getURLForPath(rule, ingressPath, forceHttps, healthEndpoint)
+		targets = append(targets, target)
+	}
 
-		if len(podContainers) == 1 {
-			if podContainers[0].ReadinessProbe != nil && podContainers[0].ReadinessProbe.HTTPGet != nil {
-				return podContainers[0].ReadinessProbe.HTTPGet.Path, true
-			}
-		} else {
-			log.Info(fmt.Sprintf("Pod has %d containers so skipping health endpoint", len(podContainers)))
+	for _, rule := range iw.Ingress.Spec.Rules {
+		if len(rule.Host) == 0 {
+			log.Info("Skipping rule without host in ingress: " + iw.Ingress.GetName())
+			continue
+		}
+		if rule.HTTP == nil || len(rule.HTTP.Paths) == 0 {
+			add(rule, nil)
+			continue
+		}
+		for index := range rule.HTTP.Paths {
+			add(rule, &rule.HTTP.Paths[index])
 		}
 	}
+	return targets
+}
 
-	return "", false
+// getURLForRule builds the URL of a path of a rule and records its health endpoint
+func (iw *IngressWrapper) getURLForRule(rule v1.IngressRule, ingressPath *v1.HTTPIngressPath, forceHttps bool, healthEndpoint string) string {
+	var URL string
+	URL, iw.HealthEndpoint = iw.getURLForPath(rule, ingressPath, forceHttps, healthEndpoint)
+	return URL
+}
+
+// getURLForPath builds the URL of a path of a rule. HTTPS is used if the host is covered by a TLS entry.
+func (iw *IngressWrapper) getURLForPath(rule v1.IngressRule, ingressPath *v1.HTTPIngressPath, forceHttps bool, healthEndpoint string) (string, HealthEndpoint) {
+	u := url.URL{Scheme: "http", Host: rule.Host}
+	if forceHttps || iw.hasTLSHost(rule.Host) {
+		u.Scheme = "https"
+	}
+
+	if len(healthEndpoint) != 0 {
+		u.Path = healthEndpoint
+		return u.String(), configuredHealthEndpoint(healthEndpoint)
+	}
+
+	if ingressPath == nil {
+		return u.String(), healthEndpointNotFound("Rule of host %s has no paths", rule.Host)
+	}
+	// Remove * and regex capture groups from the path if they exist
+	subPath := strings.Split(strings.TrimRight(ingressPath.Path, "*"), "(")[0]
+	u.Path = path.Join(u.Path, subPath)
+
+	// Find pod by backtracking ingress -> service -> pod
+	if ingressPath.Backend.Service == nil || len(ingressPath.Backend.Service.Name) == 0 {
+		return u.String(), healthEndpointNotFound("Path %s has no service backend", ingressPath.Path)
+	}
+	discovered := iw.tryGetHealthEndpointFromService(ingressPath.Backend.Service)
+	if len(discovered.Path) != 0 {
+		u.Path = path.Join(u.Path, discovered.Path)
+	}
+	return u.String(), discovered
+}
+
+func (iw *IngressWrapper) hasTLSHost(host string) bool {
+	for _, tls := range iw.Ingress.Spec.TLS {
+		for _, tlsHost := range tls.Hosts {
+			if tlsHost == host {
+				return true
+			}
+		}
+	}
+	return false
 }
@@ -116,3 +116,74 @@ func TestIngressWrapper_getURL(t *testing.T) {
 		})
 	}
 }
+
+func createIngressObjectWithRules() *v1.Ingress {
+	ingress := util.CreateIngressObject("testIngress", "test", "api.stackator.com")
+	ingress.Spec.Rules = []v1.IngressRule{
+		{
+			Host: "api.stackator.com",
+			IngressRuleValue: v1.IngressRuleValue{
+				HTTP: &v1.HTTPIngressRuleValue{
+					Paths: []v1.HTTPIngressPath{{Path: "/v1"}, {Path: "/v2(/|$)(.*)"}},
+				},
+			},
+		},
+		{Host: "www.stackator.com"},
+		{Host: ""},
+		{Host: "api.stackator.com", IngressRuleValue: v1.IngressRuleValue{
+			HTTP: &v1.HTTPIngressRuleValue{Paths: []v1.HTTPIngressPath{{Path: "/v1"}}},
+		}},
+	}
+	ingress.Spec.TLS = []v1.IngressTLS{{Hosts: []string{"www.stackator.com"}}}
+	return ingress
+}
+
+func TestIngressWrapper_GetURLs(t *testing.T) {
+	iw := NewIngressWrapper(createIngressObjectWithRules(), fakekubeclient.NewClientBuilder().Build())
+
+	want := []IngressTarget{
+		{Host: "api.stackator.com", Path: "/v1", URL: "http://api.stackator.com/v1"},
+		{Host: "api.stackator.com", Path: "/v2(/|$)(.*)", URL: "http://api.stackator.com/v2"},
+		{Host: "www.stackator.com", URL: "https://www.stackator.com"},
+	}
+	got := iw.GetURLs(false, "")
+	if len(got) != len(want) {
+		t.Fatalf("GetURLs() = %v, want %v", got, want)
+	}
+	for index := range want {
+		got[index].HealthEndpoint = HealthEndpoint{}
+		if got[index] != want[index] {
+			t.Errorf("GetURLs()[%d] = %v, want %v", index, got[index], want[index])
+		}
+	}
+}
+
+func TestIngressWrapper_GetURLForRule(t *testing.T) {
+	iw := NewIngressWrapper(createIngressObjectWithRules(), fakekubeclient.NewClientBuilder().Build())
+
+	tests := []struct {
+		name    string
+		host    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "TestGetUrlForHost", host: "www.stackator.com", want: "https://www.stackator.com"},
+		{name: "TestGetUrlForFirstPathOfHost", host: "api.stackator.com", want: "http://api.stackator.com/v1"},
+		{name: "TestGetUrlForHostAndPath", host: "api.stackator.com", path: "/v2(/|$)(.*)", want: "http://api.stackator.com/v2"},
+		{name: "TestGetUrlForPathOfFirstRule", path: "/v1", want: "http://api.stackator.com/v1"},
+		{name: "TestGetUrlForUnknownHost", host: "admin.stackator.com", wantErr: true},
+		{name: "TestGetUrlForUnknownPath", host: "api.stackator.com", path: "/v3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := iw.GetURLForRule(tt.host, tt.path, false, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetURLForRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetURLForRule() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,15 +1,11 @@
 package wrappers
 
 import (
-	"context"
 	"fmt"
 	"net/url"
 	"path"
 
 	routev1 "github.com/openshift/api/route/v1"
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -19,6 +15,9 @@ var log = logf.Log.WithName("route-wrapper")
 type RouteWrapper struct {
 	Route  *routev1.Route
 	Client client.Client
+
+	// HealthEndpoint of the URL returned by the last call of GetURL
+	HealthEndpoint HealthEndpoint
 }
 
 func NewRouteWrapper(route *routev1.Route, client client.Client) *RouteWrapper {
@@ -59,43 +58,17 @@ func (rw *RouteWrapper) hasService() (string, bool) {
 	return "", false
 }
 
-func (rw *RouteWrapper) tryGetHealthEndpointFromRoute() (string, bool) {
+func (rw *RouteWrapper) tryGetHealthEndpointFromRoute() HealthEndpoint {
 	serviceName, exists := rw.hasService()
 	if !exists {
-		return "", false
+		return healthEndpointNotFound("Route %s has no service", rw.Route.GetName())
 	}
 
-	service := &corev1.Service{}
-	err := rw.Client.Get(context.TODO(), types.NamespacedName{Name: serviceName, Namespace: rw.Route.Namespace}, service)
-	if err != nil {
-		log.Info(fmt.Sprintf("Get service from kubernetes cluster error:%v", err))
-		return "", false
-	}
-
-	labels := labels.Set(service.Spec.Selector)
-
-	podList := &corev1.PodList{}
-	listOps := &client.ListOptions{
-		Namespace:     rw.Route.Namespace,
-		LabelSelector: labels.AsSelector(),
+	var servicePort string
+	if rw.Route.Spec.Port != nil {
+		servicePort = rw.Route.Spec.Port.TargetPort.String()
 	}
-	err = rw.Client.List(context.TODO(), podList, listOps)
-	if err != nil {
-		log.Info(fmt.Sprintf("List Pods of service[%s] error:%v", service.GetName(), err))
-	} else if len(podList.Items) > 0 {
-		pod := podList.Items[0]
-		podContainers := pod.Spec.Containers
-
-		if len(podContainers) == 1 {
-			if podContainers[0].ReadinessProbe != nil && podContainers[0].ReadinessProbe.HTTPGet != nil {
-				return podContainers[0].ReadinessProbe.HTTPGet.Path, true
-			}
-		} else {
-			log.Info(fmt.Sprintf("Pod has %d containers so skipping health endpoint", len(podContainers)))
-		}
-	}
-
-	return "", false
+	return discoverHealthEndpoint(rw.Client, rw.Route.Namespace, serviceName, servicePort)
 }
 
 func (rw *RouteWrapper) GetURL(forceHttps bool, healthEndpoint string) string {
@@ -117,16 +90,17 @@ func (rw *RouteWrapper) GetURL(forceHttps bool, healthEndpoint string) string {
 
 	if len(healthEndpoint) != 0 {
 		u.Path = healthEndpoint
+		rw.HealthEndpoint = configuredHealthEndpoint(healthEndpoint)
 	} else {
 		// Append subpath
 		u.Path = path.Join(u.Path, rw.getRouteSubPath())
 
 		// Find pod by backtracking route -> service -> pod
-		healthEndpoint, exists := rw.tryGetHealthEndpointFromRoute()
+		rw.HealthEndpoint = rw.tryGetHealthEndpointFromRoute()
 
 		// Health endpoint from pod successful
-		if exists {
-			u.Path = path.Join(u.Path, healthEndpoint)
+		if len(rw.HealthEndpoint.Path) != 0 {
+			u.Path = path.Join(u.Path, rw.HealthEndpoint.Path)
 		}
 	}
 	return u.String()
@@ -0,0 +1,123 @@
+package wrappers
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	schemeTCP   = "tcp"
+	schemeHTTP  = "http"
+	schemeHTTPS = "https"
+)
+
+// ServiceWrapper resolves the URL of a Service of type LoadBalancer from the address of its load balancer
+type ServiceWrapper struct {
+	Service *corev1.Service
+	Client  client.Client
+
+	// HealthEndpoint of the URL returned by the last call of GetURL
+	HealthEndpoint HealthEndpoint
+}
+
+func NewServiceWrapper(service *corev1.Service, client client.Client) *ServiceWrapper {
+	return &ServiceWrapper{
+		Service: service,
+		Client:  client,
+	}
+}
+
+// GetURL returns the URL of the given port of the Service. The port is selected by name or number and defaults to
+// the first port. The scheme defaults to http or https if the app protocol or the name of the port starts with it,
+// and to tcp otherwise. Paths are only added to http and https URLs.
+func (sw *ServiceWrapper) GetURL(portName string, scheme string, forceHttps bool, healthEndpoint string) (string, error) {
+	address := sw.getLoadBalancerAddress()
+	if len(address) == 0 {
+		return "", fmt.Errorf("service %s has no load balancer address", sw.Service.GetName())
+	}
+
+	port, err := sw.getPort(portName)
+	if err != nil {
+		return "", err
+	}
+
+	if len(scheme) == 0 {
+		scheme = getPortScheme(port)
+	}
+	if forceHttps && scheme == schemeHTTP {
+		scheme = schemeHTTPS
+	}
+
+	u := url.URL{Scheme: scheme, Host: net.JoinHostPort(address, strconv.Itoa(int(port.Port)))}
+	if scheme == schemeTCP {
+		return u.String(), nil
+	}
+
+	if len(healthEndpoint) != 0 {
+		u.Path = healthEndpoint
+		sw.HealthEndpoint = configuredHealthEndpoint(healthEndpoint)
+		return u.String(), nil
+	}
+	sw.HealthEndpoint = discoverHealthEndpoint(sw.Client, sw.Service.Namespace, sw.Service.Name, port.Name)
+	if len(sw.HealthEndpoint.Path) != 0 {
+		u.Path = path.Join("/", sw.HealthEndpoint.Path)
+	}
+	return u.String(), nil
+}
+
+// getLoadBalancerAddress returns the first IP or hostname of the load balancer of the Service
+func (sw *ServiceWrapper) getLoadBalancerAddress() string {
+	for _, ingress := range sw.Service.Status.LoadBalancer.Ingress {
+		if len(ingress.IP) != 0 {
+			return ingress.IP
+		}
+		if len(ingress.Hostname) != 0 {
+			return ingress.Hostname
+		}
+	}
+	return ""
+}
+
+func (sw *ServiceWrapper) getPort(portName string) (corev1.ServicePort, error) {
+	ports := sw.Service.Spec.Ports
+	if len(ports) == 0 {
+		return corev1.ServicePort{}, fmt.Errorf("service %s has no ports", sw.Service.GetName())
+	}
+	if len(portName) == 0 {
+		return ports[0], nil
+	}
+	for _, port := range ports {
+		if port.Name == portName || strconv.Itoa(int(port.Port)) == portName {
+			return port, nil
+		}
+	}
+	return corev1.ServicePort{}, fmt.Errorf("service %s has no port %s", sw.Service.GetName(), portName)
+}
+
+// getPortScheme derives the scheme of a port from its app protocol or its name, e.g. http-metrics
+func getPortScheme(port corev1.ServicePort) string {
+	for _, protocol := range []string{stringValue(port.AppProtocol), port.Name} {
+		protocol = strings.ToLower(protocol)
+		if strings.HasPrefix(protocol, schemeHTTPS) {
+			return schemeHTTPS
+		}
+		if strings.HasPrefix(protocol, schemeHTTP) {
+			return schemeHTTP
+		}
+	}
+	return schemeTCP
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
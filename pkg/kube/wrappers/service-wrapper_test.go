@@ -0,0 +1,95 @@
+package wrappers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func createLoadBalancerService(ingress []corev1.LoadBalancerIngress) *corev1.Service {
+	appProtocol := "https"
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "testService", Namespace: "test"},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{
+				{Name: "postgres", Port: 5432},
+				{Name: "http-metrics", Port: 9090},
+				{Name: "web", Port: 8443, AppProtocol: &appProtocol},
+			},
+		},
+		Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: ingress}},
+	}
+}
+
+func TestServiceWrapper_GetURL(t *testing.T) {
+	ipIngress := []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+	tests := []struct {
+		name           string
+		service        *corev1.Service
+		port           string
+		scheme         string
+		forceHttps     bool
+		healthEndpoint string
+		want           string
+		wantErr        bool
+	}{
+		{
+			name:    "TestGetUrlOfFirstPort",
+			service: createLoadBalancerService(ipIngress),
+			want:    "tcp://10.0.0.1:5432",
+		},
+		{
+			name:           "TestGetUrlFromPortName",
+			service:        createLoadBalancerService(ipIngress),
+			port:           "http-metrics",
+			healthEndpoint: "/metrics",
+			want:           "http://10.0.0.1:9090/metrics",
+		},
+		{
+			name:    "TestGetUrlFromAppProtocol",
+			service: createLoadBalancerService(ipIngress),
+			port:    "8443",
+			want:    "https://10.0.0.1:8443",
+		},
+		{
+			name:       "TestGetUrlWithForceHttps",
+			service:    createLoadBalancerService(ipIngress),
+			port:       "http-metrics",
+			forceHttps: true,
+			want:       "https://10.0.0.1:9090",
+		},
+		{
+			name:    "TestGetUrlWithScheme",
+			service: createLoadBalancerService([]corev1.LoadBalancerIngress{{Hostname: "lb.stackator.com"}}),
+			port:    "postgres",
+			scheme:  "http",
+			want:    "http://lb.stackator.com:5432",
+		},
+		{
+			name:    "TestGetUrlWithUnknownPort",
+			service: createLoadBalancerService(ipIngress),
+			port:    "redis",
+			wantErr: true,
+		},
+		{
+			name:    "TestGetUrlWithoutLoadBalancerAddress",
+			service: createLoadBalancerService(nil),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sw := NewServiceWrapper(tt.service, fakekubeclient.NewClientBuilder().Build())
+			got, err := sw.GetURL(tt.port, tt.scheme, tt.forceHttps, tt.healthEndpoint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Package metrics defines the Prometheus metrics of the controller, they are served by the metrics endpoint of the
+// controller-runtime manager
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "ingressmonitorcontroller"
+
+// Operations of the provider API calls
+const (
+	OperationList   = "list"
+	OperationGet    = "get"
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+	OperationVerify = "verify"
+)
+
+// Outcomes of a reconcile
+const (
+	// ReconcileSucceeded means the monitors are in sync with all providers
+	ReconcileSucceeded = "success"
+	// ReconcileFailed means the instance is not ready and won't be retried until it changes
+	ReconcileFailed = "failed"
+	// ReconcileErrored means the reconcile returned an error and is retried
+	ReconcileErrored = "error"
+)
+
+// StatusError is the status of provider API calls that didn't get a response
+const StatusError = "error"
+
+// checkLabels identify the remote check of a target of an EndpointMonitor at a provider
+var checkLabels = []string{"provider", "namespace", "endpointmonitor", "target"}
+
+var (
+	// ProviderRequests counts the calls of provider APIs by provider type, operation and HTTP status
+	ProviderRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_requests_total",
+		Help:      "Number of provider API calls by provider, operation and HTTP status",
+	}, []string{"provider", "operation", "status"})
+
+	// ProviderRequestDuration observes the latency of provider API calls by provider type and operation
+	ProviderRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "provider_request_duration_seconds",
+		Help:      "Latency of provider API calls by provider and operation",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"provider", "operation"})
+
+	// ManagedMonitors is the number of remote monitors managed by the EndpointMonitors by provider name
+	ManagedMonitors = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "managed_monitors",
+		Help:      "Number of monitors managed by EndpointMonitors by provider",
+	}, []string{"provider"})
+
+	// FailingMonitors is the number of remote monitors failing to sync by provider name
+	FailingMonitors = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "failing_monitors",
+		Help:      "Number of monitors failing to sync with their provider by provider",
+	}, []string{"provider"})
+
+	// OrphanedMonitors is the number of orphaned monitors found by the last garbage collection by provider name
+	OrphanedMonitors = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "orphaned_monitors",
+		Help:      "Number of monitors without EndpointMonitor found by the last garbage collection by provider",
+	}, []string{"provider"})
+
+	// CheckUp is 1 if the remote check of a target of an EndpointMonitor is up and 0 if it is down, it is only set
+	// by the status exporter
+	CheckUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "check_up",
+		Help:      "Whether the remote check is up (1) or down (0) by provider, EndpointMonitor and target",
+	}, checkLabels)
+
+	// CheckResponseTime is the response time of the last remote check of a target of an EndpointMonitor, it is only
+	// set by the status exporter
+	CheckResponseTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "check_response_time_seconds",
+		Help:      "Response time of the last remote check by provider, EndpointMonitor and target",
+	}, checkLabels)
+
+	// CheckUptimeRatio is the uptime ratio of the remote check of a target of an EndpointMonitor, it is only set by
+	// the status exporter
+	CheckUptimeRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "check_uptime_ratio",
+		Help:      "Uptime ratio of the remote check reported by the provider by provider, EndpointMonitor and target",
+	}, checkLabels)
+
+	// ConfigDegraded is 1 while the configuration of the controller is invalid, entirely or for some providers, and 0
+	// otherwise
+	ConfigDegraded = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "config_degraded",
+		Help:      "Whether the configuration of the controller is invalid (1) or valid (0)",
+	})
+
+	// Reconciles counts the reconciles of EndpointMonitors by outcome
+	Reconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_total",
+		Help:      "Number of reconciles of EndpointMonitors by outcome",
+	}, []string{"outcome"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(ProviderRequests, ProviderRequestDuration, ManagedMonitors, FailingMonitors, OrphanedMonitors,
+		CheckUp, CheckResponseTime, CheckUptimeRatio, ConfigDegraded, Reconciles)
+}
+
+// ObserveProviderRequest records a call of a provider API started at the given time. The status code is the HTTP
+// status of the response, 0 if no response was received.
+func ObserveProviderRequest(provider string, operation string, statusCode int, start time.Time) {
+	status := StatusError
+	if statusCode != 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	ProviderRequests.WithLabelValues(provider, operation, status).Inc()
+	ProviderRequestDuration.WithLabelValues(provider, operation).Observe(time.Since(start).Seconds())
+}
+
+// ObserveProviderResponse records a call of a provider API started at the given time, the response is nil if none
+// was received
+func ObserveProviderResponse(provider string, operation string, response *http.Response, start time.Time) {
+	statusCode := 0
+	if response != nil {
+		statusCode = response.StatusCode
+	}
+	ObserveProviderRequest(provider, operation, statusCode, start)
+}
@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveProviderRequest(t *testing.T) {
+	ObserveProviderRequest("UptimeRobot", OperationCreate, 200, time.Now())
+	ObserveProviderRequest("UptimeRobot", OperationCreate, 200, time.Now())
+	ObserveProviderRequest("UptimeRobot", OperationCreate, 0, time.Now())
+
+	if count := testutil.ToFloat64(ProviderRequests.WithLabelValues("UptimeRobot", OperationCreate, "200")); count != 2 {
+		t.Errorf("Expected 2 successful requests, got %v", count)
+	}
+	if count := testutil.ToFloat64(ProviderRequests.WithLabelValues("UptimeRobot", OperationCreate, StatusError)); count != 1 {
+		t.Errorf("Expected 1 failed request, got %v", count)
+	}
+	if count := testutil.CollectAndCount(ProviderRequestDuration); count != 1 {
+		t.Errorf("Expected a single histogram, got %v", count)
+	}
+}
@@ -1,10 +1,20 @@
 package models
 
+import (
+	"time"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+)
+
 type Monitor struct {
 	URL    string
 	Name   string
 	ID     string
 	Config interface{}
+	// Check is the provider agnostic check, provider specific Config takes precedence over it
+	Check *endpointmonitorv1alpha1.CheckConfig
+	// BasicAuthPassword is the password of the basic authentication of the check, resolved from its secret
+	BasicAuthPassword string
 }
 
 func NewMonitor(monitorName string, id string, monitorUrl string, config interface{}) Monitor {
@@ -15,3 +25,21 @@ func NewMonitor(monitorName string, id string, monitorUrl string, config interfa
 		Config: config,
 	}
 }
+
+// States of a remote check reported by the providers
+const (
+	CheckStateUp      = "Up"
+	CheckStateDown    = "Down"
+	CheckStatePaused  = "Paused"
+	CheckStateUnknown = "Unknown"
+)
+
+// CheckStatus is the state of a remote check as reported by the provider
+type CheckStatus struct {
+	// State is one of CheckStateUp, CheckStateDown, CheckStatePaused or CheckStateUnknown
+	State string
+	// ResponseTime of the last check, nil if the provider doesn't report it
+	ResponseTime *time.Duration
+	// UptimeRatio between 0 and 1 over the period reported by the provider, nil if the provider doesn't report it
+	UptimeRatio *float64
+}
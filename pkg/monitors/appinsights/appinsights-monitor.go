@@ -6,13 +6,19 @@ import (
 	"encoding/xml"
 	"fmt"
 	"net/http"
-	"os"
+	"reflect"
+	"strconv"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/appinsights/mgmt/2015-05-01/insights"
 	insightsAlert "github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	providerhttp "github.com/stakater/IngressMonitorController/v2/pkg/http"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -24,6 +30,16 @@ const (
 	AppInsightsFrequencyDefaultValue    = 300
 )
 
+// providerType labels the metrics of the API calls
+const providerType = "AppInsights"
+
+// Operations of the alert rule API calls recorded in the metrics
+const (
+	operationCreateAlertRule = "create_alert_rule"
+	operationUpdateAlertRule = "update_alert_rule"
+	operationDeleteAlertRule = "delete_alert_rule"
+)
+
 var log = logf.Log.WithName("appinsights-monitor")
 
 // Configuration holds appinsights specific configuration
@@ -31,6 +47,8 @@ type Configuration struct {
 	isRetryEnabled     bool
 	expectedStatusCode int
 	frequency          int32
+	method             string
+	timeout            int
 }
 
 // AppinsightsMonitorService struct contains parameters required by appinsights go client
@@ -96,21 +114,39 @@ func NewWebTest() *WebTest {
 	return &w
 }
 
+// Equal compares the WebTest that would be sent to update the monitor with the WebTest on Azure
 func (monitor *AppinsightsMonitorService) Equal(oldMonitor models.Monitor, newMonitor models.Monitor) bool {
-	// TODO: Retrieve oldMonitor config and compare it here
-	return false
+	webtest, ok := oldMonitor.Config.(*insights.WebTest)
+	if !ok || webtest == nil {
+		return false
+	}
+
+	if !reflect.DeepEqual(getWebTestSettings(*webtest), getWebTestSettings(monitor.createWebTest(newMonitor))) {
+		log.Info(fmt.Sprintf("There are some new changes in %s monitor", newMonitor.Name))
+		return false
+	}
+	return true
+}
+
+// getAuthorizer returns the authorizer of the service principal, requesting its tokens with the given sender
+func getAuthorizer(clientConfig auth.ClientCredentialsConfig, sender adal.Sender) (autorest.Authorizer, error) {
+	token, err := clientConfig.ServicePrincipalToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SPT from client credentials: %w", err)
+	}
+	token.SetSender(sender)
+	return autorest.NewBearerAuthorizer(token), nil
 }
 
 // Setup method will initialize a appinsights's go client
-func (aiService *AppinsightsMonitorService) Setup(provider config.Provider) {
+func (aiService *AppinsightsMonitorService) Setup(provider config.Provider) error {
 
 	log.Info("AppInsights Monitor's Setup has been called. Initializing AppInsights Client..")
 
 	var azConfig AzureConfig
 	err := envconfig.Process("AZURE", &azConfig)
 	if err != nil {
-		log.Error(err, "Error fetching environment variable")
-		os.Exit(1)
+		return fmt.Errorf("error fetching environment variables: %w", err)
 	}
 
 	aiService.ctx = context.Background()
@@ -129,36 +165,47 @@ func (aiService *AppinsightsMonitorService) Setup(provider config.Provider) {
 	// initialize appinsights client
 	err = aiService.insightsClient.AddToUserAgent("appInsightsMonitor")
 	if err != nil {
-		log.Error(err, "Error adding UserAgent in AppInsights Client")
-		os.Exit(1)
+		return fmt.Errorf("error adding UserAgent in AppInsights Client: %w", err)
 	}
 
 	aiService.insightsClient = insights.NewWebTestsClient(azConfig.Subscription_ID)
 	if err != nil {
-		log.Error(err, "Error initializing AppInsights Client")
-		os.Exit(1)
+		return fmt.Errorf("error initializing AppInsights Client: %w", err)
 	}
 
-	aiService.insightsClient.Authorizer, err = clientConfig.Authorizer()
+	// Both clients and the token requests are sent with the same client, they share its rate limit, proxy and
+	// certificates
+	httpClient := providerhttp.NewClient(provider.HTTP)
+	authorizer, err := getAuthorizer(clientConfig, httpClient)
 	if err != nil {
-		log.Error(err, "Error initializing AppInsights Client")
-		os.Exit(1)
+		return fmt.Errorf("error initializing AppInsights Client: %w", err)
 	}
+	aiService.insightsClient.Authorizer = authorizer
+	aiService.insightsClient.Sender = httpClient
 
 	log.Info("AppInsights Insights Client has been initialized")
 
 	// initialize monitoring alertrule client only if Email Action or Webhook Action is specified.
 	if aiService.isAlertEnabled() {
 		aiService.alertrulesClient = insightsAlert.NewAlertRulesClient(azConfig.Subscription_ID)
-		aiService.alertrulesClient.Authorizer, err = clientConfig.Authorizer()
-		if err != nil {
-			log.Error(err, "Error initializing AppInsights Alertrules Client")
-			os.Exit(1)
-		}
+		aiService.alertrulesClient.Sender = httpClient
+		aiService.alertrulesClient.Authorizer = authorizer
 		log.Info("AppInsights Alertrules Client has been initialized")
 	}
 
 	log.Info("AppInsights Monitor has been initialized")
+	return nil
+}
+
+// VerifyCredentials checks the credentials by listing the WebTests of the component
+func (aiService *AppinsightsMonitorService) VerifyCredentials() error {
+	start := time.Now()
+	webtests, err := aiService.insightsClient.ListByComponent(aiService.ctx, aiService.name, aiService.resourceGroup)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationVerify, webtests.Response().Response.Response, start)
+	if err != nil {
+		return appInsightsError(webtests.Response().Response.Response, fmt.Sprintf("Error listing Application Insights WebTests (Resource Group %s)", aiService.resourceGroup), err)
+	}
+	return nil
 }
 
 // GetAll function will return all monitors (appinsights webtest) object in an array
@@ -169,7 +216,9 @@ func (aiService *AppinsightsMonitorService) GetAll() []models.Monitor {
 
 	var monitors []models.Monitor
 
+	start := time.Now()
 	webtests, err := aiService.insightsClient.ListByComponent(aiService.ctx, aiService.name, aiService.resourceGroup)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationList, webtests.Response().Response.Response, start)
 	if err != nil {
 		if webtests.Response().StatusCode == http.StatusNotFound {
 			return monitors
@@ -195,97 +244,115 @@ func (aiService *AppinsightsMonitorService) GetAll() []models.Monitor {
 func (aiService *AppinsightsMonitorService) GetByName(monitorName string) (*models.Monitor, error) {
 
 	log.Info("AppInsights Monitor's GetByName method has been called")
+	start := time.Now()
 	webtest, err := aiService.insightsClient.Get(aiService.ctx, aiService.resourceGroup, monitorName)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationGet, webtest.Response.Response, start)
 	if err != nil {
-		if webtest.Response.StatusCode == http.StatusNotFound {
-			return nil, fmt.Errorf("Application Insights WebTest %s was not found in Resource Group %s", monitorName, aiService.resourceGroup)
-		}
-		return nil, fmt.Errorf("Error retrieving Application Insights WebTests %s (Resource Group %s): %v", monitorName, aiService.resourceGroup, err)
+		// A missing WebTest is reported as not found, other failures by their status code
+		return nil, appInsightsError(webtest.Response.Response, fmt.Sprintf("Error retrieving Application Insights WebTests %s (Resource Group %s)", monitorName, aiService.resourceGroup), err)
 	}
 	return &models.Monitor{
-		Name: *webtest.Name,
-		URL:  getURL(*webtest.Configuration.WebTest),
-		ID:   *webtest.ID,
+		Name:   *webtest.Name,
+		URL:    getURL(*webtest.Configuration.WebTest),
+		ID:     *webtest.ID,
+		Config: &webtest,
 	}, nil
 
 }
 
 // Add function method will add a monitor
-func (aiService *AppinsightsMonitorService) Add(monitor models.Monitor) {
+func (aiService *AppinsightsMonitorService) Add(monitor models.Monitor) error {
 
 	log.Info("AppInsights Monitor's Add method has been called")
 	log.Info(fmt.Sprintf("Adding Application Insights WebTest '%s' from '%s'", monitor.Name, aiService.name))
 	webtest := aiService.createWebTest(monitor)
-	_, err := aiService.insightsClient.CreateOrUpdate(aiService.ctx, aiService.resourceGroup, monitor.Name, webtest)
+	start := time.Now()
+	r, err := aiService.insightsClient.CreateOrUpdate(aiService.ctx, aiService.resourceGroup, monitor.Name, webtest)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationCreate, r.Response.Response, start)
 	if err != nil {
 		log.Error(err, fmt.Sprintf("Error adding Application Insights WebTests %s (Resource Group %s): %v", monitor.Name, aiService.resourceGroup, err))
-	} else {
-		log.Info(fmt.Sprintf("Successfully added Application Insights WebTest %s (Resource Group %s)", monitor.Name, aiService.resourceGroup))
-		if aiService.isAlertEnabled() {
-			log.Info(fmt.Sprintf("Adding alert rule for WebTest '%s' from '%s'", monitor.Name, aiService.name))
-			alertName := fmt.Sprintf("%s-alert", monitor.Name)
-			webtestAlert := aiService.createAlertRuleResource(monitor)
-			_, err := aiService.alertrulesClient.CreateOrUpdate(aiService.ctx, aiService.resourceGroup, alertName, webtestAlert)
-			if err != nil {
-				log.Error(err, fmt.Sprintf("Error adding alert rule for WebTests %s (Resource Group %s): %v", monitor.Name, aiService.resourceGroup, err))
-			}
-			log.Info(fmt.Sprintf("Successfully added Alert rule for WebTest %s (Resource Group %s)", monitor.Name, aiService.resourceGroup))
+		return appInsightsError(r.Response.Response, fmt.Sprintf("Error adding Application Insights WebTests %s (Resource Group %s)", monitor.Name, aiService.resourceGroup), err)
+	}
+	log.Info(fmt.Sprintf("Successfully added Application Insights WebTest %s (Resource Group %s)", monitor.Name, aiService.resourceGroup))
+	if aiService.isAlertEnabled() {
+		log.Info(fmt.Sprintf("Adding alert rule for WebTest '%s' from '%s'", monitor.Name, aiService.name))
+		alertName := fmt.Sprintf("%s-alert", monitor.Name)
+		webtestAlert := aiService.createAlertRuleResource(monitor)
+		start := time.Now()
+		r, err := aiService.alertrulesClient.CreateOrUpdate(aiService.ctx, aiService.resourceGroup, alertName, webtestAlert)
+		metrics.ObserveProviderResponse(providerType, operationCreateAlertRule, r.Response.Response, start)
+		if err != nil {
+			log.Error(err, fmt.Sprintf("Error adding alert rule for WebTests %s (Resource Group %s): %v", monitor.Name, aiService.resourceGroup, err))
+			return appInsightsError(r.Response.Response, fmt.Sprintf("Error adding alert rule for WebTests %s (Resource Group %s)", monitor.Name, aiService.resourceGroup), err)
 		}
+		log.Info(fmt.Sprintf("Successfully added Alert rule for WebTest %s (Resource Group %s)", monitor.Name, aiService.resourceGroup))
 	}
-
+	return nil
 }
 
 // Update method will update a monitor
-func (aiService *AppinsightsMonitorService) Update(monitor models.Monitor) {
+func (aiService *AppinsightsMonitorService) Update(monitor models.Monitor) error {
 
 	log.Info("AppInsights Monitor's Update method has been called")
 	log.Info(fmt.Sprintf("Updating Application Insights WebTest '%s' from '%s'", monitor.Name, aiService.name))
 
 	webtest := aiService.createWebTest(monitor)
-	_, err := aiService.insightsClient.CreateOrUpdate(aiService.ctx, aiService.resourceGroup, monitor.Name, webtest)
+	start := time.Now()
+	r, err := aiService.insightsClient.CreateOrUpdate(aiService.ctx, aiService.resourceGroup, monitor.Name, webtest)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationUpdate, r.Response.Response, start)
 	if err != nil {
 		log.Error(err, fmt.Sprintf("Error updating Application Insights WebTests %s (Resource Group %s): %v", monitor.Name, aiService.resourceGroup, err))
-	} else {
-		log.Info(fmt.Sprintf("Successfully updated Application Insights WebTest %s (Resource Group %s)", monitor.Name, aiService.resourceGroup))
-		if aiService.isAlertEnabled() {
-			log.Info(fmt.Sprintf("Updating alert rule for WebTest '%s' from '%s'", monitor.Name, aiService.name))
-			alertName := fmt.Sprintf("%s-alert", monitor.Name)
-			webtestAlert := aiService.createAlertRuleResource(monitor)
-			_, err := aiService.alertrulesClient.CreateOrUpdate(aiService.ctx, aiService.resourceGroup, alertName, webtestAlert)
-			if err != nil {
-				log.Error(err, fmt.Sprintf("Error updating alert rule for WebTests %s (Resource Group %s): %v", monitor.Name, aiService.resourceGroup, err))
-			}
-			log.Info(fmt.Sprintf("Successfully updating Alert rule for WebTest %s (Resource Group %s)", monitor.Name, aiService.resourceGroup))
+		return appInsightsError(r.Response.Response, fmt.Sprintf("Error updating Application Insights WebTests %s (Resource Group %s)", monitor.Name, aiService.resourceGroup), err)
+	}
+	log.Info(fmt.Sprintf("Successfully updated Application Insights WebTest %s (Resource Group %s)", monitor.Name, aiService.resourceGroup))
+	if aiService.isAlertEnabled() {
+		log.Info(fmt.Sprintf("Updating alert rule for WebTest '%s' from '%s'", monitor.Name, aiService.name))
+		alertName := fmt.Sprintf("%s-alert", monitor.Name)
+		webtestAlert := aiService.createAlertRuleResource(monitor)
+		start := time.Now()
+		r, err := aiService.alertrulesClient.CreateOrUpdate(aiService.ctx, aiService.resourceGroup, alertName, webtestAlert)
+		metrics.ObserveProviderResponse(providerType, operationUpdateAlertRule, r.Response.Response, start)
+		if err != nil {
+			log.Error(err, fmt.Sprintf("Error updating alert rule for WebTests %s (Resource Group %s): %v", monitor.Name, aiService.resourceGroup, err))
+			return appInsightsError(r.Response.Response, fmt.Sprintf("Error updating alert rule for WebTests %s (Resource Group %s)", monitor.Name, aiService.resourceGroup), err)
 		}
+		log.Info(fmt.Sprintf("Successfully updating Alert rule for WebTest %s (Resource Group %s)", monitor.Name, aiService.resourceGroup))
 	}
+	return nil
 }
 
 // Remove method will remove a monitor
-func (aiService *AppinsightsMonitorService) Remove(monitor models.Monitor) {
+func (aiService *AppinsightsMonitorService) Remove(monitor models.Monitor) error {
 
 	log.Info("AppInsights Monitor's Remove method has been called")
 	log.Info(fmt.Sprintf("Deleting Application Insights WebTest '%s' from '%s'", monitor.Name, aiService.name))
+	start := time.Now()
 	r, err := aiService.insightsClient.Delete(aiService.ctx, aiService.resourceGroup, monitor.Name)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationDelete, r.Response, start)
 	if err != nil {
-		if r.Response.StatusCode == http.StatusNotFound {
+		if r.Response != nil && r.Response.StatusCode == http.StatusNotFound {
 			log.Error(err, fmt.Sprintf("Application Insights WebTest %s was not found in Resource Group %s", monitor.Name, aiService.resourceGroup))
 		}
 		log.Error(err, fmt.Sprintf("Error deleting Application Insights WebTests %s (Resource Group %s): %v", monitor.Name, aiService.resourceGroup, err))
-	} else {
-		log.Info(fmt.Sprintf("Successfully removed Application Insights WebTest %s (Resource Group %s)", monitor.Name, aiService.resourceGroup))
-		if aiService.isAlertEnabled() {
-			log.Info(fmt.Sprintf("Deleting alert rule for WebTest '%s' from '%s'", monitor.Name, aiService.name))
-			alertName := fmt.Sprintf("%s-alert", monitor.Name)
-			r, err := aiService.alertrulesClient.Delete(aiService.ctx, aiService.resourceGroup, alertName)
-			if err != nil {
-				if r.Response.StatusCode == http.StatusNotFound {
-					log.Error(err, fmt.Sprintf("WebTest Alert rule %s was not found in Resource Group %s", alertName, aiService.resourceGroup))
-				}
-				log.Error(err, fmt.Sprintf("Error deleting alert rule for WebTests %s (Resource Group %s): %v", alertName, aiService.resourceGroup, err))
+		return appInsightsError(r.Response, fmt.Sprintf("Error deleting Application Insights WebTests %s (Resource Group %s)", monitor.Name, aiService.resourceGroup), err)
+	}
+	log.Info(fmt.Sprintf("Successfully removed Application Insights WebTest %s (Resource Group %s)", monitor.Name, aiService.resourceGroup))
+	if aiService.isAlertEnabled() {
+		log.Info(fmt.Sprintf("Deleting alert rule for WebTest '%s' from '%s'", monitor.Name, aiService.name))
+		alertName := fmt.Sprintf("%s-alert", monitor.Name)
+		start := time.Now()
+		r, err := aiService.alertrulesClient.Delete(aiService.ctx, aiService.resourceGroup, alertName)
+		metrics.ObserveProviderResponse(providerType, operationDeleteAlertRule, r.Response, start)
+		if err != nil {
+			if r.Response != nil && r.Response.StatusCode == http.StatusNotFound {
+				log.Error(err, fmt.Sprintf("WebTest Alert rule %s was not found in Resource Group %s", alertName, aiService.resourceGroup))
 			}
-			log.Info(fmt.Sprintf("Successfully removed Alert rule for WebTest %s (Resource Group %s)", monitor.Name, aiService.resourceGroup))
+			log.Error(err, fmt.Sprintf("Error deleting alert rule for WebTests %s (Resource Group %s): %v", alertName, aiService.resourceGroup, err))
+			return appInsightsError(r.Response, fmt.Sprintf("Error deleting alert rule for WebTests %s (Resource Group %s)", alertName, aiService.resourceGroup), err)
 		}
+		log.Info(fmt.Sprintf("Successfully removed Alert rule for WebTest %s (Resource Group %s)", monitor.Name, aiService.resourceGroup))
 	}
+	return nil
 }
 
 // createWebTest forms xml configuration for Appinsights WebTest
@@ -298,6 +365,13 @@ func (aiService *AppinsightsMonitorService) createWebTest(monitor models.Monitor
 	webtest.Description = fmt.Sprintf("%s webtest is created by Ingress Monitor controller", monitor.Name)
 	webtest.Items.Request.URL = monitor.URL
 	webtest.Items.Request.ExpectedHttpStatusCode = configs.expectedStatusCode
+	if len(configs.method) != 0 {
+		webtest.Items.Request.Method = configs.method
+	}
+	if configs.timeout > 0 {
+		webtest.Timeout = strconv.Itoa(configs.timeout)
+		webtest.Items.Request.Timeout = configs.timeout
+	}
 
 	xmlByte, err := xml.Marshal(webtest)
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/services/appinsights/mgmt/2015-05-01/insights"
@@ -245,3 +246,47 @@ func TestAppinsightsMonitorService_createAlertRuleResource(t *testing.T) {
 		})
 	}
 }
+
+func TestAppinsightsMonitorService_Equal(t *testing.T) {
+	aiService := &AppinsightsMonitorService{
+		name:           "foo-appinsights",
+		location:       "westeurope",
+		resourceGroup:  "demoRG",
+		geoLocation:    []interface{}{"us-tx-sn1-azr", "emea-nl-ams-azr"},
+		subscriptionID: "99cb99da-9cf9-9999-9999-9eacc5d36a65",
+	}
+	monitor := models.Monitor{
+		Name: "foo",
+		URL:  "https://microsoft.com",
+		Check: &endpointmonitorv1alpha1.CheckConfig{
+			ExpectedStatusCodes: []endpointmonitorv1alpha1.StatusCodeRange{"204"},
+		},
+	}
+
+	// Azure returns the locations in its own order and formats the XML differently
+	webtest := aiService.createWebTest(monitor)
+	locations := []insights.WebTestGeolocation{(*webtest.Locations)[1], (*webtest.Locations)[0]}
+	webtest.Locations = &locations
+	webTestConfig := strings.ReplaceAll(*webtest.Configuration.WebTest, "></Request>", " />")
+	webtest.Configuration = &insights.WebTestPropertiesConfiguration{WebTest: &webTestConfig}
+	oldMonitor := models.Monitor{Name: "foo", URL: "https://microsoft.com", Config: &webtest}
+
+	if !aiService.Equal(oldMonitor, monitor) {
+		t.Error("Monitor without changes should be equal")
+	}
+
+	monitor.Check.ExpectedStatusCodes = []endpointmonitorv1alpha1.StatusCodeRange{"200"}
+	if aiService.Equal(oldMonitor, monitor) {
+		t.Error("Monitor with a changed status code should not be equal")
+	}
+
+	monitor.Check.ExpectedStatusCodes = []endpointmonitorv1alpha1.StatusCodeRange{"204"}
+	monitor.Config = &endpointmonitorv1alpha1.AppInsightsConfig{Frequency: 900, RetryEnable: true}
+	if aiService.Equal(oldMonitor, monitor) {
+		t.Error("Monitor with a changed frequency should not be equal")
+	}
+
+	if aiService.Equal(models.Monitor{Name: "foo", URL: "https://microsoft.com"}, monitor) {
+		t.Error("Monitor without the full WebTest should not be equal")
+	}
+}
@@ -3,12 +3,19 @@ package appinsights
 import (
 	"encoding/xml"
 	"fmt"
+	"net/http"
+	"sort"
 
 	"github.com/Azure/azure-sdk-for-go/services/appinsights/mgmt/2015-05-01/insights"
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
+	"github.com/stakater/IngressMonitorController/v2/pkg/util"
 )
 
+// frequencies are the intervals in seconds supported by WebTests
+var frequencies = []int{300, 600, 900}
+
 // isAlertEnabled returns true if Alertrule is required
 func (aiService *AppinsightsMonitorService) isAlertEnabled() bool {
 	if aiService.emailToOwners || len(aiService.emailAction) != 0 || aiService.webhookAction != "" {
@@ -39,6 +46,54 @@ func (aiService *AppinsightsMonitorService) getTags(tagType string, name string)
 	return tags
 }
 
+// webTestSettings holds the settings of a WebTest managed by the controller in a comparable form
+type webTestSettings struct {
+	name         string
+	enabled      bool
+	retryEnabled bool
+	frequency    int32
+	locations    []string
+	webTest      WebTest
+}
+
+// getWebTestSettings returns the settings of the WebTest managed by the controller
+func getWebTestSettings(webtest insights.WebTest) webTestSettings {
+	var settings webTestSettings
+	if webtest.Name != nil {
+		settings.name = *webtest.Name
+	}
+
+	properties := webtest.WebTestProperties
+	if properties == nil {
+		return settings
+	}
+	if properties.Enabled != nil {
+		settings.enabled = *properties.Enabled
+	}
+	if properties.RetryEnabled != nil {
+		settings.retryEnabled = *properties.RetryEnabled
+	}
+	if properties.Frequency != nil {
+		settings.frequency = *properties.Frequency
+	}
+	if properties.Locations != nil {
+		for _, location := range *properties.Locations {
+			if location.Location != nil {
+				settings.locations = append(settings.locations, *location.Location)
+			}
+		}
+		sort.Strings(settings.locations)
+	}
+	if properties.Configuration != nil && properties.Configuration.WebTest != nil {
+		// Both WebTests are parsed so that differences in the formatting of the XML are ignored
+		err := xml.Unmarshal([]byte(*properties.Configuration.WebTest), &settings.webTest)
+		if err != nil {
+			log.Error(err, "Failed to parse XML configuration for WebTest")
+		}
+	}
+	return settings
+}
+
 func getURL(rawXmlData string) string {
 	var w WebTest
 	err := xml.Unmarshal([]byte(rawXmlData), &w)
@@ -48,6 +103,14 @@ func getURL(rawXmlData string) string {
 	return w.Items.Request.URL
 }
 
+// appInsightsError maps the response of a failed azure request to a monitor error
+func appInsightsError(response *http.Response, message string, err error) error {
+	if response == nil || response.StatusCode == 0 {
+		return monitorerrors.NewRetryable(message, err)
+	}
+	return monitorerrors.FromStatusCode(response.StatusCode, fmt.Sprintf("%s: %v", message, err))
+}
+
 // getGeolocation converts slice of locations into slice of location struct
 func getGeoLocation(locations []interface{}) *[]insights.WebTestGeolocation {
 	var geoLocations []insights.WebTestGeolocation
@@ -70,6 +133,9 @@ func getConfiguration(monitor models.Monitor) Configuration {
 	// ExpectedStatusCode is configurable via Config, Default value 200
 	if providerConfig != nil && providerConfig.StatusCode > 0 {
 		config.expectedStatusCode = providerConfig.StatusCode
+	} else if monitor.Check.HasExpectedStatusCodes() {
+		// WebTests only support a single expected status code
+		config.expectedStatusCode, _ = monitor.Check.ExpectedStatusCodes[0].Bounds()
 	} else {
 		config.expectedStatusCode = AppInsightsStatusCodeDefaultValue
 	}
@@ -82,11 +148,18 @@ func getConfiguration(monitor models.Monitor) Configuration {
 	}
 
 	// frequency is configurable via config, Default value 300
-	if providerConfig != nil && providerConfig.StatusCode > 0 {
-		config.frequency = int32(providerConfig.StatusCode)
+	if providerConfig != nil && providerConfig.Frequency > 0 {
+		config.frequency = int32(providerConfig.Frequency)
+	} else if monitor.Check.GetIntervalSeconds() > 0 {
+		config.frequency = int32(util.ClosestInt(frequencies, monitor.Check.GetIntervalSeconds()))
 	} else {
 		config.frequency = AppInsightsFrequencyDefaultValue
 	}
 
+	if monitor.Check != nil {
+		config.method = monitor.Check.Method
+		config.timeout = monitor.Check.GetTimeoutSeconds()
+	}
+
 	return config
 }
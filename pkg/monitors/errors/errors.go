@@ -0,0 +1,107 @@
+// Package errors defines the errors returned by monitor services when a request to a provider fails
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Reason describes why a request to a provider failed
+type Reason string
+
+const (
+	// ReasonRetryable means the request may succeed if it is retried later, e.g. on network errors or rate limiting
+	ReasonRetryable Reason = "Retryable"
+	// ReasonAuthFailure means the provider rejected the configured credentials
+	ReasonAuthFailure Reason = "AuthFailure"
+	// ReasonValidationFailed means the provider rejected the monitor configuration
+	ReasonValidationFailed Reason = "ValidationFailed"
+	// ReasonNotFound means the monitor does not exist at the provider
+	ReasonNotFound Reason = "NotFound"
+)
+
+// MonitorError is an error returned by a monitor service
+type MonitorError struct {
+	Reason  Reason
+	Message string
+	Err     error
+}
+
+func (e *MonitorError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *MonitorError) Unwrap() error {
+	return e.Err
+}
+
+// NewRetryable returns an error for a request that may succeed if it is retried
+func NewRetryable(message string, err error) error {
+	return &MonitorError{Reason: ReasonRetryable, Message: message, Err: err}
+}
+
+// NewAuthFailure returns an error for a request that was rejected because of invalid credentials
+func NewAuthFailure(message string, err error) error {
+	return &MonitorError{Reason: ReasonAuthFailure, Message: message, Err: err}
+}
+
+// NewValidationFailed returns an error for a request that was rejected because of an invalid monitor configuration
+func NewValidationFailed(message string, err error) error {
+	return &MonitorError{Reason: ReasonValidationFailed, Message: message, Err: err}
+}
+
+// NewNotFound returns an error for a request on a monitor that does not exist
+func NewNotFound(message string, err error) error {
+	return &MonitorError{Reason: ReasonNotFound, Message: message, Err: err}
+}
+
+// FromStatusCode returns an error matching the HTTP status code of a failed provider response
+func FromStatusCode(statusCode int, message string) error {
+	message = fmt.Sprintf("%s. Status Code: %d", message, statusCode)
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return NewAuthFailure(message, nil)
+	case statusCode == http.StatusNotFound:
+		return NewNotFound(message, nil)
+	case statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError:
+		return NewRetryable(message, nil)
+	case statusCode >= http.StatusBadRequest:
+		return NewValidationFailed(message, nil)
+	default:
+		return NewRetryable(message, nil)
+	}
+}
+
+// ReasonForError returns the reason of the given error. Errors not returned by a
+// monitor service, e.g. network errors, are considered retryable.
+func ReasonForError(err error) Reason {
+	var monitorError *MonitorError
+	if errors.As(err, &monitorError) {
+		return monitorError.Reason
+	}
+	return ReasonRetryable
+}
+
+// IsRetryable returns true if the request that returned the given error may succeed if it is retried
+func IsRetryable(err error) bool {
+	return err != nil && ReasonForError(err) == ReasonRetryable
+}
+
+// IsAuthFailure returns true if the given error was caused by invalid credentials
+func IsAuthFailure(err error) bool {
+	return err != nil && ReasonForError(err) == ReasonAuthFailure
+}
+
+// IsValidationFailed returns true if the given error was caused by an invalid monitor configuration
+func IsValidationFailed(err error) bool {
+	return err != nil && ReasonForError(err) == ReasonValidationFailed
+}
+
+// IsNotFound returns true if the given error was caused by a monitor that does not exist
+func IsNotFound(err error) bool {
+	return err != nil && ReasonForError(err) == ReasonNotFound
+}
@@ -3,46 +3,125 @@ package gcloud
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
+	"path"
 	"strconv"
+	"time"
 
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3"
+	"golang.org/x/oauth2"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 	monitoredres "google.golang.org/genproto/googleapis/api/monitoredres"
 	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	providerhttp "github.com/stakater/IngressMonitorController/v2/pkg/http"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
+	"github.com/stakater/IngressMonitorController/v2/pkg/util"
 )
 
 var log = logf.Log.WithName("gcloud-monitor")
 
+// providerType labels the metrics of the API calls
+const providerType = "gcloud"
+
+// periods are the check intervals in seconds supported by google cloud
+var periods = []int{60, 300, 600, 900}
+
 type MonitorService struct {
 	client    *monitoring.UptimeCheckClient
 	projectID string
 	ctx       context.Context
 }
 
+// Equal applies the monitor to a copy of the uptime check it was retrieved from, the same way Update does, and compares
+// the result with the uptime check
 func (monitor *MonitorService) Equal(oldMonitor models.Monitor, newMonitor models.Monitor) bool {
-	// TODO: Retrieve oldMonitor config and compare it here
-	return false
+	uptimeCheckConfig, ok := oldMonitor.Config.(*monitoringpb.UptimeCheckConfig)
+	if !ok || uptimeCheckConfig == nil {
+		return false
+	}
+
+	updatedUptimeCheckConfig := proto.Clone(uptimeCheckConfig).(*monitoringpb.UptimeCheckConfig)
+	if err := applyMonitor(updatedUptimeCheckConfig, newMonitor); err != nil {
+		// Let Update report the error
+		return false
+	}
+
+	// Passwords are not returned by google cloud
+	if authInfo := updatedUptimeCheckConfig.GetHttpCheck().GetAuthInfo(); authInfo != nil {
+		authInfo.Password = uptimeCheckConfig.GetHttpCheck().GetAuthInfo().GetPassword()
+	}
+
+	if !proto.Equal(uptimeCheckConfig, updatedUptimeCheckConfig) {
+		log.Info(fmt.Sprintf("There are some new changes in %s monitor", newMonitor.Name))
+		return false
+	}
+	return true
 }
 
-func (service *MonitorService) Setup(provider config.Provider) {
+func (service *MonitorService) Setup(provider config.Provider) error {
 	service.ctx = context.Background()
 
-	client, err := monitoring.NewUptimeCheckClient(service.ctx, option.WithCredentialsJSON([]byte(provider.ApiKey)))
-
+	// The calls are retried by the client, the transport only limits their rate and duration and connects them
+	// through its proxy
+	transport := providerhttp.NewTransport(provider.HTTP)
+	options := []option.ClientOption{
+		option.WithCredentialsJSON([]byte(provider.ApiKey)),
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(limitRequest(transport), observeRequest)),
+		option.WithGRPCDialOption(grpc.WithContextDialer(transport.DialContext)),
+	}
+	if tlsConfig := transport.TLSConfig(); tlsConfig != nil {
+		options = append(options, option.WithGRPCDialOption(grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))))
+	}
+	// The access tokens are requested with the transport as well
+	tokenContext := context.WithValue(service.ctx, oauth2.HTTPClient, &http.Client{Transport: transport})
+	client, err := monitoring.NewUptimeCheckClient(tokenContext, options...)
 	if err != nil {
-		log.Info("Error Seting Up Monitor Service: " + err.Error())
-	} else {
-		service.client = client
+		return fmt.Errorf("unable to create gcloud uptime check client: %w", err)
 	}
+	service.client = client
 	service.projectID = provider.GcloudConfig.ProjectID
+	return nil
+}
+
+// Close closes the gRPC connection of the uptime check client
+func (service *MonitorService) Close() error {
+	if service.client == nil {
+		return nil
+	}
+	return service.client.Close()
+}
+
+// VerifyCredentials checks the credentials by listing the uptime checks of the project
+func (service *MonitorService) VerifyCredentials() error {
+	_, err := service.client.ListUptimeCheckConfigs(service.ctx, &monitoringpb.ListUptimeCheckConfigsRequest{
+		Parent: "projects/" + service.projectID,
+	}).Next()
+	if err == nil || err == iterator.Done {
+		return nil
+	}
+	switch status.Code(err) {
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return monitorerrors.NewAuthFailure("Error listing uptime checks", err)
+	case codes.NotFound:
+		return monitorerrors.NewNotFound("Error listing uptime checks", err)
+	default:
+		return monitorerrors.NewRetryable("Error listing uptime checks", err)
+	}
 }
 
 func (service *MonitorService) GetByName(name string) (monitor *models.Monitor, err error) {
@@ -56,7 +135,7 @@ func (service *MonitorService) GetByName(name string) (monitor *models.Monitor,
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("Error Locating Monitor: %s", err.Error())
+			return nil, gcloudError("Error Locating Monitor", err)
 		}
 		if uptimeCheckConfig.DisplayName == name {
 			localMonitor := transformToMonitor(uptimeCheckConfig)
@@ -64,7 +143,7 @@ func (service *MonitorService) GetByName(name string) (monitor *models.Monitor,
 		}
 	}
 
-	return nil, fmt.Errorf("Unable to locate monitor with name %v", name)
+	return nil, monitorerrors.NewNotFound("Unable to locate monitor with name "+name, nil)
 }
 
 func (service *MonitorService) GetAll() (monitors []models.Monitor) {
@@ -88,30 +167,17 @@ func (service *MonitorService) GetAll() (monitors []models.Monitor) {
 	return monitors
 }
 
-func (service *MonitorService) Add(monitor models.Monitor) {
+func (service *MonitorService) Add(monitor models.Monitor) error {
 	url, err := url.Parse(monitor.URL)
 	if err != nil {
 		log.Info("Error Adding Monitor: " + err.Error())
-		return
+		return monitorerrors.NewValidationFailed("Invalid URL for monitor: "+monitor.Name, err)
 	}
 
-	portString := url.Port()
-	var port int
-	if portString == "" {
-		if url.Scheme == "http" {
-			port = 80
-		} else if url.Scheme == "https" {
-			port = 443
-		} else {
-			log.Info("Error Adding Monitor: unknown protocol " + url.Scheme)
-			return
-		}
-	} else {
-		port, err = strconv.Atoi(portString)
-		if err != nil {
-			log.Info("Error Adding Monitor: " + err.Error())
-			return
-		}
+	port, err := getPort(url)
+	if err != nil {
+		log.Info("Error Adding Monitor: " + err.Error())
+		return monitorerrors.NewValidationFailed("Invalid URL for monitor: "+monitor.Name, err)
 	}
 
 	projectID := service.projectID
@@ -120,101 +186,260 @@ func (service *MonitorService) Add(monitor models.Monitor) {
 		projectID = providerConfig.ProjectId
 	}
 
-	_, err = service.client.CreateUptimeCheckConfig(service.ctx, &monitoringpb.CreateUptimeCheckConfigRequest{
-		Parent: "projects/" + projectID,
-		UptimeCheckConfig: &monitoringpb.UptimeCheckConfig{
-			DisplayName: monitor.Name,
-			Resource: &monitoringpb.UptimeCheckConfig_MonitoredResource{
-				MonitoredResource: &monitoredres.MonitoredResource{
-					Type: "uptime_url",
-					Labels: map[string]string{
-						"host": url.Hostname(),
-					},
+	uptimeCheckConfig := &monitoringpb.UptimeCheckConfig{
+		DisplayName: monitor.Name,
+		Resource: &monitoringpb.UptimeCheckConfig_MonitoredResource{
+			MonitoredResource: &monitoredres.MonitoredResource{
+				Type: "uptime_url",
+				Labels: map[string]string{
+					"host": url.Hostname(),
 				},
 			},
-			CheckRequestType: &monitoringpb.UptimeCheckConfig_HttpCheck_{
-				HttpCheck: &monitoringpb.UptimeCheckConfig_HttpCheck{
-					Path:   url.Path,
-					Port:   int32(port),
-					UseSsl: url.Scheme == "https",
-				},
+		},
+		CheckRequestType: &monitoringpb.UptimeCheckConfig_HttpCheck_{
+			HttpCheck: &monitoringpb.UptimeCheckConfig_HttpCheck{
+				Path:   url.Path,
+				Port:   int32(port),
+				UseSsl: url.Scheme == "https",
 			},
 		},
+	}
+	applyCheck(uptimeCheckConfig, monitor.Check, monitor.BasicAuthPassword, port)
+
+	_, err = service.client.CreateUptimeCheckConfig(service.ctx, &monitoringpb.CreateUptimeCheckConfigRequest{
+		Parent:            "projects/" + projectID,
+		UptimeCheckConfig: uptimeCheckConfig,
 	})
 	if err != nil {
 		log.Info("Error Adding Monitor: " + err.Error())
-		return
+		return gcloudError("Error Adding Monitor: "+monitor.Name, err)
 	}
 
 	log.Info("Added monitor for: " + monitor.Name)
+	return nil
 }
 
-func (service *MonitorService) Update(monitor models.Monitor) {
+func (service *MonitorService) Update(monitor models.Monitor) error {
 	uptimeCheckConfig, err := service.client.GetUptimeCheckConfig(service.ctx, &monitoringpb.GetUptimeCheckConfigRequest{Name: monitor.ID})
 	if err != nil {
 		log.Info("Error updating Monitor: " + err.Error())
+		return gcloudError("Error updating Monitor: "+monitor.Name, err)
 	}
 
-	url, err := url.Parse(monitor.URL)
-	if err != nil {
-		log.Info("Error Adding Monitor: " + err.Error())
-		return
-	}
-
-	if uptimeCheckConfig.GetMonitoredResource().Labels["host"] != url.Hostname() {
-		log.Info("Error Adding Monitor: URL Host is immutable")
-		return
-	}
-
-	portString := url.Port()
-	var port int
-	if portString == "" {
-		if url.Scheme == "http" {
-			port = 80
-		} else if url.Scheme == "https" {
-			port = 443
-		} else {
-			log.Info("Error Adding Monitor: unknown protocol " + url.Scheme)
-			return
-		}
-	} else {
-		port, err = strconv.Atoi(portString)
-		if err != nil {
-			log.Info("Error Adding Monitor: " + err.Error())
-			return
-		}
+	if err = applyMonitor(uptimeCheckConfig, monitor); err != nil {
+		log.Info("Error updating Monitor: " + err.Error())
+		return err
 	}
 
-	uptimeCheckConfig.DisplayName = monitor.Name
-	uptimeCheckConfig.GetHttpCheck().Port = int32(port)
-	uptimeCheckConfig.GetHttpCheck().Path = url.Path
-
 	uptimeCheckConfig, err = service.client.UpdateUptimeCheckConfig(service.ctx, &monitoringpb.UpdateUptimeCheckConfigRequest{
 		UptimeCheckConfig: uptimeCheckConfig,
 	})
 	if err != nil {
 		log.Info("Error Adding Monitor: " + err.Error())
-		return
+		return gcloudError("Error updating Monitor: "+monitor.Name, err)
 	}
 
 	log.Info(fmt.Sprintf("Updated Monitor: %v", uptimeCheckConfig))
+	return nil
 }
 
-func (service *MonitorService) Remove(monitor models.Monitor) {
+func (service *MonitorService) Remove(monitor models.Monitor) error {
 	err := service.client.DeleteUptimeCheckConfig(service.ctx, &monitoringpb.DeleteUptimeCheckConfigRequest{
 		Name: monitor.ID,
 	})
 	if err != nil {
 		log.Info("Error deleting Monitor: " + err.Error())
-		return
+		return gcloudError("Error deleting Monitor: "+monitor.Name, err)
 	}
 	log.Info("Deleted Monitor: " + monitor.Name)
+	return nil
+}
+
+// applyMonitor maps the monitor onto an existing uptime check config
+func applyMonitor(uptimeCheckConfig *monitoringpb.UptimeCheckConfig, monitor models.Monitor) error {
+	url, err := url.Parse(monitor.URL)
+	if err != nil {
+		return monitorerrors.NewValidationFailed("Invalid URL for monitor: "+monitor.Name, err)
+	}
+
+	if uptimeCheckConfig.GetMonitoredResource().Labels["host"] != url.Hostname() {
+		return monitorerrors.NewValidationFailed("URL Host is immutable for monitor: "+monitor.Name, nil)
+	}
+
+	port, err := getPort(url)
+	if err != nil {
+		return monitorerrors.NewValidationFailed("Invalid URL for monitor: "+monitor.Name, err)
+	}
+
+	uptimeCheckConfig.DisplayName = monitor.Name
+	if httpCheck := uptimeCheckConfig.GetHttpCheck(); httpCheck != nil {
+		httpCheck.Port = int32(port)
+		httpCheck.Path = url.Path
+	}
+	applyCheck(uptimeCheckConfig, monitor.Check, monitor.BasicAuthPassword, port)
+	return nil
+}
+
+// applyCheck maps the provider agnostic check onto the uptime check config
+func applyCheck(uptimeCheckConfig *monitoringpb.UptimeCheckConfig, check *endpointmonitorv1alpha1.CheckConfig, basicAuthPassword string, port int) {
+	if check == nil {
+		return
+	}
+
+	if check.GetType() == endpointmonitorv1alpha1.CheckTypeTCP {
+		uptimeCheckConfig.CheckRequestType = &monitoringpb.UptimeCheckConfig_TcpCheck_{
+			TcpCheck: &monitoringpb.UptimeCheckConfig_TcpCheck{
+				Port: int32(port),
+			},
+		}
+	} else if httpCheck := uptimeCheckConfig.GetHttpCheck(); httpCheck != nil {
+		switch check.Method {
+		case "":
+		case http.MethodGet:
+			httpCheck.RequestMethod = monitoringpb.UptimeCheckConfig_HttpCheck_GET
+		case http.MethodPost:
+			httpCheck.RequestMethod = monitoringpb.UptimeCheckConfig_HttpCheck_POST
+		default:
+			log.Info("HTTP method " + check.Method + " is not supported by gcloud, ignoring it")
+		}
+
+		if len(check.Headers) != 0 {
+			httpCheck.Headers = check.Headers
+		}
+
+		if check.BasicAuth != nil {
+			if basicAuthPassword != "" {
+				httpCheck.AuthInfo = &monitoringpb.UptimeCheckConfig_HttpCheck_BasicAuthentication{
+					Username: check.BasicAuth.Username,
+					Password: basicAuthPassword,
+				}
+			} else {
+				log.Info("Basic auth password is empty, ignoring basic auth")
+			}
+		}
+	}
+
+	if check.GetIntervalSeconds() > 0 {
+		uptimeCheckConfig.Period = durationpb.New(time.Duration(util.ClosestInt(periods, check.GetIntervalSeconds())) * time.Second)
+	}
+	if check.Timeout != nil {
+		uptimeCheckConfig.Timeout = durationpb.New(check.Timeout.Duration)
+	}
+
+	if check.GetType() == endpointmonitorv1alpha1.CheckTypeKeyword && check.Keyword != nil {
+		matcher := monitoringpb.UptimeCheckConfig_ContentMatcher_CONTAINS_STRING
+		if check.Keyword.IsKeywordAbsent() {
+			matcher = monitoringpb.UptimeCheckConfig_ContentMatcher_NOT_CONTAINS_STRING
+		}
+		uptimeCheckConfig.ContentMatchers = []*monitoringpb.UptimeCheckConfig_ContentMatcher{
+			{
+				Content: check.Keyword.Value,
+				Matcher: matcher,
+			},
+		}
+	}
+}
+
+// getPort returns the port of the given URL, falling back to the default port of its scheme
+func getPort(url *url.URL) (int, error) {
+	portString := url.Port()
+	if portString != "" {
+		return strconv.Atoi(portString)
+	}
+	switch url.Scheme {
+	case "http":
+		return 80, nil
+	case "https":
+		return 443, nil
+	default:
+		return 0, fmt.Errorf("unknown protocol %s", url.Scheme)
+	}
+}
+
+// operations maps the methods of the uptime check service to the operations recorded in the metrics
+var operations = map[string]string{
+	"ListUptimeCheckConfigs":  metrics.OperationList,
+	"GetUptimeCheckConfig":    metrics.OperationGet,
+	"CreateUptimeCheckConfig": metrics.OperationCreate,
+	"UpdateUptimeCheckConfig": metrics.OperationUpdate,
+	"DeleteUptimeCheckConfig": metrics.OperationDelete,
+}
+
+// limitRequest returns an interceptor waiting for the rate limit of the transport before every call and applying its
+// timeout to the call
+func limitRequest(transport *providerhttp.Transport) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := transport.Wait(ctx); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		ctx, cancel := context.WithTimeout(ctx, transport.Timeout())
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// observeRequest records the calls of the google cloud API in the metrics, gRPC codes are mapped to HTTP statuses
+func observeRequest(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	operation, exists := operations[path.Base(method)]
+	if !exists {
+		operation = path.Base(method)
+	}
+	metrics.ObserveProviderRequest(providerType, operation, httpStatusFromCode(status.Code(err)), start)
+	return err
+}
+
+// httpStatusFromCode returns the HTTP status corresponding to a gRPC code
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// gcloudError maps an error returned by the google cloud client to a monitor error
+func gcloudError(message string, err error) error {
+	switch status.Code(err) {
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return monitorerrors.NewAuthFailure(message, err)
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.AlreadyExists, codes.OutOfRange:
+		return monitorerrors.NewValidationFailed(message, err)
+	case codes.NotFound:
+		return monitorerrors.NewNotFound(message, err)
+	default:
+		return monitorerrors.NewRetryable(message, err)
+	}
 }
 
 func transformToMonitor(uptimeCheckConfig *monitoringpb.UptimeCheckConfig) (monitor models.Monitor) {
-	isSsl := uptimeCheckConfig.GetHttpCheck().UseSsl
-	path := uptimeCheckConfig.GetHttpCheck().Path
-	port := uptimeCheckConfig.GetHttpCheck().Port
+	isSsl := uptimeCheckConfig.GetHttpCheck().GetUseSsl()
+	path := uptimeCheckConfig.GetHttpCheck().GetPath()
+	port := uptimeCheckConfig.GetHttpCheck().GetPort()
+	if tcpCheck := uptimeCheckConfig.GetTcpCheck(); tcpCheck != nil {
+		port = tcpCheck.GetPort()
+	}
 	host := uptimeCheckConfig.GetMonitoredResource().Labels["host"]
 
 	var scheme string
@@ -235,8 +460,9 @@ func transformToMonitor(uptimeCheckConfig *monitoringpb.UptimeCheckConfig) (moni
 	}
 
 	return models.Monitor{
-		URL:  url.String(),
-		Name: uptimeCheckConfig.DisplayName,
-		ID:   uptimeCheckConfig.Name,
+		URL:    url.String(),
+		Name:   uptimeCheckConfig.DisplayName,
+		ID:     uptimeCheckConfig.Name,
+		Config: uptimeCheckConfig,
 	}
 }
@@ -1,5 +1,23 @@
 package gcloud
 
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
+	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+	monitoredres "google.golang.org/genproto/googleapis/api/monitoredres"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 /*import (
 	"testing"
 
@@ -99,3 +117,70 @@ func TestUpdateMonitorWithCorrectValues(t *testing.T) {
 	}
 }
 */
+
+func TestEqual(t *testing.T) {
+	uptimeCheckConfig := &monitoringpb.UptimeCheckConfig{
+		Name:        "projects/test/uptimeCheckConfigs/google-test",
+		DisplayName: "google-test",
+		Resource: &monitoringpb.UptimeCheckConfig_MonitoredResource{
+			MonitoredResource: &monitoredres.MonitoredResource{
+				Type:   "uptime_url",
+				Labels: map[string]string{"host": "google.com"},
+			},
+		},
+		CheckRequestType: &monitoringpb.UptimeCheckConfig_HttpCheck_{
+			HttpCheck: &monitoringpb.UptimeCheckConfig_HttpCheck{
+				Path:   "/health",
+				Port:   443,
+				UseSsl: true,
+			},
+		},
+		Period:  durationpb.New(5 * time.Minute),
+		Timeout: durationpb.New(10 * time.Second),
+	}
+	oldMonitor := transformToMonitor(uptimeCheckConfig)
+	if oldMonitor.URL != "https://google.com/health" {
+		t.Errorf("Unexpected URL %s", oldMonitor.URL)
+	}
+
+	service := MonitorService{}
+	newMonitor := models.Monitor{
+		Name: "google-test",
+		URL:  "https://google.com/health",
+		ID:   oldMonitor.ID,
+		Check: &endpointmonitorv1alpha1.CheckConfig{
+			Interval: &metav1.Duration{Duration: 5 * time.Minute},
+		},
+	}
+	if !service.Equal(oldMonitor, newMonitor) {
+		t.Error("Monitor without changes should be equal")
+	}
+
+	newMonitor.URL = "https://google.com/ready"
+	if service.Equal(oldMonitor, newMonitor) {
+		t.Error("Monitor with a changed path should not be equal")
+	}
+
+	newMonitor.URL = "https://google.com/health"
+	newMonitor.Check.Interval = &metav1.Duration{Duration: time.Minute}
+	if service.Equal(oldMonitor, newMonitor) {
+		t.Error("Monitor with a changed interval should not be equal")
+	}
+
+	if uptimeCheckConfig.Period.AsDuration() != 5*time.Minute {
+		t.Error("Equal should not modify the uptime check config")
+	}
+}
+
+func TestObserveRequest(t *testing.T) {
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.NotFound, "not found")
+	}
+	err := observeRequest(context.Background(), "/google.monitoring.v3.UptimeCheckService/GetUptimeCheckConfig", nil, nil, nil, invoker)
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected the error of the call, got %v", err)
+	}
+	if count := testutil.ToFloat64(metrics.ProviderRequests.WithLabelValues(providerType, metrics.OperationGet, "404")); count != 1 {
+		t.Errorf("Expected the call to be recorded once, got %v", count)
+	}
+}
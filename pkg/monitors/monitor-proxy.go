@@ -1,6 +1,8 @@
 package monitors
 
 import (
+	"fmt"
+
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
@@ -17,15 +19,31 @@ import (
 var log = logf.Log.WithName("monitors")
 
 type MonitorServiceProxy struct {
+	name        string
 	monitorType string
 	monitor     MonitorService
 }
 
+// GetName returns the name of the provider instance, which defaults to its type
+func (mp *MonitorServiceProxy) GetName() string {
+	if len(mp.name) == 0 {
+		return mp.monitorType
+	}
+	return mp.name
+}
+
 func (mp *MonitorServiceProxy) GetType() string {
 	return mp.monitorType
 }
 
-func (mp *MonitorServiceProxy) OfType(mType string) MonitorServiceProxy {
+// NewMonitorServiceProxy returns a proxy for the given monitor service of a provider, e.g. an implementation that
+// doesn't call the API of a provider in tests
+func NewMonitorServiceProxy(name string, monitorType string, monitor MonitorService) MonitorServiceProxy {
+	return MonitorServiceProxy{name: name, monitorType: monitorType, monitor: monitor}
+}
+
+// OfType returns a proxy for the provider of the given type or an error if the type is unknown
+func (mp *MonitorServiceProxy) OfType(mType string) (MonitorServiceProxy, error) {
 	mp.monitorType = mType
 	switch mType {
 	case "UptimeRobot":
@@ -43,9 +61,9 @@ func (mp *MonitorServiceProxy) OfType(mType string) MonitorServiceProxy {
 	case "gcloud":
 		mp.monitor = &gcloud.MonitorService{}
 	default:
-		panic("No such provider found: " + mType)
+		return *mp, fmt.Errorf("no such provider found: %s", mType)
 	}
-	return *mp
+	return *mp, nil
 }
 
 func (mp *MonitorServiceProxy) ExtractConfig(spec endpointmonitorv1alpha1.EndpointMonitorSpec) interface{} {
@@ -72,8 +90,8 @@ func (mp *MonitorServiceProxy) ExtractConfig(spec endpointmonitorv1alpha1.Endpoi
 	return config
 }
 
-func (mp *MonitorServiceProxy) Setup(p config.Provider) {
-	mp.monitor.Setup(p)
+func (mp *MonitorServiceProxy) Setup(p config.Provider) error {
+	return mp.monitor.Setup(p)
 }
 
 func (mp *MonitorServiceProxy) GetAll() []models.Monitor {
@@ -84,18 +102,56 @@ func (mp *MonitorServiceProxy) GetByName(name string) (*models.Monitor, error) {
 	return mp.monitor.GetByName(name)
 }
 
-func (mp *MonitorServiceProxy) Add(m models.Monitor) {
-	mp.monitor.Add(m)
+// GetByID returns the monitor with the given ID, or ErrGetByIDNotSupported if the provider doesn't support it
+func (mp *MonitorServiceProxy) GetByID(id string) (*models.Monitor, error) {
+	if getter, ok := mp.monitor.(MonitorGetter); ok {
+		return getter.GetByID(id)
+	}
+	return nil, ErrGetByIDNotSupported
+}
+
+func (mp *MonitorServiceProxy) Add(m models.Monitor) error {
+	return mp.monitor.Add(m)
 }
 
 func (mp *MonitorServiceProxy) Equal(oldMonitor models.Monitor, newMonitor models.Monitor) bool {
 	return mp.monitor.Equal(oldMonitor, newMonitor)
 }
 
-func (mp *MonitorServiceProxy) Update(m models.Monitor) {
-	mp.monitor.Update(m)
+func (mp *MonitorServiceProxy) Update(m models.Monitor) error {
+	return mp.monitor.Update(m)
+}
+
+func (mp *MonitorServiceProxy) Remove(m models.Monitor) error {
+	return mp.monitor.Remove(m)
+}
+
+// VerifyCredentials checks the credentials of the provider if it supports it
+func (mp *MonitorServiceProxy) VerifyCredentials() error {
+	if verifier, ok := mp.monitor.(CredentialsVerifier); ok {
+		return verifier.VerifyCredentials()
+	}
+	return nil
+}
+
+// Close closes the connections of the provider if it holds any
+func (mp *MonitorServiceProxy) Close() error {
+	if closer, ok := mp.monitor.(Closer); ok {
+		return closer.Close()
+	}
+	return nil
 }
 
-func (mp *MonitorServiceProxy) Remove(m models.Monitor) {
-	mp.monitor.Remove(m)
+// SupportsCheckStatus returns true if the provider reports the state of its checks
+func (mp *MonitorServiceProxy) SupportsCheckStatus() bool {
+	_, ok := mp.monitor.(CheckStatusReporter)
+	return ok
+}
+
+// GetCheckStatus returns the state of the check of the monitor, or nil if the provider doesn't report it
+func (mp *MonitorServiceProxy) GetCheckStatus(m models.Monitor) (*models.CheckStatus, error) {
+	if reporter, ok := mp.monitor.(CheckStatusReporter); ok {
+		return reporter.GetCheckStatus(m)
+	}
+	return nil, nil
 }
@@ -2,13 +2,18 @@ package monitors
 
 import (
 	"testing"
+	"time"
 
-	"github.com/stakater/IngressMonitorController/v2/pkg/util"
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestMonitorServiceProxyOfTypeWithCorrectType(t *testing.T) {
 	monitorType := "UptimeRobot"
-	uptime := (&MonitorServiceProxy{}).OfType(monitorType)
+	uptime, err := (&MonitorServiceProxy{}).OfType(monitorType)
+	if err != nil {
+		t.Error("Unexpected error: " + err.Error())
+	}
 
 	if uptime.monitorType != monitorType {
 		t.Error("Monitor type is not the same")
@@ -16,12 +21,225 @@ func TestMonitorServiceProxyOfTypeWithCorrectType(t *testing.T) {
 }
 
 func TestMonitorServiceProxyOfTypeWithWrongType(t *testing.T) {
-	util.AssertPanic(t, func() {
-		monitorType := "Testing"
-		uptime := (&MonitorServiceProxy{}).OfType(monitorType)
+	monitorType := "Testing"
+	_, err := (&MonitorServiceProxy{}).OfType(monitorType)
+
+	if err == nil {
+		t.Error("Expected an error for an unknown monitor type")
+	}
+}
+
+func TestSetupMonitorServicesForProvidersWithNamedInstances(t *testing.T) {
+	providers := []config.Provider{
+		{Name: "uptimerobot-prod", Type: "UptimeRobot"},
+		{Name: "uptimerobot-staging", Type: "UptimeRobot"},
+		{Name: "StatusCake"},
+	}
+	monitorServices, err := SetupMonitorServicesForProviders(providers)
+	if err != nil {
+		t.Error("Unexpected error: " + err.Error())
+	}
 
-		if uptime.monitorType != monitorType {
-			t.Error("Monitor type is not the same")
+	expected := []struct{ name, monitorType string }{
+		{"uptimerobot-prod", "UptimeRobot"},
+		{"uptimerobot-staging", "UptimeRobot"},
+		{"StatusCake", "StatusCake"},
+	}
+	if len(monitorServices) != len(expected) {
+		t.Fatalf("Expected %d monitor services, got %d", len(expected), len(monitorServices))
+	}
+	for index, monitorService := range monitorServices {
+		if monitorService.GetName() != expected[index].name || monitorService.GetType() != expected[index].monitorType {
+			t.Errorf("Expected provider %s of type %s, got %s of type %s", expected[index].name, expected[index].monitorType,
+				monitorService.GetName(), monitorService.GetType())
 		}
+	}
+}
+
+func TestSetupMonitorServicesForProvidersSkipsInvalidProviders(t *testing.T) {
+	monitorServices, err := SetupMonitorServicesForProviders([]config.Provider{
+		{Name: "uptimerobot", Type: "UptimeRobot"},
+		{Name: "uptimerobot", Type: "UptimeRobot"},
+		{Name: "typo", Type: "UptimeRobott"},
+		{Name: "StatusCake"},
 	})
+
+	if err == nil {
+		t.Error("Expected an error for the duplicate and unknown providers")
+	}
+	if len(monitorServices) != 2 || monitorServices[0].GetName() != "uptimerobot" || monitorServices[1].GetName() != "StatusCake" {
+		t.Error("Expected the valid providers to be set up")
+	}
+}
+
+func TestGetInvalidProviders(t *testing.T) {
+	providers := []config.Provider{
+		{Name: "uptimerobot", Type: "UptimeRobot"},
+		{Name: "uptimerobot", Type: "UptimeRobot"},
+		{Name: "typo", Type: "UptimeRobott"},
+		{Name: "StatusCake"},
+	}
+	monitorServices, _ := SetupMonitorServicesForProviders(providers)
+
+	invalid := GetInvalidProviders(providers, monitorServices)
+	if len(invalid) != 2 || invalid[0] != "uptimerobot" || invalid[1] != "typo" {
+		t.Errorf("Expected the duplicate and unknown providers to be invalid, got %v", invalid)
+	}
+}
+
+func TestSetupMonitorServicesForProvidersWithoutProviders(t *testing.T) {
+	_, err := SetupMonitorServicesForProviders([]config.Provider{})
+
+	if err == nil {
+		t.Error("Expected an error without providers")
+	}
+}
+
+func TestMonitorServiceCacheRebuildsServicesOnChange(t *testing.T) {
+	cache := &MonitorServiceCache{}
+	key := types.NamespacedName{Namespace: "team", Name: "credentials"}
+
+	data := "providers:\n  - name: team-uptimerobot\n    type: UptimeRobot\n    apiKey: abc\n"
+	services, err := cache.Get(key, data)
+	if err != nil {
+		t.Fatal("Unexpected error: " + err.Error())
+	}
+	if len(services) != 1 || services[0].GetName() != "team-uptimerobot" {
+		t.Error("Expected the providers of the secret")
+	}
+
+	changed := data + "  - name: team-statuscake\n    type: StatusCake\n"
+	services, err = cache.Get(key, changed)
+	if err != nil {
+		t.Fatal("Unexpected error: " + err.Error())
+	}
+	if len(services) != 2 {
+		t.Error("Expected the services to be rebuilt after the secret changed")
+	}
+
+	if _, err = cache.Get(key, "providers:\n  - name: typo\n    type: UptimeRobott\n"); err == nil {
+		t.Error("Expected an error for invalid providers")
+	}
+	if cached, ok := cache.GetCached(key); !ok || len(cached) != 2 {
+		t.Error("Expected the last valid services to be cached")
+	}
+}
+
+func TestMonitorServiceCacheRestrictsEndpointsOfCredentialsSecrets(t *testing.T) {
+	controllerConfig := config.Config{Providers: []config.Provider{
+		{Name: "UptimeRobot", ApiKey: "controller", ApiURL: "https://api.uptimerobot.com/v2/"},
+	}}
+	config.SetControllerConfig(controllerConfig)
+	defer config.SetControllerConfig(config.Config{})
+	cache := &MonitorServiceCache{}
+	key := types.NamespacedName{Namespace: "team", Name: "credentials"}
+
+	for name, data := range map[string]string{
+		"proxy":    "providers:\n  - name: UptimeRobot\n    apiKey: abc\n    http:\n      proxy: http://proxy.team:3128\n",
+		"no proxy": "providers:\n  - name: UptimeRobot\n    apiKey: abc\n    http:\n      noProxy: [api.uptimerobot.com]\n",
+		"apiURL":   "providers:\n  - name: UptimeRobot\n    apiKey: abc\n    apiURL: https://collector.team/v2/\n",
+		"type":     "providers:\n  - name: Pingdom\n    apiToken: abc\n    apiURL: https://api.uptimerobot.com/v2/\n",
+	} {
+		if _, err := cache.Get(key, data); err == nil {
+			t.Errorf("Expected the %s of the credentials secret to be rejected", name)
+		}
+	}
+
+	// The API URLs of the controller can be used
+	data := "providers:\n  - name: UptimeRobot\n    apiKey: abc\n  - name: team\n    type: UptimeRobot\n    apiKey: def\n    apiURL: https://api.uptimerobot.com/v2/\n"
+	if services, err := cache.Get(key, data); err != nil || len(services) != 2 {
+		t.Errorf("Expected the API URLs of the controller to be accepted, got %v", err)
+	}
+
+	controllerConfig.CredentialsSecrets.AllowEndpointOverrides = true
+	config.SetControllerConfig(controllerConfig)
+	data = "providers:\n  - name: UptimeRobot\n    apiKey: abc\n    apiURL: https://collector.team/v2/\n    http:\n      proxy: http://proxy.team:3128\n"
+	if _, err := cache.Get(key, data); err != nil {
+		t.Errorf("Expected overrides to be allowed, got %v", err)
+	}
+}
+
+func TestMonitorServiceRegistryPrefersProviderConfigServices(t *testing.T) {
+	registry := &MonitorServiceRegistry{}
+	secretServices, err := SetupMonitorServicesForProviders([]config.Provider{
+		{Name: "uptimerobot", Type: "UptimeRobot"},
+		{Name: "statuscake", Type: "StatusCake"},
+		{Name: "updown", Type: "Updown"},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error: " + err.Error())
+	}
+	registry.Set(secretServices)
+
+	providerConfigServices, err := SetupMonitorServicesForProviders([]config.Provider{
+		{Name: "uptimerobot", Type: "Pingdom"},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error: " + err.Error())
+	}
+	registry.SetProviderConfigServices(providerConfigServices, []string{"statuscake"})
+
+	expected := []struct{ name, monitorType string }{
+		{"uptimerobot", "Pingdom"},
+		{"updown", "Updown"},
+	}
+	monitorServices := registry.Get()
+	if len(monitorServices) != len(expected) {
+		t.Fatalf("Expected %d monitor services, got %d", len(expected), len(monitorServices))
+	}
+	for index, monitorService := range monitorServices {
+		if monitorService.GetName() != expected[index].name || monitorService.GetType() != expected[index].monitorType {
+			t.Errorf("Expected provider %s of type %s, got %s of type %s", expected[index].name, expected[index].monitorType,
+				monitorService.GetName(), monitorService.GetType())
+		}
+	}
+	if !registry.IsUnavailable("statuscake") {
+		t.Error("Expected provider statuscake to be unavailable")
+	}
+}
+
+func TestMonitorServiceRegistryWithUnavailableProviderConfigs(t *testing.T) {
+	registry := &MonitorServiceRegistry{}
+	secretServices, err := SetupMonitorServicesForProviders([]config.Provider{{Name: "uptimerobot", Type: "UptimeRobot"}})
+	if err != nil {
+		t.Fatal("Unexpected error: " + err.Error())
+	}
+	registry.Set(secretServices)
+
+	registry.SetProviderConfigsUnavailable()
+	if len(registry.Get()) != 0 || !registry.IsUnavailable("uptimerobot") || !registry.IsUnavailable("statuscake") {
+		t.Error("Expected all providers to be unavailable while the ProviderConfigs are unknown")
+	}
+
+	registry.SetProviderConfigServices(nil, nil)
+	if len(registry.Get()) != 1 || registry.IsUnavailable("uptimerobot") {
+		t.Error("Expected the providers of the config secret to be available once the ProviderConfigs are known")
+	}
+}
+
+// closingMonitorService records whether its connections have been closed
+type closingMonitorService struct {
+	MonitorService
+	closed chan struct{}
+}
+
+func (s *closingMonitorService) Close() error {
+	close(s.closed)
+	return nil
+}
+
+func TestMonitorServiceRegistryClosesReplacedServices(t *testing.T) {
+	closeDelay = time.Millisecond
+	defer func() { closeDelay = 5 * time.Minute }()
+
+	replaced := &closingMonitorService{closed: make(chan struct{})}
+	registry := &MonitorServiceRegistry{}
+	registry.Set([]MonitorServiceProxy{NewMonitorServiceProxy("gcloud", "gcloud", replaced)})
+	registry.Set([]MonitorServiceProxy{NewMonitorServiceProxy("gcloud", "gcloud", &closingMonitorService{closed: make(chan struct{})})})
+
+	select {
+	case <-replaced.closed:
+	case <-time.After(time.Second):
+		t.Error("Expected the replaced monitor service to be closed")
+	}
 }
@@ -1,41 +1,148 @@
 package monitors
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
 )
 
+// MonitorService is implemented by every provider. GetByName, Add, Update and Remove
+// return errors created with the errors package in pkg/monitors/errors, so callers can
+// decide whether a failed request should be retried. GetByName returns a NotFound error
+// only if the monitor doesn't exist.
 type MonitorService interface {
 	GetAll() []models.Monitor
-	Add(m models.Monitor)
-	Update(m models.Monitor)
+	Add(m models.Monitor) error
+	Update(m models.Monitor) error
 	GetByName(name string) (*models.Monitor, error)
-	Remove(m models.Monitor)
-	Setup(p config.Provider)
+	Remove(m models.Monitor) error
+	Setup(p config.Provider) error
 	Equal(oldMonitor models.Monitor, newMonitor models.Monitor) bool
 }
 
-func CreateMonitorService(p *config.Provider) MonitorServiceProxy {
-	monitorService := (&MonitorServiceProxy{}).OfType(p.Name)
-	monitorService.Setup(*p)
-	return monitorService
+// CredentialsVerifier is implemented by providers that can check their credentials without changing any monitor
+type CredentialsVerifier interface {
+	VerifyCredentials() error
+}
+
+// CheckStatusReporter is implemented by providers that report the state of their checks, e.g. whether the monitored
+// URL is up and its uptime ratio
+type CheckStatusReporter interface {
+	GetCheckStatus(m models.Monitor) (*models.CheckStatus, error)
+}
+
+// MonitorGetter is implemented by providers that can retrieve a monitor by its ID. GetByID returns a NotFound error
+// of pkg/monitors/errors if the monitor doesn't exist.
+type MonitorGetter interface {
+	GetByID(id string) (*models.Monitor, error)
 }
 
-func SetupMonitorServicesForProviders(providers []config.Provider) []MonitorServiceProxy {
+// ErrGetByIDNotSupported is returned by MonitorServiceProxy.GetByID for providers that only look up monitors by name
+var ErrGetByIDNotSupported = errors.New("provider doesn't support retrieving monitors by ID")
+
+// Closer is implemented by providers that hold connections to their API, e.g. gRPC connections, which have to be
+// closed when their monitor service is replaced
+type Closer interface {
+	Close() error
+}
+
+// closeDelay leaves the requests in flight of replaced monitor services the time to complete before they are closed
+var closeDelay = 5 * time.Minute
+
+// CloseMonitorServices closes the connections of monitor services that have been replaced, once the requests in
+// flight are done
+func CloseMonitorServices(services []MonitorServiceProxy) {
+	if len(services) == 0 {
+		return
+	}
+	time.AfterFunc(closeDelay, func() {
+		for index := range services {
+			if err := services[index].Close(); err != nil {
+				log.Error(err, "Failed to close monitor service of "+services[index].GetName())
+			}
+		}
+	})
+}
+
+func CreateMonitorService(p *config.Provider) (MonitorServiceProxy, error) {
+	monitorService, err := (&MonitorServiceProxy{name: p.Name}).OfType(p.GetType())
+	if err != nil {
+		return monitorService, err
+	}
+	if err := monitorService.Setup(*p); err != nil {
+		return monitorService, err
+	}
+	return monitorService, nil
+}
+
+// SetupMonitorServicesForProviders creates the monitor services of all valid providers. Invalid providers are
+// skipped and reported in the returned error, so that the valid ones keep working.
+func SetupMonitorServicesForProviders(providers []config.Provider) ([]MonitorServiceProxy, error) {
 	if len(providers) < 1 {
-		panic("Cannot Instantiate controller with no providers")
+		return nil, fmt.Errorf("no providers are configured")
 	}
 
 	monitorServices := []MonitorServiceProxy{}
+	names := make(map[string]bool)
+	var errs []error
 
 	for index := 0; index < len(providers); index++ {
-		monitorServices = append(monitorServices, CreateMonitorService(&providers[index]))
-		log.Info("Configuration added for " + providers[index].Name)
+		// Status and deletion of monitors are tracked by the name of the provider
+		if names[providers[index].Name] {
+			errs = append(errs, fmt.Errorf("duplicate provider name: %s", providers[index].Name))
+			continue
+		}
+		names[providers[index].Name] = true
+
+		monitorService, err := CreateMonitorService(&providers[index])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid provider %s: %w", providers[index].Name, err))
+			continue
+		}
+		monitorServices = append(monitorServices, monitorService)
+		log.Info("Configuration added for " + providers[index].Name + " of type " + providers[index].GetType())
 	}
 
-	return monitorServices
+	return monitorServices, utilerrors.NewAggregate(errs)
+}
+
+// GetInvalidProviders returns the names of the providers that have no monitor service among the given ones, e.g.
+// because their configuration is invalid, and the names of duplicate providers
+func GetInvalidProviders(providers []config.Provider, services []MonitorServiceProxy) []string {
+	valid := make(map[string]bool)
+	for index := range services {
+		valid[services[index].GetName()] = true
+	}
+	count := make(map[string]int)
+	for index := range providers {
+		count[getProviderName(providers[index])]++
+	}
+
+	var invalid []string
+	for index := range providers {
+		name := getProviderName(providers[index])
+		if (!valid[name] || count[name] > 1) && !contains(invalid, name) {
+			invalid = append(invalid, name)
+		}
+	}
+	return invalid
+}
+
+// getProviderName returns the name of the monitor service of the provider, which defaults to its type
+func getProviderName(p config.Provider) string {
+	if len(p.Name) == 0 {
+		return p.GetType()
+	}
+	return p.Name
 }
 
 func SetupMonitorServicesForProvidersTest(providers []config.Provider) []MonitorServiceProxy {
@@ -49,8 +156,12 @@ func SetupMonitorServicesForProvidersTest(providers []config.Provider) []Monitor
 	monitorServices := []MonitorServiceProxy{}
 
 	for index := 0; index < len(providers); index++ {
-		if contains(allowedProviders, providers[index].Name) {
-			monitorServices = append(monitorServices, CreateMonitorService(&providers[index]))
+		if contains(allowedProviders, providers[index].GetType()) {
+			monitorService, err := CreateMonitorService(&providers[index])
+			if err != nil {
+				panic(err)
+			}
+			monitorServices = append(monitorServices, monitorService)
 			log.Info("Configuration added for " + providers[index].Name)
 		}
 	}
@@ -66,3 +177,162 @@ func contains(arr []string, str string) bool {
 	}
 	return false
 }
+
+// MonitorServiceRegistry holds the monitor services of the configured providers. The services are replaced
+// as a whole when the configuration changes, callers should work on the snapshot returned by Get.
+// Providers defined by ProviderConfigs take precedence over the providers of the config secret with the same name.
+type MonitorServiceRegistry struct {
+	mutex                  sync.RWMutex
+	services               []MonitorServiceProxy
+	providerConfigServices []MonitorServiceProxy
+	unavailable            map[string]bool
+	// allUnavailable is set while the ProviderConfigs are unknown, see SetProviderConfigsUnavailable
+	allUnavailable bool
+	merged         []MonitorServiceProxy
+}
+
+// Get returns the current monitor services
+func (r *MonitorServiceRegistry) Get() []MonitorServiceProxy {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.merged
+}
+
+// Set replaces the monitor services of the providers in the config secret, the previous ones are closed
+func (r *MonitorServiceRegistry) Set(services []MonitorServiceProxy) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	CloseMonitorServices(r.services)
+	r.services = services
+	r.merge()
+}
+
+// SetProviderConfigServices replaces the monitor services of the ProviderConfigs. The names of ProviderConfigs
+// whose monitor service couldn't be built are reported as unavailable.
+func (r *MonitorServiceRegistry) SetProviderConfigServices(services []MonitorServiceProxy, unavailable []string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.providerConfigServices = services
+	r.unavailable = make(map[string]bool)
+	for _, name := range unavailable {
+		r.unavailable[name] = true
+	}
+	r.allUnavailable = false
+	r.merge()
+}
+
+// SetProviderConfigsUnavailable marks all providers as unavailable until the monitor services of the ProviderConfigs
+// are set, e.g. because the ProviderConfigs couldn't be listed. ProviderConfigs take precedence over the providers of
+// the config secret, so none of the providers can be used safely in the meantime.
+func (r *MonitorServiceRegistry) SetProviderConfigsUnavailable() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.providerConfigServices = nil
+	r.unavailable = nil
+	r.allUnavailable = true
+	r.merge()
+}
+
+// IsUnavailable returns true if the provider is configured but its monitor service couldn't be built
+func (r *MonitorServiceRegistry) IsUnavailable(name string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.allUnavailable || r.unavailable[name]
+}
+
+func (r *MonitorServiceRegistry) merge() {
+	merged := make([]MonitorServiceProxy, 0, len(r.providerConfigServices)+len(r.services))
+	names := make(map[string]bool)
+	for _, service := range r.providerConfigServices {
+		names[service.GetName()] = true
+		merged = append(merged, service)
+	}
+	for _, service := range r.services {
+		if !names[service.GetName()] && !r.unavailable[service.GetName()] && !r.allUnavailable {
+			merged = append(merged, service)
+		}
+	}
+	r.merged = merged
+}
+
+// MonitorServiceCache caches the monitor services built from the providers stored in secrets, e.g. the credentials
+// of an EndpointMonitor. The services are rebuilt when the data of a secret changes, and evicted when the last
+// EndpointMonitor referencing the secret has been finalized.
+type MonitorServiceCache struct {
+	mutex   sync.Mutex
+	entries map[types.NamespacedName]monitorServiceCacheEntry
+}
+
+type monitorServiceCacheEntry struct {
+	data string
+	// controllerConfig holds the parts of the configuration of the controller the services depend on
+	controllerConfig config.Config
+	services         []MonitorServiceProxy
+}
+
+// Get returns the monitor services for the providers in the given data of a secret. The providers use the HTTP
+// configuration of the controller and are restricted to its API URLs, they are rebuilt when it changes.
+func (c *MonitorServiceCache) Get(key types.NamespacedName, data string) ([]MonitorServiceProxy, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	controllerConfig := config.GetControllerConfig()
+	controllerConfig = config.Config{
+		Providers:          controllerConfig.Providers,
+		HTTP:               controllerConfig.HTTP,
+		CredentialsSecrets: controllerConfig.CredentialsSecrets,
+	}
+	if entry, ok := c.entries[key]; ok && entry.data == data && reflect.DeepEqual(entry.controllerConfig, controllerConfig) {
+		return entry.services, nil
+	}
+
+	providersConfig, err := config.ParseConfig([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse providers of secret %s: %w", key, err)
+	}
+	for index := range providersConfig.Providers {
+		provider := &providersConfig.Providers[index]
+		// Secrets of other namespaces, e.g. the client certificate of the controller, must not be referenced
+		if provider.HTTP.HasCertificateReferences() {
+			return nil, fmt.Errorf("invalid providers in secret %s: certificates of provider %s can't be configured in credentials secrets", key, provider.Name)
+		}
+		// The client certificate and proxy credentials of the controller must not be sent to endpoints of the users
+		if err := controllerConfig.RestrictCredentialsProvider(provider); err != nil {
+			return nil, fmt.Errorf("invalid providers in secret %s: %w", key, err)
+		}
+		provider.HTTP = provider.HTTP.WithDefaults(controllerConfig.HTTP)
+	}
+	services, err := SetupMonitorServicesForProviders(providersConfig.Providers)
+	if err != nil {
+		CloseMonitorServices(services)
+		return nil, fmt.Errorf("invalid providers in secret %s: %w", key, err)
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[types.NamespacedName]monitorServiceCacheEntry)
+	}
+	CloseMonitorServices(c.entries[key].services)
+	c.entries[key] = monitorServiceCacheEntry{data: data, controllerConfig: controllerConfig, services: services}
+	return services, nil
+}
+
+// Remove evicts the monitor services of the given secret once no EndpointMonitor references it anymore and closes them
+func (c *MonitorServiceCache) Remove(key types.NamespacedName) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if entry, ok := c.entries[key]; ok {
+		CloseMonitorServices(entry.services)
+		delete(c.entries, key)
+	}
+}
+
+// GetCached returns the monitor services last built for the given secret, e.g. to remove monitors after
+// the secret has been deleted
+func (c *MonitorServiceCache) GetCached(key types.NamespacedName) ([]MonitorServiceProxy, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key]
+	return entry.services, ok
+}
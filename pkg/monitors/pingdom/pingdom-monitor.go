@@ -2,23 +2,37 @@ package pingdom
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/russellcardullo/go-pingdom/pingdom"
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	providerhttp "github.com/stakater/IngressMonitorController/v2/pkg/http"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
 	"github.com/stakater/IngressMonitorController/v2/pkg/util"
 )
 
 var log = logf.Log.WithName("pingdom")
 
+// providerType labels the metrics of the API calls
+const providerType = "Pingdom"
+
+// resolutions are the check intervals in minutes supported by pingdom
+var resolutions = []int{1, 5, 15, 30, 60}
+
 // PingdomMonitorService interfaces with MonitorService
 type PingdomMonitorService struct {
 	apiToken          string
@@ -29,12 +43,105 @@ type PingdomMonitorService struct {
 	client            *pingdom.Client
 }
 
+// Equal compares the check that would be sent to update the monitor with the check on pingdom
 func (monitor *PingdomMonitorService) Equal(oldMonitor models.Monitor, newMonitor models.Monitor) bool {
-	// TODO: Retrieve oldMonitor config and compare it here
-	return false
+	checkResponse, ok := oldMonitor.Config.(*pingdom.CheckResponse)
+	if !ok || checkResponse == nil || checkResponse.Type.HTTP == nil {
+		return false
+	}
+
+	oldHttpCheck := checkResponseToHttpCheck(checkResponse)
+	newHttpCheck := monitor.createHttpCheck(newMonitor)
+
+	// Settings left unset by the monitor keep the value pingdom defaults them to
+	if newHttpCheck.VerifyCertificate == nil {
+		oldHttpCheck.VerifyCertificate = nil
+	}
+	if newHttpCheck.SSLDownDaysBefore == nil {
+		oldHttpCheck.SSLDownDaysBefore = nil
+	}
+	if _, exists := newHttpCheck.RequestHeaders["User-Agent"]; !exists {
+		delete(oldHttpCheck.RequestHeaders, "User-Agent")
+	}
+	// Passwords are not necessarily returned by pingdom
+	oldHttpCheck.Password = newHttpCheck.Password
+
+	if !reflect.DeepEqual(normalizeHttpCheck(oldHttpCheck), normalizeHttpCheck(newHttpCheck)) {
+		log.Info(fmt.Sprintf("There are some new changes in %s monitor", newMonitor.Name))
+		return false
+	}
+	return true
+}
+
+// checkResponseToHttpCheck maps the settings of a check returned by pingdom, which are managed by the controller, to
+// an HttpCheck
+func checkResponseToHttpCheck(checkResponse *pingdom.CheckResponse) pingdom.HttpCheck {
+	httpDetails := checkResponse.Type.HTTP
+	httpCheck := pingdom.HttpCheck{
+		Name:                     checkResponse.Name,
+		Hostname:                 checkResponse.Hostname,
+		Resolution:               checkResponse.Resolution,
+		Paused:                   checkResponse.Paused,
+		SendNotificationWhenDown: checkResponse.SendNotificationWhenDown,
+		NotifyWhenBackup:         checkResponse.NotifyWhenBackup,
+		Url:                      httpDetails.Url,
+		Encryption:               httpDetails.Encryption,
+		Username:                 httpDetails.Username,
+		Password:                 httpDetails.Password,
+		ShouldContain:            httpDetails.ShouldContain,
+		ShouldNotContain:         httpDetails.ShouldNotContain,
+		IntegrationIds:           checkResponse.IntegrationIds,
+		UserIds:                  checkResponse.UserIds,
+		TeamIds:                  checkResponse.TeamIds,
+		VerifyCertificate:        &httpDetails.VerifyCertificate,
+	}
+
+	if len(httpDetails.RequestHeaders) > 0 {
+		httpCheck.RequestHeaders = make(map[string]string, len(httpDetails.RequestHeaders))
+		for key, value := range httpDetails.RequestHeaders {
+			httpCheck.RequestHeaders[key] = value
+		}
+	}
+
+	tags := make([]string, 0, len(checkResponse.Tags))
+	for _, tag := range checkResponse.Tags {
+		tags = append(tags, tag.Name)
+	}
+	httpCheck.Tags = strings.Join(tags, ",")
+
+	// Pingdom only applies SSLDownDaysBefore if the certificate is verified
+	if httpDetails.VerifyCertificate {
+		httpCheck.SSLDownDaysBefore = &httpDetails.SSLDownDaysBefore
+	}
+	return httpCheck
+}
+
+// normalizeHttpCheck returns the check in a comparable form, with sorted IDs and tags and without empty lists
+func normalizeHttpCheck(httpCheck pingdom.HttpCheck) pingdom.HttpCheck {
+	normalizeIds := func(ids []int) []int {
+		if len(ids) == 0 {
+			return nil
+		}
+		sorted := append([]int{}, ids...)
+		sort.Ints(sorted)
+		return sorted
+	}
+	httpCheck.UserIds = normalizeIds(httpCheck.UserIds)
+	httpCheck.IntegrationIds = normalizeIds(httpCheck.IntegrationIds)
+	httpCheck.TeamIds = normalizeIds(httpCheck.TeamIds)
+
+	if len(httpCheck.Tags) > 0 {
+		tags := strings.Split(httpCheck.Tags, ",")
+		sort.Strings(tags)
+		httpCheck.Tags = strings.Join(tags, ",")
+	}
+	if len(httpCheck.RequestHeaders) == 0 {
+		httpCheck.RequestHeaders = nil
+	}
+	return httpCheck
 }
 
-func (service *PingdomMonitorService) Setup(p config.Provider) {
+func (service *PingdomMonitorService) Setup(p config.Provider) error {
 	service.apiToken = p.ApiToken
 	service.url = p.ApiURL
 	service.alertContacts = p.AlertContacts
@@ -43,34 +150,90 @@ func (service *PingdomMonitorService) Setup(p config.Provider) {
 
 	var err error
 	service.client, err = pingdom.NewClientWithConfig(pingdom.ClientConfig{
-		APIToken: service.apiToken,
-		BaseURL:  service.url,
+		APIToken:   service.apiToken,
+		BaseURL:    service.url,
+		HTTPClient: providerhttp.NewClient(p.HTTP),
 	})
 	if err != nil {
-		log.Info("Error Seting Up Monitor Service: " + err.Error())
+		return fmt.Errorf("unable to create pingdom client: %w", err)
 	}
+	return nil
 }
 
-func (service *PingdomMonitorService) GetByName(name string) (*models.Monitor, error) {
-	var match *models.Monitor
+// VerifyCredentials checks the API token by listing the checks
+func (service *PingdomMonitorService) VerifyCredentials() error {
+	start := time.Now()
+	_, err := service.client.Checks.List()
+	observeRequest(metrics.OperationVerify, start, err)
+	if err != nil {
+		return pingdomError("Error listing checks", err)
+	}
+	return nil
+}
 
-	monitors := service.GetAll()
+func (service *PingdomMonitorService) GetByName(name string) (*models.Monitor, error) {
+	monitors, err := service.list()
+	if err != nil {
+		return nil, err
+	}
 	for _, mon := range monitors {
 		if mon.Name == name {
+			// The list of checks only holds a summary of each check so the full check is retrieved
+			if monitorID, err := strconv.Atoi(mon.ID); err == nil {
+				start := time.Now()
+				checkResponse, err := service.client.Checks.Read(monitorID)
+				observeRequest(metrics.OperationGet, start, err)
+				if err == nil {
+					mon.Config = checkResponse
+				} else {
+					log.Info("Error received while reading check: " + err.Error())
+				}
+			}
 			return &mon, nil
 		}
 	}
 
-	return match, fmt.Errorf("Unable to locate monitor with name %v", name)
+	return nil, monitorerrors.NewNotFound("Unable to locate monitor with name "+name, nil)
+}
+
+// GetByID retrieves the full check with the given ID
+func (service *PingdomMonitorService) GetByID(id string) (*models.Monitor, error) {
+	monitorID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, monitorerrors.NewNotFound("Invalid ID "+id, err)
+	}
+	start := time.Now()
+	checkResponse, err := service.client.Checks.Read(monitorID)
+	observeRequest(metrics.OperationGet, start, err)
+	if err != nil {
+		return nil, pingdomError("Unable to read check "+id, err)
+	}
+	return &models.Monitor{
+		URL:    checkResponse.Hostname,
+		ID:     id,
+		Name:   checkResponse.Name,
+		Config: checkResponse,
+	}, nil
 }
 
 func (service *PingdomMonitorService) GetAll() []models.Monitor {
+	monitors, err := service.list()
+	if err != nil {
+		log.Info("Error received while listing checks: " + err.Error())
+		return nil
+	}
+	return monitors
+}
+
+// list returns the checks of the account, or the error of the request if they can't be listed
+func (service *PingdomMonitorService) list() ([]models.Monitor, error) {
 	var monitors []models.Monitor
 
+	start := time.Now()
 	checks, err := service.client.Checks.List()
+	observeRequest(metrics.OperationList, start, err)
 	if err != nil {
-		log.Info("Error received while listing checks: " + err.Error())
-		return nil
+		return nil, pingdomError("Unable to list checks", err)
 	}
 	for _, mon := range checks {
 		newMon := models.Monitor{
@@ -81,41 +244,165 @@ func (service *PingdomMonitorService) GetAll() []models.Monitor {
 		monitors = append(monitors, newMon)
 	}
 
-	return monitors
+	return monitors, nil
 }
 
-func (service *PingdomMonitorService) Add(m models.Monitor) {
+func (service *PingdomMonitorService) Add(m models.Monitor) error {
 	httpCheck := service.createHttpCheck(m)
+	if err := httpCheck.Valid(); err != nil {
+		log.Info("Error Adding Monitor: " + err.Error())
+		return monitorerrors.NewValidationFailed("Invalid check for monitor: "+m.Name, err)
+	}
 
+	start := time.Now()
 	_, err := service.client.Checks.Create(&httpCheck)
+	observeRequest(metrics.OperationCreate, start, err)
 	if err != nil {
 		log.Info("Error Adding Monitor: " + err.Error())
-	} else {
-		log.Info("Added monitor for: " + m.Name)
+		return pingdomError("Error Adding Monitor: "+m.Name, err)
 	}
+	log.Info("Added monitor for: " + m.Name)
+	return nil
 }
 
-func (service *PingdomMonitorService) Update(m models.Monitor) {
+func (service *PingdomMonitorService) Update(m models.Monitor) error {
 	httpCheck := service.createHttpCheck(m)
-	monitorID, _ := strconv.Atoi(m.ID)
+	if err := httpCheck.Valid(); err != nil {
+		log.Info("Error updating Monitor: " + err.Error())
+		return monitorerrors.NewValidationFailed("Invalid check for monitor: "+m.Name, err)
+	}
+	monitorID, err := strconv.Atoi(m.ID)
+	if err != nil {
+		return monitorerrors.NewNotFound("Invalid ID "+m.ID+" for monitor: "+m.Name, err)
+	}
 
+	start := time.Now()
 	resp, err := service.client.Checks.Update(monitorID, &httpCheck)
+	observeRequest(metrics.OperationUpdate, start, err)
 	if err != nil {
 		log.Info("Error updating Monitor: " + err.Error())
-	} else {
-		log.Info(fmt.Sprintf("Updated Monitor: %v", resp))
+		return pingdomError("Error updating Monitor: "+m.Name, err)
 	}
+	log.Info(fmt.Sprintf("Updated Monitor: %v", resp))
+	return nil
 }
 
-func (service *PingdomMonitorService) Remove(m models.Monitor) {
-	monitorID, _ := strconv.Atoi(m.ID)
+func (service *PingdomMonitorService) Remove(m models.Monitor) error {
+	monitorID, err := strconv.Atoi(m.ID)
+	if err != nil {
+		return monitorerrors.NewNotFound("Invalid ID "+m.ID+" for monitor: "+m.Name, err)
+	}
 
+	start := time.Now()
 	resp, err := service.client.Checks.Delete(monitorID)
+	observeRequest(metrics.OperationDelete, start, err)
 	if err != nil {
 		log.Info("Error deleting Monitor: " + err.Error())
-	} else {
-		log.Info(fmt.Sprintf("Delete Monitor: %v", resp))
+		return pingdomError("Error deleting Monitor: "+m.Name, err)
+	}
+	log.Info(fmt.Sprintf("Delete Monitor: %v", resp))
+	return nil
+}
+
+// observeRequest records a call of the pingdom API, the status of a failed call is taken from its error
+// uptimeRatioPeriod is the period over which the uptime ratio of a check is reported
+const uptimeRatioPeriod = 24 * time.Hour
+
+// summaryAverageResponse is the response of the summary.average API holding the uptime of a check
+type summaryAverageResponse struct {
+	Summary struct {
+		Status struct {
+			TotalUp      int64 `json:"totalup"`
+			TotalDown    int64 `json:"totaldown"`
+			TotalUnknown int64 `json:"totalunknown"`
+		} `json:"status"`
+	} `json:"summary"`
+}
+
+// GetCheckStatus returns the status of the check, its last response time and its uptime ratio over the last day
+func (service *PingdomMonitorService) GetCheckStatus(m models.Monitor) (*models.CheckStatus, error) {
+	monitorID, err := strconv.Atoi(m.ID)
+	if err != nil {
+		return nil, monitorerrors.NewNotFound("Invalid ID "+m.ID+" for monitor: "+m.Name, err)
+	}
+
+	start := time.Now()
+	check, err := service.client.Checks.Read(monitorID)
+	observeRequest(metrics.OperationGet, start, err)
+	if err != nil {
+		return nil, pingdomError("Failed to read check: "+m.Name, err)
+	}
+
+	status := &models.CheckStatus{}
+	switch check.Status {
+	case "up":
+		status.State = models.CheckStateUp
+	case "down", "unconfirmed_down":
+		status.State = models.CheckStateDown
+	case "paused":
+		status.State = models.CheckStatePaused
+	default:
+		status.State = models.CheckStateUnknown
+	}
+	if check.LastResponseTime > 0 {
+		responseTime := time.Duration(check.LastResponseTime) * time.Millisecond
+		status.ResponseTime = &responseTime
+	}
+
+	// The status is still reported if the uptime can't be retrieved
+	status.UptimeRatio, err = service.getUptimeRatio(monitorID)
+	if err != nil {
+		log.Info("Failed to get uptime of check: " + m.Name + ". Error: " + err.Error())
 	}
+	return status, nil
+}
+
+// getUptimeRatio returns the ratio of the time the check was up over uptimeRatioPeriod, nil if it wasn't checked
+func (service *PingdomMonitorService) getUptimeRatio(monitorID int) (*float64, error) {
+	params := map[string]string{
+		"includeuptime": "true",
+		"from":          strconv.FormatInt(time.Now().Add(-uptimeRatioPeriod).Unix(), 10),
+	}
+	req, err := service.client.NewRequest("GET", "/summary.average/"+strconv.Itoa(monitorID), params)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary summaryAverageResponse
+	start := time.Now()
+	_, err = service.client.Do(req, &summary)
+	observeRequest(metrics.OperationGet, start, err)
+	if err != nil {
+		return nil, err
+	}
+
+	checked := summary.Summary.Status.TotalUp + summary.Summary.Status.TotalDown
+	if checked == 0 {
+		return nil, nil
+	}
+	ratio := float64(summary.Summary.Status.TotalUp) / float64(checked)
+	return &ratio, nil
+}
+
+func observeRequest(operation string, start time.Time, err error) {
+	statusCode := http.StatusOK
+	if err != nil {
+		statusCode = 0
+		var apiError *pingdom.PingdomError
+		if errors.As(err, &apiError) {
+			statusCode = apiError.StatusCode
+		}
+	}
+	metrics.ObserveProviderRequest(providerType, operation, statusCode, start)
+}
+
+// pingdomError maps an error returned by the pingdom client to a monitor error
+func pingdomError(message string, err error) error {
+	var apiError *pingdom.PingdomError
+	if errors.As(err, &apiError) {
+		return monitorerrors.FromStatusCode(apiError.StatusCode, message+": "+apiError.Message)
+	}
+	return monitorerrors.NewRetryable(message, err)
 }
 
 func (service *PingdomMonitorService) createHttpCheck(monitor models.Monitor) pingdom.HttpCheck {
@@ -167,12 +454,12 @@ func (service *PingdomMonitorService) createHttpCheck(monitor models.Monitor) pi
 		}
 	}
 	// Generate check itself
-	service.addConfigToHttpCheck(&httpCheck, monitor.Config)
+	service.addConfigToHttpCheck(&httpCheck, monitor.Config, monitor.Check, monitor.BasicAuthPassword)
 
 	return httpCheck
 }
 
-func (service *PingdomMonitorService) addConfigToHttpCheck(httpCheck *pingdom.HttpCheck, config interface{}) {
+func (service *PingdomMonitorService) addConfigToHttpCheck(httpCheck *pingdom.HttpCheck, config interface{}, check *endpointmonitorv1alpha1.CheckConfig, basicAuthPassword string) {
 	// Read config, try to map them to pingdom configs
 	// set some default values if we can't find them
 
@@ -210,6 +497,9 @@ func (service *PingdomMonitorService) addConfigToHttpCheck(httpCheck *pingdom.Ht
 
 	if providerConfig != nil && providerConfig.Resolution > 0 {
 		httpCheck.Resolution = providerConfig.Resolution
+	} else if check.GetIntervalSeconds() > 0 {
+		// Resolution is in minutes and only supports a fixed set of values
+		httpCheck.Resolution = util.ClosestInt(resolutions, check.GetIntervalSeconds()/60)
 	} else {
 		httpCheck.Resolution = 1
 	}
@@ -226,6 +516,8 @@ func (service *PingdomMonitorService) addConfigToHttpCheck(httpCheck *pingdom.Ht
 		if err != nil {
 			log.Info("Error Converting from string to JSON object")
 		}
+	} else if check != nil && len(check.Headers) > 0 {
+		httpCheck.RequestHeaders = check.Headers
 	}
 
 	if providerConfig != nil && len(providerConfig.BasicAuthUser) > 0 {
@@ -241,11 +533,25 @@ func (service *PingdomMonitorService) addConfigToHttpCheck(httpCheck *pingdom.Ht
 		} else {
 			log.Info("Error reading basic auth password from environment variable")
 		}
+	} else if check != nil && check.BasicAuth != nil {
+		if basicAuthPassword != "" {
+			httpCheck.Username = check.BasicAuth.Username
+			httpCheck.Password = basicAuthPassword
+			log.Info("Basic auth requirement detected. Setting username and password for httpCheck")
+		} else {
+			log.Info("Basic auth password is empty, ignoring basic auth")
+		}
 	}
 
 	if providerConfig != nil && len(providerConfig.ShouldContain) > 0 {
 		httpCheck.ShouldContain = providerConfig.ShouldContain
 		log.Info("Should contain detected. Setting Should Contain string: " + providerConfig.ShouldContain)
+	} else if check.GetType() == endpointmonitorv1alpha1.CheckTypeKeyword && check.Keyword != nil {
+		if check.Keyword.IsKeywordAbsent() {
+			httpCheck.ShouldNotContain = check.Keyword.Value
+		} else {
+			httpCheck.ShouldContain = check.Keyword.Value
+		}
 	}
 
 	// Tags should be a single word or multiple comma-seperated words
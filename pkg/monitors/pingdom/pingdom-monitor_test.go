@@ -1,9 +1,14 @@
 package pingdom
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
+	"github.com/russellcardullo/go-pingdom/pingdom"
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
 	"github.com/stakater/IngressMonitorController/v2/pkg/util"
@@ -95,3 +100,103 @@ func TestUpdateMonitorWithCorrectValues(t *testing.T) {
 		t.Error("Monitor should've been deleted ", monitor, err)
 	}
 }
+
+func TestEqual(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/checks":
+			w.Write([]byte(`{"checks":[{"id":1,"name":"google-test","hostname":"google.com","resolution":5,"type":"http"}]}`))
+		case "/checks/1":
+			w.Write([]byte(`{"check":{"id":1,"name":"google-test","hostname":"google.com","resolution":5,"sendnotificationwhendown":3,"userids":[2,1],` +
+				`"tags":[{"name":"uptime","type":"a","count":1},{"name":"test","type":"a","count":1}],` +
+				`"type":{"http":{"url":"/health","encryption":true,"port":443,"shouldcontain":"ok","requestheaders":{"User-Agent":"Pingdom.com_bot_version_1.4"}}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service := PingdomMonitorService{}
+	err := service.Setup(config.Provider{Name: "Pingdom", ApiToken: "token", ApiURL: server.URL, AlertContacts: "1-2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldMonitor, err := service.GetByName("google-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := oldMonitor.Config.(*pingdom.CheckResponse); !ok {
+		t.Fatal("Monitor should hold the full check")
+	}
+
+	newMonitor := models.Monitor{
+		Name: "google-test",
+		URL:  "https://google.com/health",
+		ID:   oldMonitor.ID,
+		Config: &endpointmonitorv1alpha1.PingdomConfig{
+			Resolution:    5,
+			ShouldContain: "ok",
+			Tags:          "test,uptime",
+		},
+	}
+	if !service.Equal(*oldMonitor, newMonitor) {
+		t.Error("Monitor without changes should be equal")
+	}
+
+	newMonitor.URL = "https://google.com/ready"
+	if service.Equal(*oldMonitor, newMonitor) {
+		t.Error("Monitor with a changed path should not be equal")
+	}
+
+	newMonitor.URL = "https://google.com/health"
+	newMonitor.Config.(*endpointmonitorv1alpha1.PingdomConfig).AlertContacts = "3"
+	if service.Equal(*oldMonitor, newMonitor) {
+		t.Error("Monitor with changed alert contacts should not be equal")
+	}
+
+	if service.Equal(models.Monitor{Name: "google-test", ID: "1"}, newMonitor) {
+		t.Error("Monitor without the full check should not be equal")
+	}
+}
+
+func TestGetCheckStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/checks/1":
+			w.Write([]byte(`{"check":{"id":1,"name":"google-test","hostname":"google.com","status":"down","lastresponsetime":250}}`))
+		case "/summary.average/1":
+			if r.URL.Query().Get("includeuptime") != "true" {
+				t.Error("Uptime should be requested")
+			}
+			w.Write([]byte(`{"summary":{"status":{"totalup":3000,"totaldown":1000,"totalunknown":200}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service := PingdomMonitorService{}
+	err := service.Setup(config.Provider{Name: "Pingdom", ApiToken: "token", ApiURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := service.GetCheckStatus(models.Monitor{Name: "google-test", ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != models.CheckStateDown {
+		t.Errorf("Expected state %s, got %s", models.CheckStateDown, status.State)
+	}
+	if status.ResponseTime == nil || *status.ResponseTime != 250*time.Millisecond {
+		t.Errorf("Expected response time 250ms, got %v", status.ResponseTime)
+	}
+	if status.UptimeRatio == nil || *status.UptimeRatio != 0.75 {
+		t.Errorf("Expected uptime ratio 0.75, got %v", status.UptimeRatio)
+	}
+
+	if _, err := service.GetCheckStatus(models.Monitor{Name: "missing", ID: "2"}); err == nil {
+		t.Error("Status of a missing check should fail")
+	}
+}
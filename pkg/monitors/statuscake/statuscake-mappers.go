@@ -1,6 +1,10 @@
 package statuscake
 
 import (
+	"net/url"
+	"strconv"
+	"strings"
+
 	statuscake "github.com/StatusCakeDev/statuscake-go"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
 )
@@ -20,9 +24,28 @@ func StatusCakeApiResponseDataToBaseMonitorMapper(statuscakeData statuscake.Upti
 	m.Name = statuscakeData.Data.Name
 	m.URL = statuscakeData.Data.WebsiteURL
 	m.ID = statuscakeData.Data.ID
+	m.Config = &StatusCakeData{UptimeTest: statuscakeData.Data}
 	return &m
 }
 
+// StatusCakeDataToCheckStatusMapper maps the status and uptime percentage of an uptime test
+func StatusCakeDataToCheckStatusMapper(statuscakeData StatusCakeData) *models.CheckStatus {
+	var s models.CheckStatus
+	switch {
+	case statuscakeData.Paused:
+		s.State = models.CheckStatePaused
+	case statuscakeData.Status == statuscake.UptimeTestStatusUp:
+		s.State = models.CheckStateUp
+	case statuscakeData.Status == statuscake.UptimeTestStatusDown:
+		s.State = models.CheckStateDown
+	default:
+		s.State = models.CheckStateUnknown
+	}
+	ratio := float64(statuscakeData.Uptime) / 100
+	s.UptimeRatio = &ratio
+	return &s
+}
+
 // StatusCakeMonitorMonitorsToBaseMonitorsMapper function to map Statuscake structure to Monitor
 func StatusCakeMonitorMonitorsToBaseMonitorsMapper(statuscakeData []StatusCakeMonitorData) []models.Monitor {
 	var monitors []models.Monitor
@@ -31,3 +54,42 @@ func StatusCakeMonitorMonitorsToBaseMonitorsMapper(statuscakeData []StatusCakeMo
 	}
 	return monitors
 }
+
+// StatusCakeDataToUpsertFormMapper function to map a Statuscake Uptime Test to the form used to Add or Update it
+func StatusCakeDataToUpsertFormMapper(statuscakeData StatusCakeData) url.Values {
+	f := url.Values{}
+	f.Add("name", statuscakeData.Name)
+	f.Add("website_url", statuscakeData.WebsiteURL)
+	f.Add("check_rate", strconv.Itoa(int(statuscakeData.CheckRate)))
+	f.Add("test_type", string(statuscakeData.TestType))
+	for _, contactGroup := range statuscakeData.ContactGroups {
+		f.Add("contact_groups[]", contactGroup)
+	}
+	for _, tag := range statuscakeData.Tags {
+		f.Add("tags[]", tag)
+	}
+	f.Add("status_codes_csv", strings.Join(statuscakeData.StatusCodes, ","))
+	if statuscakeData.Paused {
+		f.Add("paused", "1")
+	}
+	if statuscakeData.FollowRedirects {
+		f.Add("follow_redirects", "1")
+	}
+	if statuscakeData.EnableSSLAlert {
+		f.Add("enable_ssl_alert", "1")
+	}
+	f.Add("trigger_rate", strconv.Itoa(int(statuscakeData.TriggerRate)))
+	if statuscakeData.Port != nil {
+		f.Add("port", strconv.Itoa(int(*statuscakeData.Port)))
+	}
+	f.Add("confirmation", strconv.Itoa(int(statuscakeData.Confirmation)))
+	f.Add("timeout", strconv.Itoa(int(statuscakeData.Timeout)))
+	if statuscakeData.FindString != nil {
+		f.Add("find_string", *statuscakeData.FindString)
+	}
+	f.Add("do_not_find", strconv.FormatBool(statuscakeData.DoNotFind))
+	if statuscakeData.CustomHeader != nil {
+		f.Add("custom_header", *statuscakeData.CustomHeader)
+	}
+	return f
+}
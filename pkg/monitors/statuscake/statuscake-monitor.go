@@ -3,26 +3,35 @@ package statuscake
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	statuscake "github.com/StatusCakeDev/statuscake-go"
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	providerhttp "github.com/stakater/IngressMonitorController/v2/pkg/http"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
+	"github.com/stakater/IngressMonitorController/v2/pkg/util"
 )
 
 var log = logf.Log.WithName("statuscake-monitor")
 
+// providerType labels the metrics of the API calls
+const providerType = "StatusCake"
+
 // StatusCakeMonitorService is the service structure for StatusCake
 type StatusCakeMonitorService struct {
 	apiKey   string
@@ -32,11 +41,136 @@ type StatusCakeMonitorService struct {
 	client   *http.Client
 }
 
+// Equal compares the form that would be sent to update the monitor with the uptime test on StatusCake. Fields left out
+// of the form are not changed by an update either, so only the fields present in the form are compared.
 func (monitor *StatusCakeMonitorService) Equal(oldMonitor models.Monitor, newMonitor models.Monitor) bool {
-	// TODO: Retrieve oldMonitor config and compare it here
-	return false
+	statuscakeData, ok := oldMonitor.Config.(*StatusCakeData)
+	if !ok || statuscakeData == nil {
+		return false
+	}
+
+	oldForm := normalizeUpsertForm(StatusCakeDataToUpsertFormMapper(*statuscakeData))
+	newForm := normalizeUpsertForm(buildUpsertForm(newMonitor, monitor.cgroup))
+	for key, values := range newForm {
+		if !reflect.DeepEqual(oldForm[key], values) {
+			log.Info(fmt.Sprintf("There are some new changes in %s monitor", newMonitor.Name))
+			return false
+		}
+	}
+	return true
 }
 
+// keywordDefaults are the keyword settings of a monitor without keyword. They are compared even if the form doesn't set
+// them, so that removing the keyword is detected.
+var keywordDefaults = map[string]string{
+	"find_string": "",
+	"do_not_find": "false",
+}
+
+// normalizeUpsertForm returns the form in a comparable form. Lists are sorted, the basic auth credentials are
+// removed as StatusCake does not return them and the missing keyword settings are set to their defaults.
+func normalizeUpsertForm(f url.Values) url.Values {
+	normalized := url.Values{}
+	for key, values := range f {
+		values = append([]string{}, values...)
+		switch key {
+		case "basic_username", "basic_password":
+			continue
+		case "test_type":
+			values[0] = strings.ToUpper(values[0])
+		case "status_codes_csv":
+			statusCodes := convertStringToArray(values[0])
+			sort.Strings(statusCodes)
+			values = []string{strings.Join(statusCodes, ",")}
+		case "custom_header":
+			// Marshalling sorts the keys of the headers
+			var headers map[string]string
+			if err := json.Unmarshal([]byte(values[0]), &headers); err == nil {
+				headersJSON, _ := json.Marshal(headers)
+				values = []string{string(headersJSON)}
+			}
+		default:
+			sort.Strings(values)
+		}
+		normalized[key] = values
+	}
+	for key, value := range keywordDefaults {
+		if _, ok := normalized[key]; !ok {
+			normalized[key] = []string{value}
+		}
+	}
+	return normalized
+}
+
+// defaultStatusCodes are the HTTP status codes that trigger an error if none are configured
+var defaultStatusCodes = []string{
+	"204", // No content
+	"205", // Reset content
+	"206", // Partial content
+	"303", // See other
+	"305", // Use proxy
+	// https://en.wikipedia.org/wiki/List_of_HTTP_status_codes#4xx_Client_errors
+	// https://support.cloudflare.com/hc/en-us/articles/115003014512/
+	"400",
+	"401",
+	"402",
+	"403",
+	"404",
+	"405",
+	"406",
+	"407",
+	"408",
+	"409",
+	"410",
+	"411",
+	"412",
+	"413",
+	"414",
+	"415",
+	"416",
+	"417",
+	"418",
+	"421",
+	"422",
+	"423",
+	"424",
+	"425",
+	"426",
+	"428",
+	"429",
+	"431",
+	"444",
+	"451",
+	"499",
+	// https://support.cloudflare.com/hc/en-us/articles/115003011431/
+	"500",
+	"501",
+	"502",
+	"503",
+	"504",
+	"505",
+	"506",
+	"507",
+	"508",
+	"509",
+	"510",
+	"511",
+	"520",
+	"521",
+	"522",
+	"523",
+	"524",
+	"525",
+	"526",
+	"527",
+	"530",
+	"598",
+	"599",
+}
+
+// checkRates are the check rates in seconds supported by StatusCake
+var checkRates = []int{30, 60, 300, 900, 1800, 3600, 86400}
+
 // buildUpsertForm function is used to create the form needed to Add or update a monitor
 func buildUpsertForm(m models.Monitor, cgroup string) url.Values {
 	f := url.Values{}
@@ -49,12 +183,16 @@ func buildUpsertForm(m models.Monitor, cgroup string) url.Values {
 
 	if providerConfig != nil && providerConfig.CheckRate > 0 {
 		f.Add("check_rate", strconv.Itoa(providerConfig.CheckRate))
+	} else if m.Check.GetIntervalSeconds() > 0 {
+		f.Add("check_rate", strconv.Itoa(util.ClosestInt(checkRates, m.Check.GetIntervalSeconds())))
 	} else {
 		f.Add("check_rate", "300")
 	}
 
 	if providerConfig != nil && len(providerConfig.TestType) > 0 {
 		f.Add("test_type", providerConfig.TestType)
+	} else if m.Check.GetType() == endpointmonitorv1alpha1.CheckTypeTCP {
+		f.Add("test_type", "TCP")
 	} else {
 		f.Add("test_type", "HTTP")
 	}
@@ -92,77 +230,25 @@ func buildUpsertForm(m models.Monitor, cgroup string) url.Values {
 		} else {
 			log.Info("Error reading basic auth password from environment variable")
 		}
+	} else if m.Check != nil && m.Check.BasicAuth != nil {
+		if m.BasicAuthPassword != "" {
+			f.Add("basic_username", m.Check.BasicAuth.Username)
+			f.Add("basic_password", m.BasicAuthPassword)
+			log.Info("Basic auth requirement detected. Setting username and password")
+		} else {
+			log.Info("Basic auth password is empty, ignoring basic auth")
+		}
 	}
 
 	if providerConfig != nil && len(providerConfig.StatusCodes) > 0 {
 		f.Add("status_codes_csv", providerConfig.StatusCodes)
-
+	} else if m.Check.HasExpectedStatusCodes() {
+		// StatusCake expects the codes that trigger an error
+		additionalCodes, _ := util.SliceAtoi(defaultStatusCodes)
+		statusCodes := util.GetUnexpectedStatusCodes(m.Check, additionalCodes)
+		f.Add("status_codes_csv", strings.Join(util.SliceItoa(statusCodes), ","))
 	} else {
-		statusCodes := []string{
-			"204", // No content
-			"205", // Reset content
-			"206", // Partial content
-			"303", // See other
-			"305", // Use proxy
-			// https://en.wikipedia.org/wiki/List_of_HTTP_status_codes#4xx_Client_errors
-			// https://support.cloudflare.com/hc/en-us/articles/115003014512/
-			"400",
-			"401",
-			"402",
-			"403",
-			"404",
-			"405",
-			"406",
-			"407",
-			"408",
-			"409",
-			"410",
-			"411",
-			"412",
-			"413",
-			"414",
-			"415",
-			"416",
-			"417",
-			"418",
-			"421",
-			"422",
-			"423",
-			"424",
-			"425",
-			"426",
-			"428",
-			"429",
-			"431",
-			"444",
-			"451",
-			"499",
-			// https://support.cloudflare.com/hc/en-us/articles/115003011431/
-			"500",
-			"501",
-			"502",
-			"503",
-			"504",
-			"505",
-			"506",
-			"507",
-			"508",
-			"509",
-			"510",
-			"511",
-			"520",
-			"521",
-			"522",
-			"523",
-			"524",
-			"525",
-			"526",
-			"527",
-			"530",
-			"598",
-			"599",
-		}
-		f.Add("status_codes_csv", strings.Join(statusCodes, ","))
+		f.Add("status_codes_csv", strings.Join(defaultStatusCodes, ","))
 	}
 
 	if providerConfig != nil {
@@ -189,13 +275,60 @@ func buildUpsertForm(m models.Monitor, cgroup string) url.Values {
 	}
 	if providerConfig != nil && providerConfig.Port > 0 {
 		f.Add("port", strconv.Itoa(providerConfig.Port))
+	} else if m.Check.GetType() == endpointmonitorv1alpha1.CheckTypeTCP {
+		if port := getPort(unEscapedURL); port > 0 {
+			f.Add("port", strconv.Itoa(port))
+		}
 	}
 	if providerConfig != nil && providerConfig.Confirmation > 0 {
 		f.Add("confirmation", strconv.Itoa(providerConfig.Confirmation))
 	}
+
+	addCheckToForm(f, m.Check)
 	return f
 }
 
+// addCheckToForm adds the settings of the check that have no StatusCake specific configuration to the form
+func addCheckToForm(f url.Values, check *endpointmonitorv1alpha1.CheckConfig) {
+	if check == nil {
+		return
+	}
+	if check.GetTimeoutSeconds() > 0 {
+		f.Add("timeout", strconv.Itoa(check.GetTimeoutSeconds()))
+	}
+	if check.GetType() == endpointmonitorv1alpha1.CheckTypeKeyword && check.Keyword != nil {
+		f.Add("find_string", check.Keyword.Value)
+		f.Add("do_not_find", strconv.FormatBool(check.Keyword.IsKeywordAbsent()))
+	}
+	if len(check.Headers) != 0 {
+		headers, err := json.Marshal(check.Headers)
+		if err != nil {
+			log.Error(err, "Failed to marshal request headers")
+		} else {
+			f.Add("custom_header", string(headers))
+		}
+	}
+}
+
+// getPort returns the port of the given URL, falling back to the default port of its scheme
+func getPort(rawURL string) int {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	switch u.Scheme {
+	case "http":
+		return 80
+	case "https":
+		return 443
+	default:
+		return 0
+	}
+}
+
 // convertValuesToString changes multiple values returned by same key to string for validation purposes
 func convertUrlValuesToString(vals url.Values, key string) string {
 	var valuesArray []string
@@ -214,26 +347,61 @@ func convertStringToArray(stringValues string) []string {
 }
 
 // Setup function is used to initialise the StatusCake service
-func (service *StatusCakeMonitorService) Setup(p config.Provider) {
+func (service *StatusCakeMonitorService) Setup(p config.Provider) error {
 	service.apiKey = p.ApiKey
 	service.url = p.ApiURL
 	service.username = p.Username
 	service.cgroup = p.AlertContacts
-	service.client = &http.Client{}
+	service.client = providerhttp.NewClient(p.HTTP)
+	return nil
+}
+
+// VerifyCredentials checks the API key by listing the uptime tests
+func (service *StatusCakeMonitorService) VerifyCredentials() error {
+	u, err := url.Parse(service.url)
+	if err != nil {
+		return monitorerrors.NewValidationFailed("Unable to parse API URL", err)
+	}
+	u.Path = "/v1/uptime/"
+	u.Scheme = "https"
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return monitorerrors.NewValidationFailed("Unable to create http request", err)
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", service.apiKey))
+
+	start := time.Now()
+	resp, err := service.client.Do(req)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationVerify, resp, start)
+	if err != nil {
+		return monitorerrors.NewRetryable("Unable to make HTTP call", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return monitorerrors.FromStatusCode(resp.StatusCode, "List Request failed")
+	}
+	return nil
 }
 
 // GetByName function will Get a monitor by it's name
 func (service *StatusCakeMonitorService) GetByName(name string) (*models.Monitor, error) {
-	monitors := service.GetAll()
+	monitors, err := service.list()
+	if err != nil {
+		return nil, err
+	}
 	if len(monitors) != 0 {
 		for _, monitor := range monitors {
 			if monitor.Name == name {
-				return &monitor, nil
+				// The list of uptime tests only holds a summary of each test so the full test is retrieved
+				fullMonitor, err := service.GetByID(monitor.ID)
+				if err != nil {
+					return &monitor, nil
+				}
+				return fullMonitor, nil
 			}
 		}
 	}
-	errorString := "GetByName Request failed for name: " + name
-	return nil, errors.New(errorString)
+	return nil, monitorerrors.NewNotFound("Unable to locate monitor with name "+name, nil)
 
 }
 
@@ -242,26 +410,30 @@ func (service *StatusCakeMonitorService) GetByID(id string) (*models.Monitor, er
 	u, err := url.Parse(service.url)
 	if err != nil {
 		log.Error(err, "Unable to Parse monitor URL")
-		return nil, err
+		return nil, monitorerrors.NewValidationFailed("Unable to parse API URL", err)
 	}
 	u.Path = fmt.Sprintf("/v1/uptime/%s", id)
 	u.Scheme = "https"
 	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
 		log.Error(err, "Unable to retrieve monitor")
-		return nil, err
+		return nil, monitorerrors.NewValidationFailed("Unable to create http request", err)
 	}
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", service.apiKey))
 
+	start := time.Now()
 	resp, err := service.client.Do(req)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationGet, resp, start)
 	if err != nil {
 		log.Error(err, "Unable to retrieve monitor")
-		return nil, err
+		return nil, monitorerrors.NewRetryable("Unable to make HTTP call", err)
 	}
+	defer resp.Body.Close()
 
 	BodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Error(err, "Unable to read response body")
+		return nil, monitorerrors.NewRetryable("Unable to read response body", err)
 	}
 	bodyString := string(BodyBytes)
 
@@ -273,64 +445,130 @@ func (service *StatusCakeMonitorService) GetByID(id string) (*models.Monitor, er
 		err = json.Unmarshal(BodyBytes, &StatusCakeMonitorData)
 		if err != nil {
 			log.Error(err, "Unable to unmarshal response")
-			return nil, err
+			return nil, monitorerrors.NewRetryable("Unable to unmarshal response", err)
 		}
 		return StatusCakeApiResponseDataToBaseMonitorMapper(StatusCakeMonitorData), nil
 	}
 	log.Info(fmt.Sprintf("Request failed with response: %s for id: %s", bodyString, id))
 
-	return nil, errors.New("GetByID Request failed")
+	return nil, monitorerrors.FromStatusCode(resp.StatusCode, "GetByID Request failed for id: "+id)
+}
+
+// GetCheckStatus returns the status of the uptime test, its latest response time and its uptime as reported by
+// StatusCake
+func (service *StatusCakeMonitorService) GetCheckStatus(m models.Monitor) (*models.CheckStatus, error) {
+	monitor, err := service.GetByID(m.ID)
+	if err != nil {
+		return nil, monitorerrors.NewRetryable("Unable to retrieve uptime test: "+m.Name, err)
+	}
+	statuscakeData, ok := monitor.Config.(*StatusCakeData)
+	if !ok {
+		return nil, monitorerrors.NewRetryable("Unable to retrieve uptime test: "+m.Name, nil)
+	}
+	status := StatusCakeDataToCheckStatusMapper(*statuscakeData)
+
+	// The status is still reported if the history can't be retrieved
+	status.ResponseTime, err = service.getLastResponseTime(m.ID)
+	if err != nil {
+		log.Info("Unable to retrieve history of uptime test: " + m.Name + ". Error: " + err.Error())
+	}
+	return status, nil
+}
+
+// getLastResponseTime returns the load time of the latest result of the uptime test, nil if it hasn't run yet
+func (service *StatusCakeMonitorService) getLastResponseTime(id string) (*time.Duration, error) {
+	u, err := url.Parse(service.url)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = fmt.Sprintf("/v1/uptime/%s/history", id)
+	u.Scheme = "https"
+	u.RawQuery = url.Values{"limit": []string{"1"}}.Encode()
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", service.apiKey))
+
+	start := time.Now()
+	resp, err := service.client.Do(req)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationGet, resp, start)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, monitorerrors.FromStatusCode(resp.StatusCode, "History Request failed for id: "+id)
+	}
+
+	var history statuscake.UptimeTestHistory
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		return nil, err
+	}
+	if len(history.Data) == 0 || history.Data[0].Performance == nil {
+		return nil, nil
+	}
+	responseTime := time.Duration(*history.Data[0].Performance) * time.Millisecond
+	return &responseTime, nil
 }
 
 // GetAll function will fetch all monitors
 func (service *StatusCakeMonitorService) GetAll() []models.Monitor {
-	u, err := url.Parse(service.url)
+	monitors, err := service.list()
 	if err != nil {
-		log.Error(err, "Unable to Parse monitor URL")
+		log.Error(err, "Unable to retrieve monitors")
 		return nil
 	}
+	return monitors
+}
+
+// list returns the uptime tests of the account, or the error of the request if they can't be listed
+func (service *StatusCakeMonitorService) list() ([]models.Monitor, error) {
+	u, err := url.Parse(service.url)
+	if err != nil {
+		return nil, monitorerrors.NewValidationFailed("Unable to parse API URL", err)
+	}
 	u.Path = "/v1/uptime/"
 	u.Scheme = "https"
 	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
-		log.Error(err, "Unable to retrieve monitor")
-		return nil
+		return nil, monitorerrors.NewValidationFailed("Unable to create http request", err)
 	}
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", service.apiKey))
 
+	start := time.Now()
 	resp, err := service.client.Do(req)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationList, resp, start)
 	if err != nil {
-		log.Error(err, "Unable to retrieve monitor")
-		return nil
+		return nil, monitorerrors.NewRetryable("Unable to make HTTP call", err)
 	}
+	defer resp.Body.Close()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Error(err, "Unable to read response body")
-		return nil
+		return nil, monitorerrors.NewRetryable("Unable to read response body", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, monitorerrors.FromStatusCode(resp.StatusCode, "List Request failed")
 	}
 
-	if resp.StatusCode == http.StatusOK {
-		var StatusCakeMonitor StatusCakeMonitor
-		var StatusCakeMonitorData []StatusCakeMonitorData
-		err = json.Unmarshal(bodyBytes, &StatusCakeMonitor)
-		if err != nil {
-			log.Error(err, "Failed to unmarshal response")
-			return nil
-		}
-
-		StatusCakeMonitorData = append(StatusCakeMonitorData, StatusCakeMonitor.StatusCakeData...)
-		return StatusCakeMonitorMonitorsToBaseMonitorsMapper(StatusCakeMonitorData)
+	var StatusCakeMonitor StatusCakeMonitor
+	var StatusCakeMonitorData []StatusCakeMonitorData
+	err = json.Unmarshal(bodyBytes, &StatusCakeMonitor)
+	if err != nil {
+		return nil, monitorerrors.NewRetryable("Failed to unmarshal response", err)
 	}
-	return nil
+
+	StatusCakeMonitorData = append(StatusCakeMonitorData, StatusCakeMonitor.StatusCakeData...)
+	return StatusCakeMonitorMonitorsToBaseMonitorsMapper(StatusCakeMonitorData), nil
 }
 
 // Add will create a new Monitor
-func (service *StatusCakeMonitorService) Add(m models.Monitor) {
+func (service *StatusCakeMonitorService) Add(m models.Monitor) error {
 	u, err := url.Parse(service.url)
 	if err != nil {
 		log.Error(err, "Unable to Parse monitor URL")
-		return
+		return monitorerrors.NewValidationFailed("Unable to parse API URL", err)
 	}
 	u.Path = "/v1/uptime"
 	u.Scheme = "https"
@@ -338,67 +576,79 @@ func (service *StatusCakeMonitorService) Add(m models.Monitor) {
 	req, err := http.NewRequest("POST", u.String(), bytes.NewBufferString(data.Encode()))
 	if err != nil {
 		log.Error(err, "Unable to create http request")
-		return
+		return monitorerrors.NewValidationFailed("Unable to create http request", err)
 	}
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", service.apiKey))
+	start := time.Now()
 	resp, err := service.client.Do(req)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationCreate, resp, start)
 	if err != nil {
 		log.Error(err, "Unable to make HTTP call")
-		return
+		return monitorerrors.NewRetryable("Unable to make HTTP call", err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusCreated {
 		log.Info("Monitor Added: " + m.Name)
-	} else {
-		bodyBytes, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			log.Error(err, "Unable to read response")
-			os.Exit(1)
-		}
-		log.Error(nil, "Insert Request failed for name: "+m.Name+" with status code "+strconv.Itoa(resp.StatusCode))
-		log.Error(nil, string(bodyBytes))
+		return nil
 	}
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Error(err, "Unable to read response")
+	}
+	log.Error(nil, "Insert Request failed for name: "+m.Name+" with status code "+strconv.Itoa(resp.StatusCode))
+	log.Error(nil, string(bodyBytes))
+	return monitorerrors.FromStatusCode(resp.StatusCode, "Insert Request failed for name: "+m.Name+": "+string(bodyBytes))
 }
 
 // Update will update an existing Monitor
-func (service *StatusCakeMonitorService) Update(m models.Monitor) {
+func (service *StatusCakeMonitorService) Update(m models.Monitor) error {
 	u, err := url.Parse(service.url)
 	if err != nil {
 		log.Error(err, "Unable to Parse monitor URL")
-		return
+		return monitorerrors.NewValidationFailed("Unable to parse API URL", err)
 	}
 	u.Path = fmt.Sprintf("/v1/uptime/%s", m.ID)
 	u.Scheme = "https"
 	data := buildUpsertForm(m, service.cgroup)
+	// StatusCake keeps the settings missing from the form, a removed keyword has to be cleared
+	for key, value := range keywordDefaults {
+		if _, ok := data[key]; !ok {
+			data.Set(key, value)
+		}
+	}
 	req, err := http.NewRequest("PUT", u.String(), bytes.NewBufferString(data.Encode()))
 	if err != nil {
 		log.Error(err, "Unable to create http request")
-		return
+		return monitorerrors.NewValidationFailed("Unable to create http request", err)
 	}
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", service.apiKey))
+	start := time.Now()
 	resp, err := service.client.Do(req)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationUpdate, resp, start)
 	if err != nil {
 		log.Error(err, "Unable to make HTTP call")
-		return
+		return monitorerrors.NewRetryable("Unable to make HTTP call", err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusNoContent {
 		log.Info("Monitor Updated: " + m.ID)
-	} else {
-		bodyBytes, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			log.Error(err, "Unable to read response")
-			os.Exit(1)
-		}
-		log.Error(nil, "Update Request failed for name: "+m.Name+" with status code "+strconv.Itoa(resp.StatusCode))
-		log.Error(nil, string(bodyBytes))
+		return nil
+	}
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Error(err, "Unable to read response")
 	}
+	log.Error(nil, "Update Request failed for name: "+m.Name+" with status code "+strconv.Itoa(resp.StatusCode))
+	log.Error(nil, string(bodyBytes))
+	return monitorerrors.FromStatusCode(resp.StatusCode, "Update Request failed for name: "+m.Name+": "+string(bodyBytes))
 }
 
 // Remove will delete an existing Monitor
-func (service *StatusCakeMonitorService) Remove(m models.Monitor) {
+func (service *StatusCakeMonitorService) Remove(m models.Monitor) error {
 	u, err := url.Parse(service.url)
 	if err != nil {
 		log.Error(err, "Unable to Parse monitor URL")
-		return
+		return monitorerrors.NewValidationFailed("Unable to parse API URL", err)
 	}
 	u.Path = fmt.Sprintf("/v1/uptime/%s", m.ID)
 	u.Scheme = "https"
@@ -406,23 +656,25 @@ func (service *StatusCakeMonitorService) Remove(m models.Monitor) {
 	req, err := http.NewRequest("DELETE", u.String(), nil)
 	if err != nil {
 		log.Error(err, "Unable to create http request")
-		return
+		return monitorerrors.NewValidationFailed("Unable to create http request", err)
 	}
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", service.apiKey))
+	start := time.Now()
 	resp, err := service.client.Do(req)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationDelete, resp, start)
 	if err != nil {
 		log.Error(err, "Unable to make HTTP call")
-		return
+		return monitorerrors.NewRetryable("Unable to make HTTP call", err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusNoContent {
 		log.Error(nil, fmt.Sprintf("Delete Request failed for Monitor: %s with id: %s", m.Name, m.ID))
-
-	} else {
-		_, err = service.GetByID(m.ID)
-		if strings.Contains(err.Error(), "Request failed") {
-			log.Info("Monitor Deleted: " + m.ID)
-		} else {
-			log.Error(nil, fmt.Sprintf("Delete Request failed for Monitor: %s with id: %s", m.Name, m.ID))
-		}
+		return monitorerrors.FromStatusCode(resp.StatusCode, fmt.Sprintf("Delete Request failed for Monitor: %s with id: %s", m.Name, m.ID))
+	}
+	if _, err = service.GetByID(m.ID); err == nil {
+		log.Error(nil, fmt.Sprintf("Delete Request failed for Monitor: %s with id: %s", m.Name, m.ID))
+		return monitorerrors.NewRetryable(fmt.Sprintf("Monitor %s with id %s still exists after deletion", m.Name, m.ID), nil)
 	}
+	log.Info("Monitor Deleted: " + m.ID)
+	return nil
 }
@@ -1,7 +1,11 @@
 package statuscake
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,6 +14,7 @@ import (
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
 	"github.com/stakater/IngressMonitorController/v2/pkg/util"
 	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestAddMonitorWithCorrectValues(t *testing.T) {
@@ -134,3 +139,157 @@ func TestBuildUpsertForm(t *testing.T) {
 	assert.Equal(t, "TCP", vals.Get("test_type"))
 	assert.Equal(t, "1", vals.Get("trigger_rate"))
 }
+
+func TestBuildUpsertFormWithCheck(t *testing.T) {
+	m := models.Monitor{Name: "google-test", URL: "https://google.com"}
+	m.Check = &endpointmonitorv1alpha1.CheckConfig{
+		Interval:            &metav1.Duration{Duration: 2 * time.Minute},
+		Timeout:             &metav1.Duration{Duration: 20 * time.Second},
+		ExpectedStatusCodes: []endpointmonitorv1alpha1.StatusCodeRange{"200-299", "401"},
+		Keyword:             &endpointmonitorv1alpha1.KeywordCheck{Value: "maintenance", Condition: endpointmonitorv1alpha1.KeywordAbsent},
+		Headers:             map[string]string{"X-Test": "true"},
+		BasicAuth:           &endpointmonitorv1alpha1.BasicAuth{Username: "checkuser"},
+	}
+	m.BasicAuthPassword = "checkpass"
+
+	vals := buildUpsertForm(m, "")
+	assert.Equal(t, "checkuser", vals.Get("basic_username"))
+	assert.Equal(t, "checkpass", vals.Get("basic_password"))
+	assert.Equal(t, "60", vals.Get("check_rate"))
+	assert.Equal(t, "20", vals.Get("timeout"))
+	assert.Equal(t, "HTTP", vals.Get("test_type"))
+	assert.Equal(t, "maintenance", vals.Get("find_string"))
+	assert.Equal(t, "true", vals.Get("do_not_find"))
+	assert.Equal(t, `{"X-Test":"true"}`, vals.Get("custom_header"))
+
+	statusCodes := strings.Split(vals.Get("status_codes_csv"), ",")
+	for _, code := range []string{"301", "400", "404", "500", "520"} {
+		assert.Assert(t, util.ContainsString(statusCodes, code), "status code %s should trigger an error", code)
+	}
+	for _, code := range []string{"200", "204", "401"} {
+		assert.Assert(t, !util.ContainsString(statusCodes, code), "status code %s should not trigger an error", code)
+	}
+
+	// Provider specific configuration takes precedence over the check
+	m.Config = &endpointmonitorv1alpha1.StatusCakeConfig{CheckRate: 300, StatusCodes: "500"}
+	vals = buildUpsertForm(m, "")
+	assert.Equal(t, "300", vals.Get("check_rate"))
+	assert.Equal(t, "500", vals.Get("status_codes_csv"))
+
+	m.Config = nil
+	m.Check = &endpointmonitorv1alpha1.CheckConfig{Type: endpointmonitorv1alpha1.CheckTypeTCP}
+	vals = buildUpsertForm(m, "")
+	assert.Equal(t, "TCP", vals.Get("test_type"))
+	assert.Equal(t, "443", vals.Get("port"))
+}
+
+func TestEqual(t *testing.T) {
+	service := StatusCakeMonitorService{cgroup: "654321,123456"}
+	m := models.Monitor{Name: "google-test", URL: "https://google.com"}
+	m.Check = &endpointmonitorv1alpha1.CheckConfig{
+		Interval: &metav1.Duration{Duration: 5 * time.Minute},
+		Timeout:  &metav1.Duration{Duration: 20 * time.Second},
+		Keyword:  &endpointmonitorv1alpha1.KeywordCheck{Value: "maintenance", Condition: endpointmonitorv1alpha1.KeywordAbsent},
+		Headers:  map[string]string{"X-Test": "true", "Accept": "text/html"},
+	}
+
+	findString := "maintenance"
+	customHeader := `{"X-Test":"true","Accept":"text/html"}`
+	statusCodes := append([]string{}, defaultStatusCodes...)
+	sort.Sort(sort.Reverse(sort.StringSlice(statusCodes)))
+	remote := StatusCakeData{}
+	remote.Name = "google-test"
+	remote.WebsiteURL = "https://google.com"
+	remote.CheckRate = 300
+	remote.TestType = "HTTP"
+	remote.ContactGroups = []string{"123456", "654321"}
+	remote.StatusCodes = statusCodes
+	remote.TriggerRate = 5
+	remote.Confirmation = 2
+	remote.Timeout = 20
+	remote.FindString = &findString
+	remote.DoNotFind = true
+	remote.CustomHeader = &customHeader
+	oldMonitor := models.Monitor{Name: "google-test", URL: "https://google.com", ID: "1", Config: &remote}
+
+	assert.Assert(t, service.Equal(oldMonitor, m), "monitor without changes should be equal")
+
+	// Settings which are not part of the form are not compared
+	remote.Paused = true
+	remote.Tags = []string{"production"}
+	assert.Assert(t, service.Equal(oldMonitor, m), "settings unmanaged by the monitor should be ignored")
+
+	m.Check.Interval = &metav1.Duration{Duration: time.Minute}
+	assert.Assert(t, !service.Equal(oldMonitor, m), "changed check rate should not be equal")
+	m.Check.Interval = &metav1.Duration{Duration: 5 * time.Minute}
+
+	m.Check.Keyword.Condition = endpointmonitorv1alpha1.KeywordPresent
+	assert.Assert(t, !service.Equal(oldMonitor, m), "changed keyword condition should not be equal")
+	m.Check.Keyword.Condition = endpointmonitorv1alpha1.KeywordAbsent
+
+	keyword := m.Check.Keyword
+	m.Check.Keyword = nil
+	assert.Assert(t, !service.Equal(oldMonitor, m), "removed keyword should not be equal")
+	remote.FindString = nil
+	remote.DoNotFind = false
+	assert.Assert(t, service.Equal(oldMonitor, m), "monitor without keyword should be equal")
+	m.Check.Keyword = keyword
+	assert.Assert(t, !service.Equal(oldMonitor, m), "added keyword should not be equal")
+	remote.FindString = &findString
+	remote.DoNotFind = true
+
+	m.Config = &endpointmonitorv1alpha1.StatusCakeConfig{ContactGroup: "123456"}
+	assert.Assert(t, !service.Equal(oldMonitor, m), "changed contact groups should not be equal")
+
+	// Monitors retrieved without their full configuration are never equal
+	assert.Assert(t, !service.Equal(models.Monitor{Name: "google-test", URL: "https://google.com"}, m))
+}
+
+func TestGetByNameRetrievesFullTest(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/uptime/":
+			w.Write([]byte(`{"data":[{"id":"1","name":"google-test","website_url":"https://google.com","test_type":"HTTP","check_rate":300}],"metadata":{"page":1,"per_page":25,"page_count":1,"total_count":1}}`))
+		case "/v1/uptime/1":
+			w.Write([]byte(`{"data":{"id":"1","name":"google-test","website_url":"https://google.com","test_type":"HTTP","check_rate":300,"confirmation":2,"contact_groups":["123456"],"dns_ips":[],"do_not_find":false,"enable_ssl_alert":false,"follow_redirects":false,"include_header":false,"paused":false,"processing":false,"servers":[],"status":"up","status_codes":["500"],"tags":[],"timeout":15,"trigger_rate":0,"uptime":100,"use_jar":false}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service := StatusCakeMonitorService{url: server.URL, client: server.Client()}
+	monitor, err := service.GetByName("google-test")
+	assert.NilError(t, err)
+	assert.Equal(t, "1", monitor.ID)
+
+	statuscakeData, ok := monitor.Config.(*StatusCakeData)
+	assert.Assert(t, ok, "monitor should hold the full uptime test")
+	assert.DeepEqual(t, []string{"123456"}, statuscakeData.ContactGroups)
+	assert.Equal(t, int32(15), statuscakeData.Timeout)
+}
+
+func TestGetCheckStatus(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/uptime/1":
+			w.Write([]byte(`{"data":{"id":"1","name":"google-test","website_url":"https://google.com","test_type":"HTTP","check_rate":300,"confirmation":2,"contact_groups":[],"dns_ips":[],"do_not_find":false,"enable_ssl_alert":false,"follow_redirects":false,"include_header":false,"paused":false,"processing":false,"servers":[],"status":"up","status_codes":[],"tags":[],"timeout":15,"trigger_rate":0,"uptime":99.5,"use_jar":false}}`))
+		case "/v1/uptime/1/history":
+			assert.Equal(t, "1", r.URL.Query().Get("limit"))
+			w.Write([]byte(`{"data":[{"created_at":"2022-05-01T10:00:00Z","location":"UK1","performance":120,"status_code":200}],"links":{"self":"https://api.statuscake.com/v1/uptime/1/history"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service := StatusCakeMonitorService{url: server.URL, client: server.Client()}
+	status, err := service.GetCheckStatus(models.Monitor{Name: "google-test", ID: "1"})
+	assert.NilError(t, err)
+	assert.Equal(t, models.CheckStateUp, status.State)
+	assert.Assert(t, status.ResponseTime != nil && *status.ResponseTime == 120*time.Millisecond)
+	assert.Assert(t, status.UptimeRatio != nil && *status.UptimeRatio == 0.995)
+
+	_, err = service.GetCheckStatus(models.Monitor{Name: "missing", ID: "2"})
+	assert.Assert(t, err != nil, "status of a missing uptime test should fail")
+}
@@ -30,7 +30,7 @@ type StatusCakeMonitorMetadata struct {
 	TotalCount int `json:"total_count"`
 }
 
-// TODO use statuscake managed structs, rather than managing own structs
+// StatusCakeData holds the full configuration of an uptime test, it is the Config of monitors retrieved by ID
 type StatusCakeData struct {
 	statuscake.UptimeTest
 }
@@ -5,13 +5,18 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/antoineaugusti/updown"
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	providerhttp "github.com/stakater/IngressMonitorController/v2/pkg/http"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
+	"github.com/stakater/IngressMonitorController/v2/pkg/util"
 )
 
 const (
@@ -23,6 +28,12 @@ const (
 
 var log = logf.Log.WithName("updown")
 
+// providerType labels the metrics of the API calls
+const providerType = "Updown"
+
+// updownPeriods are the check intervals in seconds supported by updown
+var updownPeriods = []int{15, 30, 60, 120, 300, 600, 1800, 3600}
+
 // UpdownMonitorService struct contains parameters required by updown go client
 type UpdownMonitorService struct {
 	apiKey string
@@ -35,7 +46,7 @@ func (monitor *UpdownMonitorService) Equal(oldMonitor models.Monitor, newMonitor
 }
 
 // Setup method will initialize a updown's go client object by using the configuration parameters
-func (updownService *UpdownMonitorService) Setup(confProvider config.Provider) {
+func (updownService *UpdownMonitorService) Setup(confProvider config.Provider) error {
 
 	// initializeCustomLog(os.Stdout)
 	log.Info("Updown monitor's Setup has been called. Updown monitor initializing")
@@ -44,8 +55,20 @@ func (updownService *UpdownMonitorService) Setup(confProvider config.Provider) {
 	updownService.apiKey = confProvider.ApiKey
 
 	// creating updown go client
-	updownService.client = updown.NewClient(updownService.apiKey, http.DefaultClient)
+	updownService.client = updown.NewClient(updownService.apiKey, providerhttp.NewClient(confProvider.HTTP))
 	log.Info("Updown monitor has been initialized")
+	return nil
+}
+
+// VerifyCredentials checks the API key by listing the checks
+func (updownService *UpdownMonitorService) VerifyCredentials() error {
+	start := time.Now()
+	_, httpResponse, err := updownService.client.Check.List()
+	metrics.ObserveProviderResponse(providerType, metrics.OperationVerify, httpResponse, start)
+	if err != nil || httpResponse == nil || httpResponse.StatusCode != http.StatusOK {
+		return updownError(httpResponse, err, "Unable to list checks")
+	}
+	return nil
 }
 
 // GetAll function will return all monitors (updown checks) object in an array
@@ -53,13 +76,25 @@ func (updownService *UpdownMonitorService) GetAll() []models.Monitor {
 
 	log.Info("Updown monitor's GetAll method has been called")
 
+	monitors, err := updownService.list()
+	if err != nil {
+		log.Info("Unable to get updown provider checks(monitor) list: " + err.Error())
+		return nil
+	}
+	return monitors
+}
+
+// list returns the checks of the account, or the error of the request if they can't be listed
+func (updownService *UpdownMonitorService) list() ([]models.Monitor, error) {
 	var monitors []models.Monitor
 
 	// getting all monitors(checks) list
+	start := time.Now()
 	updownChecks, httpResponse, err := updownService.client.Check.List()
+	metrics.ObserveProviderResponse(providerType, metrics.OperationList, httpResponse, start)
 	log.Info("Monitors (updown checks) object list has been pulled")
 
-	if (httpResponse.StatusCode == http.StatusOK) && (err == nil) {
+	if httpResponse != nil && httpResponse.StatusCode == http.StatusOK && err == nil {
 		log.Info("Populating monitors list using the updownChecks object given in updownChecks list")
 
 		// populating a monitors slice using the updownChecks objects given in updownChecks slice
@@ -71,14 +106,9 @@ func (updownService *UpdownMonitorService) GetAll() []models.Monitor {
 			}
 			monitors = append(monitors, newMonitor)
 		}
-		return monitors
-
-	} else {
-		log.Info("Unable to get updown provider checks(monitor) list")
-		return nil
-
+		return monitors, nil
 	}
-
+	return nil, updownError(httpResponse, err, "Unable to get updown provider checks(monitor) list")
 }
 
 // GetByName function will return a monitor(updown check) object based on the name provided
@@ -86,8 +116,10 @@ func (updownService *UpdownMonitorService) GetByName(monitorName string) (*model
 
 	log.Info("Updown monitor's GetByName method has been called")
 
-	updownMonitors := updownService.GetAll()
-	log.Info("Monitors (updown checks) object list has been pulled")
+	updownMonitors, err := updownService.list()
+	if err != nil {
+		return nil, err
+	}
 
 	log.Info("Searching the monitor from monitors object list using its name")
 	for _, updownMonitor := range updownMonitors {
@@ -97,30 +129,57 @@ func (updownService *UpdownMonitorService) GetByName(monitorName string) (*model
 		}
 	}
 
-	return nil, fmt.Errorf("unable to locate %v monitor", monitorName)
+	return nil, monitorerrors.NewNotFound(fmt.Sprintf("unable to locate %v monitor", monitorName), nil)
+}
+
+// GetByID retrieves the check with the given token
+func (updownService *UpdownMonitorService) GetByID(id string) (*models.Monitor, error) {
+	start := time.Now()
+	updownCheck, httpResponse, err := updownService.client.Check.Get(id)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationGet, httpResponse, start)
+	if httpResponse == nil || httpResponse.StatusCode != http.StatusOK || err != nil {
+		return nil, updownError(httpResponse, err, fmt.Sprintf("Unable to get check %s", id))
+	}
+	return &models.Monitor{
+		URL:  updownCheck.URL,
+		Name: updownCheck.Alias,
+		ID:   updownCheck.Token,
+	}, nil
 }
 
 // Add function method will add a monitor (updown check)
-func (service *UpdownMonitorService) Add(updownMonitor models.Monitor) {
+func (service *UpdownMonitorService) Add(updownMonitor models.Monitor) error {
 
 	log.Info("Updown monitor's Add method has been called")
 
 	updownCheckItemObj := service.createHttpCheck(updownMonitor)
 
+	start := time.Now()
 	_, httpResponse, err := service.client.Check.Add(updownCheckItemObj)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationCreate, httpResponse, start)
 	log.Info("Monitor addition request has been completed")
 
-	if (httpResponse.StatusCode == http.StatusCreated) && (err == nil) {
+	if err == nil && httpResponse.StatusCode == http.StatusCreated {
 		log.Info(fmt.Sprintf("Monitor %s has been added.", updownMonitor.Name))
-
-	} else if (httpResponse.StatusCode == http.StatusBadRequest) && (err != nil) {
+		return nil
+	}
+	if httpResponse != nil && httpResponse.StatusCode == http.StatusBadRequest {
 		log.Info(fmt.Sprintf("Monitor %s is not created because of invalid parameters or it exists.", updownMonitor.Name))
-
 	} else {
 		log.Info(fmt.Sprintf("Unable to create monitor %s ", updownMonitor.Name))
-
 	}
+	return updownError(httpResponse, err, fmt.Sprintf("Unable to create monitor %s", updownMonitor.Name))
+}
 
+// updownError maps the response of a failed updown request to a monitor error
+func updownError(httpResponse *http.Response, err error, message string) error {
+	if httpResponse == nil {
+		return monitorerrors.NewRetryable(message, err)
+	}
+	if err != nil {
+		message = message + ": " + err.Error()
+	}
+	return monitorerrors.FromStatusCode(httpResponse.StatusCode, message)
 }
 
 // createHttpCheck method it will populate updown CheckItem object using updownMonitor's attributes
@@ -146,13 +205,13 @@ func (updownService *UpdownMonitorService) createHttpCheck(updownMonitor models.
 	updownCheckItemObj.Alias = updownMonitor.Name
 
 	// populating updownCheckItemObj object attributes using Provider Config
-	updownService.addConfigToHttpCheck(&updownCheckItemObj, updownMonitor.Config)
+	updownService.addConfigToHttpCheck(&updownCheckItemObj, updownMonitor.Config, updownMonitor.Check)
 
 	return updownCheckItemObj
 }
 
 // addConfigToHttpCheck method will populate Updown's CheckItem object attributes using provider config
-func (service *UpdownMonitorService) addConfigToHttpCheck(updownCheckItemObj *updown.CheckItem, config interface{}) {
+func (service *UpdownMonitorService) addConfigToHttpCheck(updownCheckItemObj *updown.CheckItem, config interface{}, check *endpointmonitorv1alpha1.CheckConfig) {
 	// Read provider config, try to map them to updown check configs
 	// set some default values if we can't find them
 
@@ -175,47 +234,67 @@ func (service *UpdownMonitorService) addConfigToHttpCheck(updownCheckItemObj *up
 
 	if providerConfig != nil && providerConfig.Period > 0 {
 		updownCheckItemObj.Period = providerConfig.Period
+	} else if check.GetIntervalSeconds() > 0 {
+		updownCheckItemObj.Period = util.ClosestInt(updownPeriods, check.GetIntervalSeconds())
 	} else {
 		log.Info("Using default value `15` for period")
 		updownCheckItemObj.Period = UpdownPeriodDefaultValue
 	}
+
+	if check == nil {
+		return
+	}
+
+	if check.GetType() == endpointmonitorv1alpha1.CheckTypeKeyword && check.Keyword != nil {
+		if check.Keyword.IsKeywordAbsent() {
+			log.Info("Absent keywords are not supported by updown, ignoring keyword: " + check.Keyword.Value)
+		} else {
+			updownCheckItemObj.StringMatch = check.Keyword.Value
+		}
+	}
+
+	if len(check.Headers) != 0 {
+		updownCheckItemObj.CustomHeaders = check.Headers
+	}
 }
 
 // Update method will update a monitor (updown check)
-func (service *UpdownMonitorService) Update(updownMonitor models.Monitor) {
+func (service *UpdownMonitorService) Update(updownMonitor models.Monitor) error {
 
 	log.Info("Updown's Update method has been called")
 
 	httpCheckItemObj := service.createHttpCheck(updownMonitor)
+	start := time.Now()
 	_, httpResponse, err := service.client.Check.Update(updownMonitor.ID, httpCheckItemObj)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationUpdate, httpResponse, start)
 	log.Info("Updown's check Update request has been completed")
 
-	if (httpResponse.StatusCode == http.StatusOK) && (err == nil) {
+	if err == nil && httpResponse.StatusCode == http.StatusOK {
 		log.Info(fmt.Sprintf("Monitor %s has been updated with following parameters", updownMonitor.Name))
-
-	} else {
-		log.Info(fmt.Sprintf("Monitor %s is not updated because of %s", updownMonitor.Name, err.Error()))
-
+		return nil
 	}
-
+	log.Info(fmt.Sprintf("Monitor %s is not updated because of %v", updownMonitor.Name, err))
+	return updownError(httpResponse, err, fmt.Sprintf("Monitor %s is not updated", updownMonitor.Name))
 }
 
 // Remove method will remove a monitor (updown check)
-func (updownService *UpdownMonitorService) Remove(updownMonitor models.Monitor) {
+func (updownService *UpdownMonitorService) Remove(updownMonitor models.Monitor) error {
 
 	log.Info("Updown's Remove method has been called")
 
+	start := time.Now()
 	_, httpResponse, err := updownService.client.Check.Remove(updownMonitor.ID)
+	metrics.ObserveProviderResponse(providerType, metrics.OperationDelete, httpResponse, start)
 	log.Info("Updown's check Remove request has been completed")
 
-	if (httpResponse.StatusCode == http.StatusOK) && (err == nil) {
+	if err == nil && httpResponse.StatusCode == http.StatusOK {
 		log.Info(fmt.Sprintf("Monitor %v has been deleted.", updownMonitor.Name))
-
-	} else if (httpResponse.StatusCode == http.StatusNotFound) && (err != nil) {
+		return nil
+	}
+	if httpResponse != nil && httpResponse.StatusCode == http.StatusNotFound {
 		log.Info(fmt.Sprintf("Monitor %v is not found.", updownMonitor.Name))
-
 	} else {
-		log.Info("Unable to delete %v monitor: " + updownMonitor.Name)
+		log.Info("Unable to delete monitor: " + updownMonitor.Name)
 	}
-
+	return updownError(httpResponse, err, fmt.Sprintf("Unable to delete monitor %v", updownMonitor.Name))
 }
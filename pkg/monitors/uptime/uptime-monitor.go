@@ -2,7 +2,6 @@ package uptime
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"reflect"
 	"sort"
@@ -19,17 +18,23 @@ import (
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
 	"github.com/stakater/IngressMonitorController/v2/pkg/http"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
 	"github.com/stakater/IngressMonitorController/v2/pkg/util"
 )
 
 var cache = gocache.New(5*time.Minute, 5*time.Minute)
 var log = logf.Log.WithName("uptime-monitor")
 
+// providerType labels the metrics of the API calls
+const providerType = "Uptime"
+
 type UpTimeMonitorService struct {
 	apiKey        string
 	url           string
 	alertContacts string
+	client        *Http.Client
 }
 
 func (monitor *UpTimeMonitorService) Equal(oldMonitor models.Monitor, newMonitor models.Monitor) bool {
@@ -43,15 +48,34 @@ func (monitor *UpTimeMonitorService) Equal(oldMonitor models.Monitor, newMonitor
 	return true
 }
 
-func (monitor *UpTimeMonitorService) Setup(p config.Provider) {
+func (monitor *UpTimeMonitorService) Setup(p config.Provider) error {
 	monitor.apiKey = p.ApiKey
 	monitor.url = p.ApiURL
 	monitor.alertContacts = p.AlertContacts
+	monitor.client = http.NewClient(p.HTTP)
+	return nil
+}
+
+// VerifyCredentials checks the API key by retrieving the first page of checks
+func (monitor *UpTimeMonitorService) VerifyCredentials() error {
+	headers := make(map[string]string)
+	headers["Authorization"] = "Token " + monitor.apiKey
+	headers["Content-Type"] = "application/json"
+
+	client := http.CreateProviderHttpClient(monitor.client, fmt.Sprintf("%schecks/?page=1", monitor.url), providerType, metrics.OperationVerify)
+	response := client.GetUrl(headers, []byte(""))
+	if response.StatusCode != Http.StatusOK {
+		return monitorerrors.FromStatusCode(response.StatusCode, "GetAllMonitors Request for Uptime failed")
+	}
+	return nil
 }
 
 func (monitor *UpTimeMonitorService) GetByName(name string) (*models.Monitor, error) {
 
-	monitors := monitor.GetAll()
+	monitors, err := monitor.list()
+	if err != nil {
+		return nil, err
+	}
 
 	for _, monitor := range monitors {
 		if monitor.Name == name {
@@ -61,11 +85,20 @@ func (monitor *UpTimeMonitorService) GetByName(name string) (*models.Monitor, er
 
 	errorString := name + " not found"
 	log.Info(errorString)
-	return nil, errors.New(errorString)
+	return nil, monitorerrors.NewNotFound(errorString, nil)
 }
 
 func (monitor *UpTimeMonitorService) GetAll() []models.Monitor {
+	monitors, err := monitor.list()
+	if err != nil {
+		log.Info(err.Error())
+		return nil
+	}
+	return monitors
+}
 
+// list returns the checks of the account, or the error of the request if they can't be listed
+func (monitor *UpTimeMonitorService) list() ([]models.Monitor, error) {
 	var monitors []UptimeMonitorMonitor
 	headers := make(map[string]string)
 	headers["Authorization"] = "Token " + monitor.apiKey
@@ -76,37 +109,36 @@ func (monitor *UpTimeMonitorService) GetAll() []models.Monitor {
 
 	cached, found := cache.Get("uptime-checks")
 	if found {
-		return UptimeMonitorMonitorsToBaseMonitorsMapper(cached.([]UptimeMonitorMonitor))
+		return UptimeMonitorMonitorsToBaseMonitorsMapper(cached.([]UptimeMonitorMonitor)), nil
 	}
 
 	// Loop over paginated response until Next is null
 	for next != nil {
 		var f UptimeMonitorGetMonitorsResponse
 		checksUrl := fmt.Sprintf("%schecks/?page=%d", monitor.url, pageNo)
-		client := http.CreateHttpClient(checksUrl)
+		client := http.CreateProviderHttpClient(monitor.client, checksUrl, providerType, metrics.OperationList)
 		response := client.GetUrl(headers, []byte(""))
 		if response.StatusCode != Http.StatusOK {
-			log.Info("GetAllMonitors Request for Uptime failed. Status Code: " + strconv.Itoa(response.StatusCode))
-			return nil
+			return nil, monitorerrors.FromStatusCode(response.StatusCode, "GetAllMonitors Request for Uptime failed")
 		}
 
 		err := json.Unmarshal(response.Bytes, &f)
 		if err != nil {
-			log.Info(fmt.Sprintf("Could not Unmarshal Json Response with error: %v", err))
+			return nil, monitorerrors.NewRetryable("Could not Unmarshal Json Response", err)
 		}
 		monitors = append(monitors, f.Monitors...)
 		pageNo++
 		next = f.Next
 	}
 	cache.Set("uptime-checks", monitors, gocache.DefaultExpiration)
-	return UptimeMonitorMonitorsToBaseMonitorsMapper(monitors)
+	return UptimeMonitorMonitorsToBaseMonitorsMapper(monitors), nil
 }
 
-func (monitor *UpTimeMonitorService) Add(m models.Monitor) {
+func (monitor *UpTimeMonitorService) Add(m models.Monitor) error {
 
 	defer cache.Flush()
-	action := "checks/add-http/"
-	client := http.CreateHttpClient(monitor.url + action)
+	action := "checks/add-" + getCheckType(m) + "/"
+	client := http.CreateProviderHttpClient(monitor.client, monitor.url+action, providerType, metrics.OperationCreate)
 
 	headers := make(map[string]string)
 	headers["Authorization"] = "Token " + monitor.apiKey
@@ -116,41 +148,42 @@ func (monitor *UpTimeMonitorService) Add(m models.Monitor) {
 	body := processProviderConfig(m)
 
 	jsonBody, err := json.Marshal(body)
-	if err == nil {
-		log.Info(string(jsonBody))
-		response := client.PostUrl(headers, jsonBody)
-
-		if response.StatusCode == Http.StatusOK {
-			var f UptimeMonitorMonitorResponse
+	if err != nil {
+		log.Info(err.Error())
+		return monitorerrors.NewValidationFailed("Failed to Marshal JSON Object for monitor: "+m.Name, err)
+	}
+	log.Info(string(jsonBody))
+	response := client.PostUrl(headers, jsonBody)
 
-			err := json.Unmarshal(response.Bytes, &f)
-			if err != nil {
-				log.Info("Failed to Unmarshal Response Json Object")
-			}
+	if response.StatusCode != Http.StatusOK {
+		log.Info("AddMonitor Request failed. Status Code: " + strconv.Itoa(response.StatusCode) + "\n" + string(response.Bytes))
+		return monitorerrors.FromStatusCode(response.StatusCode, "AddMonitor Request failed for name: "+m.Name+": "+string(response.Bytes))
+	}
 
-			if !f.Errors {
-				log.Info("Monitor Added: " + m.Name)
-			} else {
-				log.Info("Monitor couldn't be added: " + m.Name +
-					"Response: ")
-				log.Info(string(response.Bytes))
-			}
-		} else {
-			log.Info("AddMonitor Request failed. Status Code: " + strconv.Itoa(response.StatusCode) + "\n" + string(response.Bytes))
-		}
-	} else {
-		log.Info(err.Error())
+	var f UptimeMonitorMonitorResponse
+	err = json.Unmarshal(response.Bytes, &f)
+	if err != nil {
+		log.Info("Failed to Unmarshal Response Json Object")
+		return monitorerrors.NewRetryable("Failed to Unmarshal Response Json Object", err)
 	}
 
+	if f.Errors {
+		log.Info("Monitor couldn't be added: " + m.Name +
+			"Response: ")
+		log.Info(string(response.Bytes))
+		return monitorerrors.NewValidationFailed("Monitor couldn't be added: "+m.Name+": "+string(response.Bytes), nil)
+	}
+	log.Info("Monitor Added: " + m.Name)
+	return nil
 }
 
-func (monitor *UpTimeMonitorService) Update(m models.Monitor) {
+func (monitor *UpTimeMonitorService) Update(m models.Monitor) error {
 
 	log.Info("Updating Monitor: " + m.Name)
 	defer cache.Flush()
 
 	action := "checks/" + m.ID + "/"
-	client := http.CreateHttpClient(monitor.url + action)
+	client := http.CreateProviderHttpClient(monitor.client, monitor.url+action, providerType, metrics.OperationUpdate)
 
 	headers := make(map[string]string)
 	headers["Authorization"] = "Token " + monitor.apiKey
@@ -159,35 +192,38 @@ func (monitor *UpTimeMonitorService) Update(m models.Monitor) {
 	body := processProviderConfig(m)
 
 	jsonBody, err := json.Marshal(body)
-	log.Info(string(jsonBody))
-	if err == nil {
-		response := client.PutUrl(headers, jsonBody)
-
-		if response.StatusCode == Http.StatusOK {
-			var f UptimeMonitorMonitorResponse
-			err := json.Unmarshal(response.Bytes, &f)
-			if err != nil {
-				log.Info("Failed to Unmarshal Response Json Object")
-			}
-			if !f.Errors {
-				log.Info("Monitor Updated: " + m.Name)
-			} else {
-				log.Info("Monitor couldn't be updated: " + m.Name)
-			}
-		} else {
-			log.Info("UpdateMonitor Request failed. Status Code: " + strconv.Itoa(response.StatusCode))
-		}
-	} else {
+	if err != nil {
 		log.Info("Failed to Marshal JSON Object")
+		return monitorerrors.NewValidationFailed("Failed to Marshal JSON Object for monitor: "+m.Name, err)
+	}
+	log.Info(string(jsonBody))
+	response := client.PutUrl(headers, jsonBody)
+
+	if response.StatusCode != Http.StatusOK {
+		log.Info("UpdateMonitor Request failed. Status Code: " + strconv.Itoa(response.StatusCode))
+		return monitorerrors.FromStatusCode(response.StatusCode, "UpdateMonitor Request failed for name: "+m.Name+": "+string(response.Bytes))
+	}
+
+	var f UptimeMonitorMonitorResponse
+	err = json.Unmarshal(response.Bytes, &f)
+	if err != nil {
+		log.Info("Failed to Unmarshal Response Json Object")
+		return monitorerrors.NewRetryable("Failed to Unmarshal Response Json Object", err)
 	}
+	if f.Errors {
+		log.Info("Monitor couldn't be updated: " + m.Name)
+		return monitorerrors.NewValidationFailed("Monitor couldn't be updated: "+m.Name+": "+string(response.Bytes), nil)
+	}
+	log.Info("Monitor Updated: " + m.Name)
+	return nil
 }
 
-func (monitor *UpTimeMonitorService) Remove(m models.Monitor) {
+func (monitor *UpTimeMonitorService) Remove(m models.Monitor) error {
 
 	defer cache.Flush()
 	action := "checks/" + m.ID + "/"
 
-	client := http.CreateHttpClient(monitor.url + action)
+	client := http.CreateProviderHttpClient(monitor.client, monitor.url+action, providerType, metrics.OperationDelete)
 
 	headers := make(map[string]string)
 	headers["Authorization"] = "Token " + monitor.apiKey
@@ -195,20 +231,23 @@ func (monitor *UpTimeMonitorService) Remove(m models.Monitor) {
 
 	response := client.DeleteUrl(headers, []byte(""))
 
-	if response.StatusCode == Http.StatusOK {
-		var f UptimeMonitorMonitorResponse
-		err := json.Unmarshal(response.Bytes, &f)
-		if err != nil {
-			log.Error(err, "Unable to unmarshal JSON")
-		}
-		if !f.Errors {
-			log.Info("Monitor Removed: " + m.Name)
-		} else {
-			log.Info("Monitor couldn't be removed: " + m.Name)
-		}
-	} else {
+	if response.StatusCode != Http.StatusOK {
 		log.Info("RemoveMonitor Request failed. Status Code: " + strconv.Itoa(response.StatusCode))
+		return monitorerrors.FromStatusCode(response.StatusCode, "RemoveMonitor Request failed for name: "+m.Name)
+	}
+
+	var f UptimeMonitorMonitorResponse
+	err := json.Unmarshal(response.Bytes, &f)
+	if err != nil {
+		log.Error(err, "Unable to unmarshal JSON")
+		return monitorerrors.NewRetryable("Unable to unmarshal JSON", err)
 	}
+	if f.Errors {
+		log.Info("Monitor couldn't be removed: " + m.Name)
+		return monitorerrors.NewRetryable("Monitor couldn't be removed: "+m.Name+": "+string(response.Bytes), nil)
+	}
+	log.Info("Monitor Removed: " + m.Name)
+	return nil
 }
 
 func processProviderConfig(m models.Monitor) map[string]interface{} {
@@ -221,8 +260,20 @@ func processProviderConfig(m models.Monitor) map[string]interface{} {
 	unEscapedURL, _ := url.QueryUnescape(m.URL)
 	body["msp_address"] = unEscapedURL
 
+	if getCheckType(m) == "tcp" {
+		// TCP checks take the host and port of the URL separately
+		if u, err := url.Parse(unEscapedURL); err == nil && len(u.Hostname()) != 0 {
+			body["msp_address"] = u.Hostname()
+			if port, err := strconv.Atoi(u.Port()); err == nil {
+				body["msp_port"] = port
+			}
+		}
+	}
+
 	if providerConfig != nil && providerConfig.Interval > 0 {
 		body["msp_interval"] = strconv.Itoa(providerConfig.Interval)
+	} else if m.Check.GetIntervalSeconds() >= 60 {
+		body["msp_interval"] = strconv.Itoa(m.Check.GetIntervalSeconds() / 60)
 	} else {
 		body["msp_interval"] = 5 // by default interval check is 5 minutes
 	}
@@ -246,6 +297,59 @@ func processProviderConfig(m models.Monitor) map[string]interface{} {
 		body["tags"] = util.SplitAndSort(providerConfig.Tags, ",")
 	}
 
+	processCheckConfig(m.Check, m.BasicAuthPassword, body)
+
 	return body
 
 }
+
+// getCheckType returns the Uptime check type used to add the monitor. The check type of the provider configuration
+// takes precedence over the type of the check.
+func getCheckType(m models.Monitor) string {
+	providerConfig, _ := m.Config.(*endpointmonitorv1alpha1.UptimeConfig)
+	if providerConfig != nil && len(providerConfig.CheckType) != 0 {
+		return strings.ToLower(providerConfig.CheckType)
+	}
+	if m.Check.GetType() == endpointmonitorv1alpha1.CheckTypeTCP {
+		return "tcp"
+	}
+	return "http"
+}
+
+// processCheckConfig adds the settings of the check that have no Uptime specific configuration to the body
+func processCheckConfig(check *endpointmonitorv1alpha1.CheckConfig, basicAuthPassword string, body map[string]interface{}) {
+	if check == nil {
+		return
+	}
+
+	if check.GetType() == endpointmonitorv1alpha1.CheckTypeKeyword && check.Keyword != nil {
+		if check.Keyword.IsKeywordAbsent() {
+			log.Info("Absent keywords are not supported by Uptime, ignoring keyword: " + check.Keyword.Value)
+		} else {
+			body["msp_expect_string"] = check.Keyword.Value
+		}
+	}
+
+	if len(check.Headers) != 0 {
+		// Headers are sorted which is useful during Equal method used in Update.
+		names := make([]string, 0, len(check.Headers))
+		for name := range check.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		headers := make([]string, 0, len(names))
+		for _, name := range names {
+			headers = append(headers, name+": "+check.Headers[name])
+		}
+		body["msp_headers"] = strings.Join(headers, "\n")
+	}
+
+	if check.BasicAuth != nil {
+		if basicAuthPassword != "" {
+			body["msp_username"] = check.BasicAuth.Username
+			body["msp_password"] = basicAuthPassword
+		} else {
+			log.Info("Basic auth password is empty, ignoring basic auth")
+		}
+	}
+}
@@ -282,3 +282,20 @@ func TestEqualMonitor(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessProviderConfigWithTCPCheck(t *testing.T) {
+	m := models.Monitor{Name: "tcp-test", URL: "tcp://10.0.0.1:5432",
+		Config: &endpointmonitorv1alpha1.UptimeConfig{},
+		Check:  &endpointmonitorv1alpha1.CheckConfig{Type: endpointmonitorv1alpha1.CheckTypeTCP}}
+
+	if checkType := getCheckType(m); checkType != "tcp" {
+		t.Errorf("Check type should be tcp, got %s", checkType)
+	}
+	body := processProviderConfig(m)
+	if body["msp_address"] != "10.0.0.1" {
+		t.Errorf("Address should be the host of the URL, got %v", body["msp_address"])
+	}
+	if body["msp_port"] != 5432 {
+		t.Errorf("Port should be the port of the URL, got %v", body["msp_port"])
+	}
+}
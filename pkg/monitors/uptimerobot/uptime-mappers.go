@@ -3,6 +3,7 @@ package uptimerobot
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
@@ -43,6 +44,34 @@ func UptimeMonitorMonitorsToBaseMonitorsMapper(uptimeMonitors []UptimeMonitorMon
 	return monitors
 }
 
+// UptimeMonitorMonitorToCheckStatusMapper maps the status, latest response time and custom uptime ratio of a monitor
+func UptimeMonitorMonitorToCheckStatusMapper(uptimeMonitor UptimeMonitorMonitor) *models.CheckStatus {
+	var s models.CheckStatus
+
+	switch uptimeMonitor.Status {
+	case UptimeMonitorStatusUp:
+		s.State = models.CheckStateUp
+	case UptimeMonitorStatusSeemsDown, UptimeMonitorStatusDown:
+		s.State = models.CheckStateDown
+	case UptimeMonitorStatusPaused:
+		s.State = models.CheckStatePaused
+	default:
+		s.State = models.CheckStateUnknown
+	}
+
+	if len(uptimeMonitor.ResponseTimes) != 0 {
+		responseTime := time.Duration(uptimeMonitor.ResponseTimes[0].Value) * time.Millisecond
+		s.ResponseTime = &responseTime
+	}
+
+	if percentage, err := strconv.ParseFloat(uptimeMonitor.CustomUptimeRatio, 64); err == nil {
+		ratio := percentage / 100
+		s.UptimeRatio = &ratio
+	}
+
+	return &s
+}
+
 func UptimeStatusPageToBaseStatusPageMapper(uptimePublicStatusPage UptimePublicStatusPage) *UpTimeStatusPage {
 	var s UpTimeStatusPage
 
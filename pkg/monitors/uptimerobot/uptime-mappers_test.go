@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
@@ -50,6 +51,23 @@ func TestUptimeMonitorMonitorsToBaseMonitorsMapper(t *testing.T) {
 	}
 }
 
+func TestUptimeMonitorMonitorToCheckStatusMapper(t *testing.T) {
+	uptimeMonitorObject := UptimeMonitorMonitor{ID: 124, Status: UptimeMonitorStatusSeemsDown, CustomUptimeRatio: "99.500",
+		ResponseTimes: []UptimeMonitorResponseTime{{Datetime: 1651399200, Value: 320}}}
+
+	status := UptimeMonitorMonitorToCheckStatusMapper(uptimeMonitorObject)
+
+	if status.State != models.CheckStateDown || status.ResponseTime == nil || *status.ResponseTime != 320*time.Millisecond ||
+		status.UptimeRatio == nil || *status.UptimeRatio != 0.995 {
+		t.Error("Mapper did not map the values correctly")
+	}
+
+	status = UptimeMonitorMonitorToCheckStatusMapper(UptimeMonitorMonitor{ID: 124, Status: UptimeMonitorStatusPaused})
+	if status.State != models.CheckStatePaused || status.ResponseTime != nil || status.UptimeRatio != nil {
+		t.Error("Mapper should only map the state of a monitor without response times and uptime ratio")
+	}
+}
+
 func TestUptimeStatusPageToBaseStatusPageMapper(t *testing.T) {
 	uptimePublicStatusPageObject := UptimePublicStatusPage{FriendlyName: "Test Status Page", ID: 124, Monitors: []int{1234, 5678}}
 
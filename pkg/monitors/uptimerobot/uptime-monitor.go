@@ -13,19 +13,25 @@ import (
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
 	"github.com/stakater/IngressMonitorController/v2/pkg/http"
+	"github.com/stakater/IngressMonitorController/v2/pkg/metrics"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
+	monitorerrors "github.com/stakater/IngressMonitorController/v2/pkg/monitors/errors"
 )
 
 type UpTimeMonitorService struct {
 	apiKey            string
 	url               string
 	alertContacts     string
+	client            *Http.Client
 	statusPageService UpTimeStatusPageService
 }
 
 // Default Interval for status checking
 const DefaultInterval = 300
 
+// providerType labels the metrics of the API calls
+const providerType = "UptimeRobot"
+
 func (monitor *UpTimeMonitorService) Equal(oldMonitor models.Monitor, newMonitor models.Monitor) bool {
 	if !(reflect.DeepEqual(monitor.processProviderConfig(oldMonitor, false), monitor.processProviderConfig(newMonitor, false))) {
 		log.Info(fmt.Sprintf("There are some new changes in %s monitor", newMonitor.Name))
@@ -34,18 +40,43 @@ func (monitor *UpTimeMonitorService) Equal(oldMonitor models.Monitor, newMonitor
 	return true
 }
 
-func (monitor *UpTimeMonitorService) Setup(p config.Provider) {
+func (monitor *UpTimeMonitorService) Setup(p config.Provider) error {
 	monitor.apiKey = p.ApiKey
 	monitor.url = p.ApiURL
 	monitor.alertContacts = p.AlertContacts
+	monitor.client = http.NewClient(p.HTTP)
 	monitor.statusPageService = UpTimeStatusPageService{}
 	monitor.statusPageService.Setup(p)
+	// The status pages are managed with the same account, they share its rate limit
+	monitor.statusPageService.client = monitor.client
+	return nil
+}
+
+// VerifyCredentials checks the API key by retrieving the account details
+func (monitor *UpTimeMonitorService) VerifyCredentials() error {
+	client := http.CreateProviderHttpClient(monitor.client, monitor.url+"getAccountDetails", providerType, metrics.OperationVerify)
+	response := client.PostUrlEncodedFormBody("api_key=" + monitor.apiKey + "&format=json")
+	if response.StatusCode != Http.StatusOK {
+		return monitorerrors.FromStatusCode(response.StatusCode, "GetAccountDetails Request failed")
+	}
+
+	var f struct {
+		Stat  string             `json:"stat"`
+		Error UptimeMonitorError `json:"error"`
+	}
+	if err := json.Unmarshal(response.Bytes, &f); err != nil {
+		return monitorerrors.NewRetryable("Unable to unmarshal account details", err)
+	}
+	if f.Stat != "ok" {
+		return responseError(f.Error, "GetAccountDetails Request failed")
+	}
+	return nil
 }
 
 func (monitor *UpTimeMonitorService) GetByName(name string) (*models.Monitor, error) {
 	action := "getMonitors"
 
-	client := http.CreateHttpClient(monitor.url + action)
+	client := http.CreateProviderHttpClient(monitor.client, monitor.url+action, providerType, metrics.OperationGet)
 
 	body := "api_key=" + monitor.apiKey + "&format=json&logs=1&alert_contacts=1&search=" + name
 
@@ -56,6 +87,7 @@ func (monitor *UpTimeMonitorService) GetByName(name string) (*models.Monitor, er
 		err := json.Unmarshal(response.Bytes, &f)
 		if err != nil {
 			log.Error(err, "Unable to unmarshal JSON")
+			return nil, monitorerrors.NewRetryable("Unable to read monitors of name: "+name, err)
 		}
 
 		if f.Monitors != nil {
@@ -69,16 +101,41 @@ func (monitor *UpTimeMonitorService) GetByName(name string) (*models.Monitor, er
 		return nil, nil
 	}
 
-	errorString := "GetByName Request failed for name: " + name + ". Status Code: " + strconv.Itoa(response.StatusCode)
+	errorString := "GetByName Request failed for name: " + name
 
-	log.Info(errorString)
-	return nil, errors.New(errorString)
+	log.Info(errorString + ". Status Code: " + strconv.Itoa(response.StatusCode))
+	return nil, monitorerrors.FromStatusCode(response.StatusCode, errorString)
+}
+
+// GetByID retrieves the monitor with the given ID
+func (monitor *UpTimeMonitorService) GetByID(id string) (*models.Monitor, error) {
+	action := "getMonitors"
+
+	client := http.CreateProviderHttpClient(monitor.client, monitor.url+action, providerType, metrics.OperationGet)
+
+	body := "api_key=" + monitor.apiKey + "&format=json&logs=1&alert_contacts=1&monitors=" + id
+
+	response := client.PostUrlEncodedFormBody(body)
+	if response.StatusCode != Http.StatusOK {
+		return nil, monitorerrors.FromStatusCode(response.StatusCode, "GetByID Request failed for ID: "+id)
+	}
+
+	var f UptimeMonitorGetMonitorsResponse
+	if err := json.Unmarshal(response.Bytes, &f); err != nil {
+		return nil, monitorerrors.NewRetryable("Unable to read monitor of ID: "+id, err)
+	}
+	for _, uptimeMonitor := range f.Monitors {
+		if strconv.Itoa(uptimeMonitor.ID) == id {
+			return UptimeMonitorMonitorToBaseMonitorMapper(uptimeMonitor), nil
+		}
+	}
+	return nil, monitorerrors.NewNotFound("Unable to locate monitor with ID "+id, nil)
 }
 
 func (monitor *UpTimeMonitorService) GetAllByName(name string) ([]models.Monitor, error) {
 	action := "getMonitors"
 
-	client := http.CreateHttpClient(monitor.url + action)
+	client := http.CreateProviderHttpClient(monitor.client, monitor.url+action, providerType, metrics.OperationGet)
 
 	body := "api_key=" + monitor.apiKey + "&format=json&logs=1" + "&search=" + name
 
@@ -107,7 +164,7 @@ func (monitor *UpTimeMonitorService) GetAll() []models.Monitor {
 
 	action := "getMonitors"
 
-	client := http.CreateHttpClient(monitor.url + action)
+	client := http.CreateProviderHttpClient(monitor.client, monitor.url+action, providerType, metrics.OperationList)
 
 	body := "api_key=" + monitor.apiKey + "&format=json&logs=1"
 
@@ -130,10 +187,10 @@ func (monitor *UpTimeMonitorService) GetAll() []models.Monitor {
 
 }
 
-func (monitor *UpTimeMonitorService) Add(m models.Monitor) {
+func (monitor *UpTimeMonitorService) Add(m models.Monitor) error {
 	action := "newMonitor"
 
-	client := http.CreateHttpClient(monitor.url + action)
+	client := http.CreateProviderHttpClient(monitor.client, monitor.url+action, providerType, metrics.OperationCreate)
 
 	body := monitor.processProviderConfig(m, true)
 
@@ -144,23 +201,25 @@ func (monitor *UpTimeMonitorService) Add(m models.Monitor) {
 		err := json.Unmarshal(response.Bytes, &f)
 		if err != nil {
 			log.Error(err, "Monitor couldn't be added: "+m.Name)
+			return monitorerrors.NewRetryable("Monitor couldn't be added: "+m.Name, err)
 		}
 
 		if f.Stat == "ok" {
 			log.Info("Monitor Added: " + m.Name)
 			monitor.handleStatusPagesConfig(m, strconv.Itoa(f.Monitor.ID))
-		} else {
-			log.Info("Monitor couldn't be added: " + m.Name + ". Error: " + f.Error.Message)
+			return nil
 		}
-	} else {
-		log.Info("AddMonitor Request failed. Status Code: " + strconv.Itoa(response.StatusCode))
+		log.Info("Monitor couldn't be added: " + m.Name + ". Error: " + f.Error.Message)
+		return responseError(f.Error, "Monitor couldn't be added: "+m.Name)
 	}
+	log.Info("AddMonitor Request failed. Status Code: " + strconv.Itoa(response.StatusCode))
+	return monitorerrors.FromStatusCode(response.StatusCode, "AddMonitor Request failed for name: "+m.Name)
 }
 
-func (monitor *UpTimeMonitorService) Update(m models.Monitor) {
+func (monitor *UpTimeMonitorService) Update(m models.Monitor) error {
 	action := "editMonitor"
 
-	client := http.CreateHttpClient(monitor.url + action)
+	client := http.CreateProviderHttpClient(monitor.client, monitor.url+action, providerType, metrics.OperationUpdate)
 
 	body := monitor.processProviderConfig(m, false)
 
@@ -171,16 +230,18 @@ func (monitor *UpTimeMonitorService) Update(m models.Monitor) {
 		err := json.Unmarshal(response.Bytes, &f)
 		if err != nil {
 			log.Error(err, "Monitor couldn't be updated: "+m.Name)
+			return monitorerrors.NewRetryable("Monitor couldn't be updated: "+m.Name, err)
 		}
 		if f.Stat == "ok" {
 			log.Info("Monitor Updated: " + m.Name)
 			monitor.handleStatusPagesConfig(m, strconv.Itoa(f.Monitor.ID))
-		} else {
-			log.Info("Monitor couldn't be updated: " + m.Name + ". Error: " + f.Error.Message)
+			return nil
 		}
-	} else {
-		log.Info("UpdateMonitor Request failed. Status Code: " + strconv.Itoa(response.StatusCode))
+		log.Info("Monitor couldn't be updated: " + m.Name + ". Error: " + f.Error.Message)
+		return responseError(f.Error, "Monitor couldn't be updated: "+m.Name)
 	}
+	log.Info("UpdateMonitor Request failed. Status Code: " + strconv.Itoa(response.StatusCode))
+	return monitorerrors.FromStatusCode(response.StatusCode, "UpdateMonitor Request failed for name: "+m.Name)
 }
 
 func (monitor *UpTimeMonitorService) processProviderConfig(m models.Monitor, createMonitorRequest bool) string {
@@ -204,6 +265,8 @@ func (monitor *UpTimeMonitorService) processProviderConfig(m models.Monitor, cre
 
 	if providerConfig != nil && providerConfig.Interval > 0 {
 		body += "&interval=" + strconv.Itoa(providerConfig.Interval)
+	} else if m.Check.GetIntervalSeconds() > 0 {
+		body += "&interval=" + strconv.Itoa(m.Check.GetIntervalSeconds())
 	} else {
 		// Uptime robot adds a default interval of 5 minutes, if it is not specified
 		body += "&interval=" + strconv.Itoa(DefaultInterval)
@@ -215,6 +278,8 @@ func (monitor *UpTimeMonitorService) processProviderConfig(m models.Monitor, cre
 
 	if providerConfig != nil && len(providerConfig.CustomHTTPStatuses) != 0 {
 		body += "&custom_http_statuses=" + providerConfig.CustomHTTPStatuses
+	} else if m.Check.HasExpectedStatusCodes() {
+		body += "&custom_http_statuses=" + getCustomHTTPStatuses(m.Check)
 	}
 
 	if providerConfig != nil && len(providerConfig.MonitorType) != 0 {
@@ -241,16 +306,93 @@ func (monitor *UpTimeMonitorService) processProviderConfig(m models.Monitor, cre
 				log.Error(nil, "Monitor is of type Keyword but the `keyword-value` is missing")
 			}
 		}
+	} else if m.Check.GetType() == endpointmonitorv1alpha1.CheckTypeKeyword && m.Check.Keyword != nil {
+		body += "&type=2"
+		// keyword_type defines when to alert: 1 if the keyword exists, 2 if it doesn't exist
+		if m.Check.Keyword.IsKeywordAbsent() {
+			body += "&keyword_type=1"
+		} else {
+			body += "&keyword_type=2"
+		}
+		body += "&keyword_value=" + url.QueryEscape(m.Check.Keyword.Value)
 	} else {
+		if m.Check.GetType() != endpointmonitorv1alpha1.CheckTypeHTTP {
+			log.Info("Check type " + m.Check.GetType() + " is not supported by UptimeRobot, using HTTP for monitor: " + m.Name)
+		}
 		body += "&type=1" // By default monitor is of type HTTP
 	}
+
+	body += processCheckConfig(m.Check, m.BasicAuthPassword)
+	return body
+}
+
+// httpMethods maps HTTP methods to the http_method values of UptimeRobot
+var httpMethods = map[string]int{
+	Http.MethodHead:    1,
+	Http.MethodGet:     2,
+	Http.MethodPost:    3,
+	Http.MethodPut:     4,
+	Http.MethodPatch:   5,
+	Http.MethodDelete:  6,
+	Http.MethodOptions: 7,
+}
+
+// processCheckConfig generates the query for the settings of the check that have no UptimeRobot specific configuration
+func processCheckConfig(check *endpointmonitorv1alpha1.CheckConfig, basicAuthPassword string) string {
+	var body string
+	if check == nil {
+		return body
+	}
+
+	if method, ok := httpMethods[check.Method]; ok {
+		body += "&http_method=" + strconv.Itoa(method)
+	}
+
+	if len(check.Headers) != 0 {
+		headers, err := json.Marshal(check.Headers)
+		if err != nil {
+			log.Error(err, "Failed to marshal request headers")
+		} else {
+			body += "&custom_http_headers=" + url.QueryEscape(string(headers))
+		}
+	}
+
+	if check.BasicAuth != nil {
+		if basicAuthPassword != "" {
+			// http_auth_type 1 is basic authentication
+			body += "&http_auth_type=1&http_username=" + url.QueryEscape(check.BasicAuth.Username) + "&http_password=" + url.QueryEscape(basicAuthPassword)
+		} else {
+			log.Info("Basic auth password is empty, ignoring basic auth")
+		}
+	}
 	return body
 }
 
-func (monitor *UpTimeMonitorService) Remove(m models.Monitor) {
+// getCustomHTTPStatuses returns the custom_http_statuses of the expected status codes of the check. UptimeRobot treats
+// 2xx and 3xx codes as up by default, so only the codes that differ from the default are listed.
+func getCustomHTTPStatuses(check *endpointmonitorv1alpha1.CheckConfig) string {
+	var statuses []string
+	for code := 100; code < 600; code++ {
+		if len(Http.StatusText(code)) == 0 {
+			continue
+		}
+		expected := check.IsExpectedStatusCode(code)
+		if expected != (code >= 200 && code < 400) {
+			// 1 means up, 0 means down
+			if expected {
+				statuses = append(statuses, strconv.Itoa(code)+":1")
+			} else {
+				statuses = append(statuses, strconv.Itoa(code)+":0")
+			}
+		}
+	}
+	return strings.Join(statuses, "_")
+}
+
+func (monitor *UpTimeMonitorService) Remove(m models.Monitor) error {
 	action := "deleteMonitor"
 
-	client := http.CreateHttpClient(monitor.url + action)
+	client := http.CreateProviderHttpClient(monitor.client, monitor.url+action, providerType, metrics.OperationDelete)
 
 	log.Info(m.ID)
 	body := "api_key=" + monitor.apiKey + "&format=json&id=" + m.ID
@@ -262,15 +404,62 @@ func (monitor *UpTimeMonitorService) Remove(m models.Monitor) {
 		err := json.Unmarshal(response.Bytes, &f)
 		if err != nil {
 			log.Error(err, "Monitor couldn't be removed: "+m.Name)
+			return monitorerrors.NewRetryable("Monitor couldn't be removed: "+m.Name, err)
 		}
 		if f.Stat == "ok" {
 			log.Info("Monitor Removed: " + m.Name)
-		} else {
-			log.Info("Monitor couldn't be removed: " + m.Name + ". Error: " + f.Error.Message)
-			log.Info(string(body))
+			return nil
 		}
-	} else {
-		log.Info("RemoveMonitor Request failed. Status Code: " + strconv.Itoa(response.StatusCode))
+		log.Info("Monitor couldn't be removed: " + m.Name + ". Error: " + f.Error.Message)
+		return responseError(f.Error, "Monitor couldn't be removed: "+m.Name)
+	}
+	log.Info("RemoveMonitor Request failed. Status Code: " + strconv.Itoa(response.StatusCode))
+	return monitorerrors.FromStatusCode(response.StatusCode, "RemoveMonitor Request failed for name: "+m.Name)
+}
+
+// GetCheckStatus returns the status of the monitor, its latest response time and its uptime ratio over the last day
+func (monitor *UpTimeMonitorService) GetCheckStatus(m models.Monitor) (*models.CheckStatus, error) {
+	action := "getMonitors"
+
+	client := http.CreateProviderHttpClient(monitor.client, monitor.url+action, providerType, metrics.OperationGet)
+
+	body := "api_key=" + monitor.apiKey + "&format=json&monitors=" + m.ID + "&custom_uptime_ratios=1&response_times=1&response_times_limit=1"
+
+	response := client.PostUrlEncodedFormBody(body)
+	if response.StatusCode != Http.StatusOK {
+		return nil, monitorerrors.FromStatusCode(response.StatusCode, "GetMonitors Request failed for name: "+m.Name)
+	}
+
+	var f struct {
+		UptimeMonitorGetMonitorsResponse
+		Error UptimeMonitorError `json:"error"`
+	}
+	if err := json.Unmarshal(response.Bytes, &f); err != nil {
+		return nil, monitorerrors.NewRetryable("Unable to unmarshal monitors", err)
+	}
+	if f.Stat != "ok" {
+		return nil, responseError(f.Error, "GetMonitors Request failed for name: "+m.Name)
+	}
+	for _, uptimeMonitor := range f.Monitors {
+		if strconv.Itoa(uptimeMonitor.ID) == m.ID {
+			return UptimeMonitorMonitorToCheckStatusMapper(uptimeMonitor), nil
+		}
+	}
+	return nil, monitorerrors.NewNotFound("Monitor not found: "+m.Name, nil)
+}
+
+// responseError maps an error returned in the body of an UptimeRobot response to a monitor error
+func responseError(uptimeError UptimeMonitorError, message string) error {
+	err := errors.New(uptimeError.Message)
+	switch uptimeError.Type {
+	case "invalid_parameter", "missing_parameter", "already_exists":
+		return monitorerrors.NewValidationFailed(message, err)
+	case "not_authorized", "api_key_invalid":
+		return monitorerrors.NewAuthFailure(message, err)
+	case "not_found":
+		return monitorerrors.NewNotFound(message, err)
+	default:
+		return monitorerrors.NewRetryable(message, err)
 	}
 }
 
@@ -1,13 +1,19 @@
 package uptimerobot
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
 	"github.com/stakater/IngressMonitorController/v2/pkg/config"
 	"github.com/stakater/IngressMonitorController/v2/pkg/models"
 	"github.com/stakater/IngressMonitorController/v2/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // Not a test case. Cleanup to remove added dummy Monitors
@@ -415,3 +421,79 @@ func TestAddMonitorWithAlertContacts(t *testing.T) {
 	}
 	service.Remove(*mRes)
 }
+
+func TestProcessProviderConfigWithCheck(t *testing.T) {
+	service := UpTimeMonitorService{apiKey: "abc", alertContacts: "0544483_0_0"}
+
+	m := models.Monitor{Name: "google-test", URL: "https://google.com", Check: &endpointmonitorv1alpha1.CheckConfig{
+		Interval:            &metav1.Duration{Duration: 2 * time.Minute},
+		Method:              "HEAD",
+		ExpectedStatusCodes: []endpointmonitorv1alpha1.StatusCodeRange{"200-299", "401"},
+		Keyword:             &endpointmonitorv1alpha1.KeywordCheck{Value: "google"},
+	}}
+
+	body, err := url.ParseQuery(service.processProviderConfig(m, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"interval":      "120",
+		"type":          "2",
+		"keyword_type":  "2",
+		"keyword_value": "google",
+		"http_method":   "1",
+	}
+	for key, value := range expected {
+		if body.Get(key) != value {
+			t.Errorf("Expected %s to be %s, but was: %s", key, value, body.Get(key))
+		}
+	}
+
+	statuses := strings.Split(body.Get("custom_http_statuses"), "_")
+	for _, status := range []string{"301:0", "401:1"} {
+		if !util.ContainsString(statuses, status) {
+			t.Errorf("Expected custom_http_statuses to contain %s, but was: %s", status, body.Get("custom_http_statuses"))
+		}
+	}
+	for _, status := range []string{"200:1", "404:0"} {
+		if util.ContainsString(statuses, status) {
+			t.Errorf("Expected custom_http_statuses not to contain default status %s", status)
+		}
+	}
+
+	// Provider specific configuration takes precedence over the check
+	m.Config = &endpointmonitorv1alpha1.UptimeRobotConfig{Interval: 300, MonitorType: "http"}
+	body, err = url.ParseQuery(service.processProviderConfig(m, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.Get("interval") != "300" || body.Get("type") != "1" || body.Get("keyword_value") != "" {
+		t.Errorf("Expected the provider configuration to take precedence over the check, but was: %v", body)
+	}
+}
+
+func TestGetCheckStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("custom_uptime_ratios") != "1" {
+			t.Errorf("Unexpected getMonitors request: %v", r.Form)
+		}
+		w.Write([]byte(`{"stat":"ok","monitors":[{"id":124,"friendly_name":"google-test","url":"https://google.com","status":2,` +
+			`"custom_uptime_ratio":"100.000","response_times":[{"datetime":1651399200,"value":180}]}]}`))
+	}))
+	defer server.Close()
+
+	service := UpTimeMonitorService{}
+	service.Setup(config.Provider{Name: "UptimeRobot", ApiKey: "key", ApiURL: server.URL + "/"})
+
+	status, err := service.GetCheckStatus(models.Monitor{Name: "google-test", ID: "124"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != models.CheckStateUp || *status.ResponseTime != 180*time.Millisecond || *status.UptimeRatio != 1 {
+		t.Errorf("Unexpected check status: %+v", status)
+	}
+
+	if _, err := service.GetCheckStatus(models.Monitor{Name: "missing", ID: "125"}); err == nil {
+		t.Error("Status of a missing monitor should fail")
+	}
+}
@@ -28,6 +28,25 @@ type UptimeMonitorMonitor struct {
 	CreateDatetime int                          `json:"create_datetime"`
 	Logs           []UptimeMonitorLogs          `json:"logs"`
 	AlertContacts  []UptimeMonitorAlertContacts `json:"alert_contacts"`
+	// Percentage of uptime, only returned if custom_uptime_ratios is requested
+	CustomUptimeRatio string `json:"custom_uptime_ratio"`
+	// Latest response times, only returned if response_times is requested
+	ResponseTimes []UptimeMonitorResponseTime `json:"response_times"`
+}
+
+// Statuses of an UptimeRobot monitor
+const (
+	UptimeMonitorStatusPaused     = 0
+	UptimeMonitorStatusNotChecked = 1
+	UptimeMonitorStatusUp         = 2
+	UptimeMonitorStatusSeemsDown  = 8
+	UptimeMonitorStatusDown       = 9
+)
+
+type UptimeMonitorResponseTime struct {
+	Datetime int `json:"datetime"`
+	// Value is the response time in milliseconds
+	Value int `json:"value"`
 }
 
 type UptimeMonitorAlertContacts struct {
@@ -17,9 +17,19 @@ import (
 
 var log = logf.Log.WithName("uptime-monitor-test")
 
+// Operations of the status page API calls recorded in the metrics
+const (
+	operationGetStatusPage    = "get_status_page"
+	operationListStatusPages  = "list_status_pages"
+	operationCreateStatusPage = "create_status_page"
+	operationUpdateStatusPage = "update_status_page"
+	operationDeleteStatusPage = "delete_status_page"
+)
+
 type UpTimeStatusPageService struct {
 	apiKey string
 	url    string
+	client *Http.Client
 }
 
 type UpTimeStatusPage struct {
@@ -31,12 +41,13 @@ type UpTimeStatusPage struct {
 func (statusPage *UpTimeStatusPageService) Setup(p config.Provider) {
 	statusPage.apiKey = p.ApiKey
 	statusPage.url = p.ApiURL
+	statusPage.client = http.NewClient(p.HTTP)
 }
 
 func (statusPageService *UpTimeStatusPageService) Add(statusPage UpTimeStatusPage) (string, error) {
 	action := "newPSP"
 
-	client := http.CreateHttpClient(statusPageService.url + action)
+	client := http.CreateProviderHttpClient(statusPageService.client, statusPageService.url+action, providerType, operationCreateStatusPage)
 
 	body := "api_key=" + statusPageService.apiKey + "&format=json&friendly_name=" + url.QueryEscape(statusPage.Name)
 
@@ -73,7 +84,7 @@ func (statusPageService *UpTimeStatusPageService) Add(statusPage UpTimeStatusPag
 func (statusPageService *UpTimeStatusPageService) Remove(statusPage UpTimeStatusPage) {
 	action := "deletePSP"
 
-	client := http.CreateHttpClient(statusPageService.url + action)
+	client := http.CreateProviderHttpClient(statusPageService.client, statusPageService.url+action, providerType, operationDeleteStatusPage)
 
 	body := "api_key=" + statusPageService.apiKey + "&format=json&id=" + statusPage.ID
 
@@ -112,7 +123,7 @@ func (statusPageService *UpTimeStatusPageService) AddMonitorToStatusPage(statusP
 
 		action := "editPSP"
 
-		client := http.CreateHttpClient(statusPageService.url + action)
+		client := http.CreateProviderHttpClient(statusPageService.client, statusPageService.url+action, providerType, operationUpdateStatusPage)
 
 		body := "api_key=" + statusPageService.apiKey + "&format=json&id=" + statusPage.ID
 
@@ -159,7 +170,7 @@ func (statusPageService *UpTimeStatusPageService) RemoveMonitorFromStatusPage(st
 
 	action := "editPSP"
 
-	client := http.CreateHttpClient(statusPageService.url + action)
+	client := http.CreateProviderHttpClient(statusPageService.client, statusPageService.url+action, providerType, operationUpdateStatusPage)
 
 	body := "api_key=" + statusPageService.apiKey + "&format=json&id=" + statusPage.ID
 
@@ -196,7 +207,7 @@ func (statusPageService *UpTimeStatusPageService) RemoveMonitorFromStatusPage(st
 func (statusPageService *UpTimeStatusPageService) Get(ID string) (*UpTimeStatusPage, error) {
 	action := "getPsps"
 
-	client := http.CreateHttpClient(statusPageService.url + action)
+	client := http.CreateProviderHttpClient(statusPageService.client, statusPageService.url+action, providerType, operationGetStatusPage)
 
 	body := "api_key=" + statusPageService.apiKey + "&format=json&logs=1" + "&psps=" + ID
 
@@ -228,7 +239,7 @@ func (statusPageService *UpTimeStatusPageService) GetAllStatusPages(name string)
 	statusPages := []UpTimeStatusPage{}
 	action := "getPsps"
 
-	client := http.CreateHttpClient(statusPageService.url + action)
+	client := http.CreateProviderHttpClient(statusPageService.client, statusPageService.url+action, providerType, operationListStatusPages)
 
 	body := "api_key=" + statusPageService.apiKey + "&format=json&logs=1"
 
@@ -271,7 +282,7 @@ func (statusPageService *UpTimeStatusPageService) GetStatusPagesForMonitor(ID st
 
 	action := "getPsps"
 
-	client := http.CreateHttpClient(statusPageService.url + action)
+	client := http.CreateProviderHttpClient(statusPageService.client, statusPageService.url+action, providerType, operationListStatusPages)
 
 	if f.StatusPages != nil {
 		for f.Pagination.Limit < f.Pagination.Total {
@@ -0,0 +1,22 @@
+package util
+
+import (
+	"net/http"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+)
+
+// GetUnexpectedStatusCodes returns the known HTTP status codes that are not treated as up by the check.
+// The given additional codes are added to the known ones, e.g. to include non standard codes.
+func GetUnexpectedStatusCodes(check *endpointmonitorv1alpha1.CheckConfig, additionalCodes []int) []int {
+	var statusCodes []int
+	for code := 100; code < 600; code++ {
+		if len(http.StatusText(code)) == 0 && !ContainsInt(additionalCodes, code) {
+			continue
+		}
+		if !check.IsExpectedStatusCode(code) {
+			statusCodes = append(statusCodes, code)
+		}
+	}
+	return statusCodes
+}
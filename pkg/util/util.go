@@ -55,3 +55,21 @@ func SplitAndSort(s string, sep string) []string {
 	sort.Strings(slice)
 	return slice
 }
+
+// ClosestInt returns the value of the non-empty slice s that is closest to e
+func ClosestInt(s []int, e int) int {
+	closest := s[0]
+	for _, a := range s[1:] {
+		if abs(a-e) < abs(closest-e) {
+			closest = a
+		}
+	}
+	return closest
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
@@ -0,0 +1,297 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors/appinsights"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors/updown"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors/uptimerobot"
+	"github.com/stakater/IngressMonitorController/v2/pkg/util"
+)
+
+var log = logf.Log.WithName("endpointmonitor-webhook")
+
+// Intervals used by the providers if neither the provider configuration nor the check sets one
+const (
+	uptimeDefaultInterval      = 5
+	statusCakeDefaultCheckRate = 300
+	pingdomDefaultResolution   = 1
+)
+
+// EndpointMonitorWebhook defaults and validates EndpointMonitors on admission, so that mistakes are reported by
+// kubectl instead of the logs of the controller
+type EndpointMonitorWebhook struct {
+	MonitorServices *monitors.MonitorServiceRegistry
+}
+
+// SetupWebhookWithManager registers the defaulting and validating webhooks with the manager
+func (w *EndpointMonitorWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&endpointmonitorv1alpha1.EndpointMonitor{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-endpointmonitor-stakater-com-v1alpha1-endpointmonitor,mutating=true,failurePolicy=ignore,sideEffects=None,groups=endpointmonitor.stakater.com,resources=endpointmonitors,verbs=create;update,versions=v1alpha1,name=mendpointmonitor.kb.io,admissionReviewVersions=v1
+
+var _ admission.CustomDefaulter = &EndpointMonitorWebhook{}
+
+// Default fills the values the providers would otherwise pick, so the stored object reflects what is sent to them
+func (w *EndpointMonitorWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	instance, ok := obj.(*endpointmonitorv1alpha1.EndpointMonitor)
+	if !ok {
+		return fmt.Errorf("expected an EndpointMonitor but got %T", obj)
+	}
+	if !instance.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	log.V(1).Info("Defaulting", "namespace", instance.Namespace, "name", instance.Name)
+	DefaultSpec(&instance.Spec)
+	return nil
+}
+
+// DefaultSpec fills the defaults of the webhook into the spec. It is also used by the generator of EndpointMonitors,
+// whose specs would otherwise differ from the stored ones on every reconcile.
+func DefaultSpec(spec *endpointmonitorv1alpha1.EndpointMonitorSpec) {
+	check := spec.Check
+	if check != nil {
+		if len(check.Type) == 0 && !isTCPURL(spec) {
+			check.Type = check.GetType()
+		}
+		if check.Keyword != nil && len(check.Keyword.Condition) == 0 {
+			check.Keyword.Condition = endpointmonitorv1alpha1.KeywordPresent
+		}
+	}
+
+	if config := spec.UptimeRobotConfig; config != nil {
+		if len(config.MonitorType) == 0 && len(config.KeywordValue) != 0 {
+			config.MonitorType = "keyword"
+		}
+		if config.MonitorType == "keyword" && len(config.KeywordExists) == 0 {
+			config.KeywordExists = "yes"
+		}
+	}
+
+	// Provider intervals are only defaulted if the check doesn't set one, which is mapped onto every provider
+	if check.GetIntervalSeconds() != 0 {
+		return
+	}
+	if config := spec.UptimeRobotConfig; config != nil && config.Interval == 0 {
+		config.Interval = uptimerobot.DefaultInterval
+	}
+	if config := spec.UptimeConfig; config != nil && config.Interval == 0 {
+		config.Interval = uptimeDefaultInterval
+	}
+	if config := spec.UpdownConfig; config != nil && config.Period == 0 {
+		config.Period = updown.UpdownPeriodDefaultValue
+	}
+	if config := spec.StatusCakeConfig; config != nil && config.CheckRate == 0 {
+		config.CheckRate = statusCakeDefaultCheckRate
+	}
+	if config := spec.PingdomConfig; config != nil && config.Resolution == 0 {
+		config.Resolution = pingdomDefaultResolution
+	}
+	if config := spec.AppInsightsConfig; config != nil && config.Frequency == 0 {
+		config.Frequency = appinsights.AppInsightsFrequencyDefaultValue
+	}
+}
+
+// isTCPURL returns true if the URL may be a tcp URL, whose check type is defaulted by the controller. The scheme of a
+// serviceRef without scheme is only known once it is resolved.
+func isTCPURL(spec *endpointmonitorv1alpha1.EndpointMonitorSpec) bool {
+	if strings.HasPrefix(spec.URL, endpointmonitorv1alpha1.ServiceSchemeTCP+"://") {
+		return true
+	}
+	if spec.URLFrom == nil || spec.URLFrom.ServiceRef == nil {
+		return false
+	}
+	scheme := spec.URLFrom.ServiceRef.Scheme
+	return scheme != endpointmonitorv1alpha1.ServiceSchemeHTTP && scheme != endpointmonitorv1alpha1.ServiceSchemeHTTPS
+}
+
+//+kubebuilder:webhook:path=/validate-endpointmonitor-stakater-com-v1alpha1-endpointmonitor,mutating=false,failurePolicy=ignore,sideEffects=None,groups=endpointmonitor.stakater.com,resources=endpointmonitors,verbs=create;update,versions=v1alpha1,name=vendpointmonitor.kb.io,admissionReviewVersions=v1
+
+var _ admission.CustomValidator = &EndpointMonitorWebhook{}
+
+// ValidateCreate rejects EndpointMonitors with an invalid spec
+func (w *EndpointMonitorWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return w.validate(obj)
+}
+
+// ValidateUpdate rejects updates to an invalid spec. Updates that leave the spec unchanged, e.g. of the finalizers,
+// are always allowed so that EndpointMonitors created before the webhook can still be deleted.
+func (w *EndpointMonitorWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldInstance, oldOk := oldObj.(*endpointmonitorv1alpha1.EndpointMonitor)
+	newInstance, newOk := newObj.(*endpointmonitorv1alpha1.EndpointMonitor)
+	if oldOk && newOk && equality.Semantic.DeepEqual(oldInstance.Spec, newInstance.Spec) {
+		return nil
+	}
+	return w.validate(newObj)
+}
+
+// ValidateDelete never rejects deletions
+func (w *EndpointMonitorWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (w *EndpointMonitorWebhook) validate(obj runtime.Object) error {
+	instance, ok := obj.(*endpointmonitorv1alpha1.EndpointMonitor)
+	if !ok {
+		return fmt.Errorf("expected an EndpointMonitor but got %T", obj)
+	}
+
+	specPath := field.NewPath("spec")
+	var errs field.ErrorList
+	errs = append(errs, validateURL(&instance.Spec, specPath)...)
+	errs = append(errs, w.validateProviders(&instance.Spec, specPath.Child("providers"))...)
+	errs = append(errs, validateCheck(instance.Spec.Check, specPath.Child("check"))...)
+	errs = append(errs, validateUptimeRobotConfig(instance.Spec.UptimeRobotConfig, specPath.Child("uptimeRobotConfig"))...)
+	errs = append(errs, validatePingdomConfig(instance.Spec.PingdomConfig, specPath.Child("pingdomConfig"))...)
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(endpointmonitorv1alpha1.GroupVersion.WithKind("EndpointMonitor").GroupKind(), instance.Name, errs)
+}
+
+// validateURL requires exactly one of url and urlFrom, and exactly one reference in urlFrom
+func validateURL(spec *endpointmonitorv1alpha1.EndpointMonitorSpec, specPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	urlFrom := spec.URLFrom
+	if len(spec.URL) != 0 && urlFrom != nil {
+		return append(errs, field.Forbidden(specPath.Child("urlFrom"), "url and urlFrom are mutually exclusive"))
+	}
+	if len(spec.URL) == 0 && urlFrom == nil {
+		return append(errs, field.Required(specPath.Child("url"), "either url or urlFrom must be set"))
+	}
+	if urlFrom == nil {
+		return errs
+	}
+
+	var refs []string
+	if urlFrom.IngressRef != nil {
+		refs = append(refs, "ingressRef")
+	}
+	if urlFrom.RouteRef != nil {
+		refs = append(refs, "routeRef")
+	}
+	if urlFrom.HTTPRouteRef != nil {
+		refs = append(refs, "httpRouteRef")
+	}
+	if urlFrom.ServiceRef != nil {
+		refs = append(refs, "serviceRef")
+	}
+	switch {
+	case len(refs) == 0:
+		errs = append(errs, field.Required(specPath.Child("urlFrom"), "one of ingressRef, routeRef, httpRouteRef or serviceRef must be set"))
+	case len(refs) > 1:
+		errs = append(errs, field.Forbidden(specPath.Child("urlFrom"), "only one reference may be set, got "+strings.Join(refs, ", ")))
+	}
+	return errs
+}
+
+// validateProviders rejects duplicate providers and providers that aren't configured for the controller. Providers
+// of a credentials secret are only known when the monitor is synced, they are not checked.
+func (w *EndpointMonitorWebhook) validateProviders(spec *endpointmonitorv1alpha1.EndpointMonitorSpec, providersPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	seen := make(map[string]bool)
+	for index, provider := range spec.Providers {
+		if seen[provider] {
+			errs = append(errs, field.Duplicate(providersPath.Index(index), provider))
+		}
+		seen[provider] = true
+	}
+
+	if spec.CredentialsRef != nil || w.MonitorServices == nil {
+		return errs
+	}
+	monitorServices := w.MonitorServices.Get()
+	if len(monitorServices) == 0 {
+		// The configuration of the controller is invalid or not loaded yet
+		return errs
+	}
+	configured := make(map[string]bool)
+	var names []string
+	for index := range monitorServices {
+		configured[monitorServices[index].GetName()] = true
+		names = append(names, monitorServices[index].GetName())
+	}
+	for index, provider := range spec.Providers {
+		if !configured[provider] && !w.MonitorServices.IsUnavailable(provider) {
+			errs = append(errs, field.NotSupported(providersPath.Index(index), provider, names))
+		}
+	}
+	return errs
+}
+
+func validateCheck(check *endpointmonitorv1alpha1.CheckConfig, checkPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if check == nil {
+		return errs
+	}
+	if check.Type == endpointmonitorv1alpha1.CheckTypeKeyword && (check.Keyword == nil || len(check.Keyword.Value) == 0) {
+		errs = append(errs, field.Required(checkPath.Child("keyword", "value"), "keyword checks require a keyword"))
+	}
+	for index, statusCodes := range check.ExpectedStatusCodes {
+		if from, to := statusCodes.Bounds(); from == 0 || from > to {
+			errs = append(errs, field.Invalid(checkPath.Child("expectedStatusCodes").Index(index), statusCodes, "invalid range of status codes"))
+		}
+	}
+	return errs
+}
+
+func validateUptimeRobotConfig(config *endpointmonitorv1alpha1.UptimeRobotConfig, configPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if config == nil {
+		return errs
+	}
+	if strings.EqualFold(config.MonitorType, "keyword") && len(config.KeywordValue) == 0 {
+		errs = append(errs, field.Required(configPath.Child("keywordValue"), "monitorType keyword requires a keywordValue"))
+	}
+	return errs
+}
+
+func validatePingdomConfig(config *endpointmonitorv1alpha1.PingdomConfig, configPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if config == nil {
+		return errs
+	}
+	if len(config.RequestHeaders) != 0 {
+		headers := make(map[string]string)
+		if err := json.Unmarshal([]byte(config.RequestHeaders), &headers); err != nil {
+			errs = append(errs, field.Invalid(configPath.Child("requestHeaders"), config.RequestHeaders,
+				"must be a JSON object of header names and values: "+err.Error()))
+		}
+	}
+
+	ids := []struct {
+		name  string
+		value string
+	}{
+		{"alertContacts", config.AlertContacts},
+		{"alertIntegrations", config.AlertIntegrations},
+		{"teamAlertContacts", config.TeamAlertContacts},
+	}
+	for _, id := range ids {
+		if len(id.value) == 0 {
+			continue
+		}
+		if _, err := util.SliceAtoi(strings.Split(id.value, "-")); err != nil {
+			errs = append(errs, field.Invalid(configPath.Child(id.name), id.value, "must be numeric IDs separated by -"))
+		}
+	}
+	return errs
+}
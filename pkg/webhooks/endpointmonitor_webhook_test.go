@@ -0,0 +1,149 @@
+package webhooks
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	endpointmonitorv1alpha1 "github.com/stakater/IngressMonitorController/v2/api/v1alpha1"
+	"github.com/stakater/IngressMonitorController/v2/pkg/config"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors"
+	"github.com/stakater/IngressMonitorController/v2/pkg/monitors/uptimerobot"
+)
+
+func createEndpointMonitor(spec endpointmonitorv1alpha1.EndpointMonitorSpec) *endpointmonitorv1alpha1.EndpointMonitor {
+	return &endpointmonitorv1alpha1.EndpointMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "test"},
+		Spec:       spec,
+	}
+}
+
+func createWebhook(t *testing.T) *EndpointMonitorWebhook {
+	services, err := monitors.SetupMonitorServicesForProviders([]config.Provider{
+		{Name: "uptimerobot", Type: "UptimeRobot"},
+		{Name: "pingdom", Type: "Pingdom"},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error: " + err.Error())
+	}
+	registry := &monitors.MonitorServiceRegistry{}
+	registry.Set(services)
+	return &EndpointMonitorWebhook{MonitorServices: registry}
+}
+
+func TestEndpointMonitorWebhook_Validate(t *testing.T) {
+	urlFrom := &endpointmonitorv1alpha1.URLSource{IngressRef: &endpointmonitorv1alpha1.IngressURLSource{Name: "frontend"}}
+	tests := []struct {
+		name    string
+		spec    endpointmonitorv1alpha1.EndpointMonitorSpec
+		wantErr string
+	}{
+		{
+			name: "TestValidSpec",
+			spec: endpointmonitorv1alpha1.EndpointMonitorSpec{URL: "https://stakater.com", Providers: []string{"pingdom"}},
+		},
+		{
+			name:    "TestURLAndURLFrom",
+			spec:    endpointmonitorv1alpha1.EndpointMonitorSpec{URL: "https://stakater.com", URLFrom: urlFrom},
+			wantErr: "url and urlFrom are mutually exclusive",
+		},
+		{
+			name:    "TestNoURL",
+			spec:    endpointmonitorv1alpha1.EndpointMonitorSpec{},
+			wantErr: "either url or urlFrom must be set",
+		},
+		{
+			name:    "TestUnknownProvider",
+			spec:    endpointmonitorv1alpha1.EndpointMonitorSpec{URL: "https://stakater.com", Providers: []string{"statuscake"}},
+			wantErr: `spec.providers[0]: Unsupported value: "statuscake"`,
+		},
+		{
+			name: "TestProviderOfCredentialsRef",
+			spec: endpointmonitorv1alpha1.EndpointMonitorSpec{URL: "https://stakater.com", Providers: []string{"statuscake"},
+				CredentialsRef: &endpointmonitorv1alpha1.CredentialsReference{Name: "credentials"}},
+		},
+		{
+			name: "TestMalformedPingdomRequestHeaders",
+			spec: endpointmonitorv1alpha1.EndpointMonitorSpec{URL: "https://stakater.com",
+				PingdomConfig: &endpointmonitorv1alpha1.PingdomConfig{RequestHeaders: "Accept: text/html"}},
+			wantErr: "spec.pingdomConfig.requestHeaders",
+		},
+		{
+			name: "TestNonNumericPingdomContacts",
+			spec: endpointmonitorv1alpha1.EndpointMonitorSpec{URL: "https://stakater.com",
+				PingdomConfig: &endpointmonitorv1alpha1.PingdomConfig{AlertContacts: "1234-abc"}},
+			wantErr: "spec.pingdomConfig.alertContacts",
+		},
+		{
+			name: "TestUptimeRobotKeywordWithoutValue",
+			spec: endpointmonitorv1alpha1.EndpointMonitorSpec{URL: "https://stakater.com",
+				UptimeRobotConfig: &endpointmonitorv1alpha1.UptimeRobotConfig{MonitorType: "keyword"}},
+			wantErr: "spec.uptimeRobotConfig.keywordValue",
+		},
+	}
+	w := createWebhook(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := w.ValidateCreate(context.Background(), createEndpointMonitor(tt.spec))
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("ValidateCreate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateCreate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEndpointMonitorWebhook_ValidateUpdateWithUnchangedSpec(t *testing.T) {
+	w := createWebhook(t)
+	invalid := createEndpointMonitor(endpointmonitorv1alpha1.EndpointMonitorSpec{})
+	updated := invalid.DeepCopy()
+	updated.Finalizers = nil
+
+	if err := w.ValidateUpdate(context.Background(), invalid, updated); err != nil {
+		t.Errorf("ValidateUpdate() unexpected error = %v", err)
+	}
+}
+
+func TestEndpointMonitorWebhook_Default(t *testing.T) {
+	w := createWebhook(t)
+	instance := createEndpointMonitor(endpointmonitorv1alpha1.EndpointMonitorSpec{
+		URL:               "https://stakater.com",
+		Check:             &endpointmonitorv1alpha1.CheckConfig{Keyword: &endpointmonitorv1alpha1.KeywordCheck{Value: "stakater"}},
+		UptimeRobotConfig: &endpointmonitorv1alpha1.UptimeRobotConfig{},
+	})
+
+	if err := w.Default(context.Background(), instance); err != nil {
+		t.Fatalf("Default() unexpected error = %v", err)
+	}
+	if instance.Spec.Check.Type != endpointmonitorv1alpha1.CheckTypeKeyword {
+		t.Errorf("Check type should be defaulted to Keyword, got %s", instance.Spec.Check.Type)
+	}
+	if instance.Spec.Check.Keyword.Condition != endpointmonitorv1alpha1.KeywordPresent {
+		t.Errorf("Keyword condition should be defaulted to Present, got %s", instance.Spec.Check.Keyword.Condition)
+	}
+	if instance.Spec.UptimeRobotConfig.Interval != uptimerobot.DefaultInterval {
+		t.Errorf("UptimeRobot interval should be defaulted to %d, got %d", uptimerobot.DefaultInterval, instance.Spec.UptimeRobotConfig.Interval)
+	}
+}
+
+func TestEndpointMonitorWebhook_DefaultKeepsCheckTypeOfServiceRef(t *testing.T) {
+	w := createWebhook(t)
+	instance := createEndpointMonitor(endpointmonitorv1alpha1.EndpointMonitorSpec{
+		URLFrom: &endpointmonitorv1alpha1.URLSource{ServiceRef: &endpointmonitorv1alpha1.ServiceURLSource{Name: "postgres"}},
+		Check:   &endpointmonitorv1alpha1.CheckConfig{},
+	})
+
+	if err := w.Default(context.Background(), instance); err != nil {
+		t.Fatalf("Default() unexpected error = %v", err)
+	}
+	if len(instance.Spec.Check.Type) != 0 {
+		t.Errorf("Check type of a serviceRef should be left to the controller, got %s", instance.Spec.Check.Type)
+	}
+}